@@ -72,7 +72,12 @@ func (m *MockClient) mockZones() {
 				m.getZone(w, zoneName)
 			}
 		case http.MethodPost:
-			m.createZone(w, r)
+			if len(parts) == 3 && parts[2] == "recordsets" {
+				// /zones/<zoneid>/recordsets
+				m.createRecordSet(w, r, zoneID)
+			} else {
+				m.createZone(w, r)
+			}
 		case http.MethodDelete:
 			m.deleteZone(w, zoneID)
 		default:
@@ -205,3 +210,37 @@ func (m *MockClient) getRecordSet(w http.ResponseWriter, zoneID, recordSetID str
 		w.WriteHeader(http.StatusNotFound)
 	}
 }
+
+func (m *MockClient) createRecordSet(w http.ResponseWriter, r *http.Request, zoneID string) {
+	if _, ok := m.zones[zoneID]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var create recordsets.CreateOpts
+	err := json.NewDecoder(r.Body).Decode(&create)
+	if err != nil {
+		panic("error decoding create recordset request")
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+
+	record := recordsets.RecordSet{
+		ID:          uuid.New().String(),
+		ZoneID:      zoneID,
+		Name:        create.Name,
+		Description: create.Description,
+		Records:     create.Records,
+		TTL:         create.TTL,
+		Type:        create.Type,
+	}
+	m.recordSets[record.ID] = record
+
+	respB, err := json.Marshal(record)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", record))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
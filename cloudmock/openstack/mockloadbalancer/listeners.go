@@ -120,6 +120,7 @@ func (m *MockClient) createListener(w http.ResponseWriter, r *http.Request) {
 		Protocol:      string(create.Listener.Protocol),
 		ProtocolPort:  create.Listener.ProtocolPort,
 		AllowedCIDRs:  create.Listener.AllowedCIDRs,
+		InsertHeaders: create.Listener.InsertHeaders,
 	}
 	m.listeners[l.ID] = l
 
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/listeners"
@@ -37,10 +38,22 @@ type loadbalancerGetResponse struct {
 	LoadBalancer loadbalancers.LoadBalancer `json:"loadbalancer"`
 }
 
+type loadbalancerStatusesResponse struct {
+	Statuses loadbalancers.StatusTree `json:"statuses"`
+}
+
+type loadbalancerStatsResponse struct {
+	Stats loadbalancers.Stats `json:"stats"`
+}
+
 type loadbalancerCreateRequest struct {
 	LoadBalancer loadbalancers.CreateOpts `json:"loadbalancer"`
 }
 
+type loadbalancerUpdateRequest struct {
+	LoadBalancer loadbalancers.UpdateOpts `json:"loadbalancer"`
+}
+
 func (m *MockClient) mockLoadBalancers() {
 	re := regexp.MustCompile(`/lbaas/loadbalancers/?`)
 
@@ -57,11 +70,17 @@ func (m *MockClient) mockLoadBalancers() {
 			if loadbalancerID == "" {
 				r.ParseForm()
 				m.listLoadBalancers(w, r.Form)
+			} else if id, ok := strings.CutSuffix(loadbalancerID, "/status"); ok {
+				m.getLoadBalancerStatuses(w, id)
+			} else if id, ok := strings.CutSuffix(loadbalancerID, "/stats"); ok {
+				m.getLoadBalancerStats(w, id)
 			} else {
 				m.getLoadBalancer(w, loadbalancerID)
 			}
 		case http.MethodPost:
 			m.createLoadBalancer(w, r)
+		case http.MethodPut:
+			m.updateLoadBalancer(w, r, loadbalancerID)
 		case http.MethodDelete:
 			m.deleteLoadBalancer(w, loadbalancerID)
 		default:
@@ -119,6 +138,46 @@ func (m *MockClient) getLoadBalancer(w http.ResponseWriter, loadbalancerID strin
 	}
 }
 
+// getLoadBalancerStatuses returns the loadbalancer with its listeners and pools as status tree
+func (m *MockClient) getLoadBalancerStatuses(w http.ResponseWriter, loadbalancerID string) {
+	loadbalancer, ok := m.loadbalancers[loadbalancerID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	lb := populateLB(loadbalancer, m.pools, m.listeners)
+	resp := loadbalancerStatusesResponse{
+		Statuses: loadbalancers.StatusTree{
+			Loadbalancer: &lb,
+		},
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
+
+// getLoadBalancerStats returns empty statistics for the loadbalancer
+func (m *MockClient) getLoadBalancerStats(w http.ResponseWriter, loadbalancerID string) {
+	if _, ok := m.loadbalancers[loadbalancerID]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	resp := loadbalancerStatsResponse{}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
+
 func (m *MockClient) deleteLoadBalancer(w http.ResponseWriter, loadbalancerID string) {
 	if _, ok := m.loadbalancers[loadbalancerID]; ok {
 		delete(m.loadbalancers, loadbalancerID)
@@ -140,8 +199,14 @@ func (m *MockClient) createLoadBalancer(w http.ResponseWriter, r *http.Request)
 		ID:                 uuid.New().String(),
 		Name:               create.LoadBalancer.Name,
 		VipSubnetID:        create.LoadBalancer.VipSubnetID,
+		VipAddress:         create.LoadBalancer.VipAddress,
+		VipPortID:          create.LoadBalancer.VipPortID,
+		Tags:               create.LoadBalancer.Tags,
+		AdminStateUp:       create.LoadBalancer.AdminStateUp == nil || *create.LoadBalancer.AdminStateUp,
+		Provider:           create.LoadBalancer.Provider,
+		FlavorID:           create.LoadBalancer.FlavorID,
 		ProvisioningStatus: "ACTIVE",
-		// TODO: create a Port and set VipPortID
+		// TODO: create a Port and set VipPortID if it is not set
 	}
 	m.loadbalancers[l.ID] = l
 
@@ -158,6 +223,46 @@ func (m *MockClient) createLoadBalancer(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// updateLoadBalancer updates the name, admin state, QoS policy and tags of the loadbalancer
+func (m *MockClient) updateLoadBalancer(w http.ResponseWriter, r *http.Request, loadbalancerID string) {
+	l, ok := m.loadbalancers[loadbalancerID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var update loadbalancerUpdateRequest
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		panic("error decoding update loadbalancer request")
+	}
+	if update.LoadBalancer.Name != nil {
+		l.Name = *update.LoadBalancer.Name
+	}
+	if update.LoadBalancer.AdminStateUp != nil {
+		l.AdminStateUp = *update.LoadBalancer.AdminStateUp
+	}
+	if update.LoadBalancer.VipQosPolicyID != nil {
+		l.VipQosPolicyID = *update.LoadBalancer.VipQosPolicyID
+	}
+	if update.LoadBalancer.Tags != nil {
+		l.Tags = *update.LoadBalancer.Tags
+	}
+	m.loadbalancers[l.ID] = l
+
+	w.WriteHeader(http.StatusOK)
+	resp := loadbalancerGetResponse{
+		LoadBalancer: l,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
+
 func populateLB(lb loadbalancers.LoadBalancer, lbPools map[string]pools.Pool, lbListeners map[string]listeners.Listener) loadbalancers.LoadBalancer {
 	lb.Pools = make([]pools.Pool, 0)
 	for _, p := range lbPools {
@@ -42,6 +42,7 @@ type MockClient struct {
 	securityGroups     map[string]groups.SecGroup
 	securityGroupRules map[string]rules.SecGroupRule
 	subnets            map[string]subnets.Subnet
+	subnetSegments     map[string]string
 	floatingips        map[string]floatingips.FloatingIP
 }
 
@@ -70,6 +71,7 @@ func (m *MockClient) Reset() {
 	m.securityGroups = make(map[string]groups.SecGroup)
 	m.securityGroupRules = make(map[string]rules.SecGroupRule)
 	m.subnets = make(map[string]subnets.Subnet)
+	m.subnetSegments = make(map[string]string)
 	m.floatingips = make(map[string]floatingips.FloatingIP)
 }
 
@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"regexp"
 
+	"github.com/google/uuid"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 )
 
@@ -30,6 +31,18 @@ type floatingIPListResponse struct {
 	FloatingIPs []floatingips.FloatingIP `json:"floatingips"`
 }
 
+type floatingIPGetResponse struct {
+	FloatingIP floatingips.FloatingIP `json:"floatingip"`
+}
+
+type floatingIPCreateRequest struct {
+	FloatingIP floatingips.CreateOpts `json:"floatingip"`
+}
+
+type floatingIPUpdateRequest struct {
+	FloatingIP floatingips.UpdateOpts `json:"floatingip"`
+}
+
 func (m *MockClient) mockFloatingIPs() {
 	re := regexp.MustCompile(`/floatingips/?`)
 
@@ -46,6 +59,10 @@ func (m *MockClient) mockFloatingIPs() {
 				r.ParseForm()
 				m.listFloatingIPs(w, r.Form)
 			}
+		case http.MethodPost:
+			m.createFloatingIP(w, r)
+		case http.MethodPut:
+			m.updateFloatingIP(w, r, floatingIPID)
 		default:
 			w.WriteHeader(http.StatusBadRequest)
 		}
@@ -59,6 +76,9 @@ func (m *MockClient) listFloatingIPs(w http.ResponseWriter, vals url.Values) {
 
 	floatingips := make([]floatingips.FloatingIP, 0)
 	for _, p := range m.floatingips {
+		if portID := vals.Get("port_id"); portID != "" && portID != p.PortID {
+			continue
+		}
 		floatingips = append(floatingips, p)
 	}
 	resp := floatingIPListResponse{
@@ -73,3 +93,64 @@ func (m *MockClient) listFloatingIPs(w http.ResponseWriter, vals url.Values) {
 		panic("failed to write body")
 	}
 }
+
+func (m *MockClient) createFloatingIP(w http.ResponseWriter, r *http.Request) {
+	var create floatingIPCreateRequest
+	err := json.NewDecoder(r.Body).Decode(&create)
+	if err != nil {
+		panic("error decoding create floating IP request")
+	}
+	w.WriteHeader(http.StatusCreated)
+
+	f := floatingips.FloatingIP{
+		ID:                uuid.New().String(),
+		FloatingNetworkID: create.FloatingIP.FloatingNetworkID,
+		FloatingIP:        create.FloatingIP.FloatingIP,
+		PortID:            create.FloatingIP.PortID,
+		Description:       create.FloatingIP.Description,
+	}
+	m.floatingips[f.ID] = f
+
+	resp := floatingIPGetResponse{
+		FloatingIP: f,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
+
+// updateFloatingIP associates the floating IP with another port
+func (m *MockClient) updateFloatingIP(w http.ResponseWriter, r *http.Request, floatingIPID string) {
+	f, ok := m.floatingips[floatingIPID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var update floatingIPUpdateRequest
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		panic("error decoding update floating IP request")
+	}
+	if update.FloatingIP.PortID != nil {
+		f.PortID = *update.FloatingIP.PortID
+	}
+	m.floatingips[f.ID] = f
+
+	w.WriteHeader(http.StatusOK)
+	resp := floatingIPGetResponse{
+		FloatingIP: f,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
+}
@@ -137,7 +137,7 @@ func (m *MockClient) deleteNetwork(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
-	if len(parts) == 4 && parts[3] == "tags" {
+	if len(parts) == 4 && parts[2] == "tags" {
 		// /networks/<networkid>/tags/<tag>
 		tagToDelete := parts[3]
 		network := m.networks[networkID]
@@ -148,11 +148,12 @@ func (m *MockClient) deleteNetwork(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		network.Tags = tags
+		m.networks[networkID] = network
 	} else {
 		// /networks/<networkid>
 		delete(m.networks, networkID)
 	}
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (m *MockClient) createNetwork(w http.ResponseWriter, r *http.Request) {
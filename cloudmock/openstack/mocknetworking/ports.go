@@ -159,10 +159,15 @@ func (m *MockClient) createPort(w http.ResponseWriter, r *http.Request) {
 	if rawIPs, ok := create.Port.FixedIPs.([]interface{}); ok {
 		for _, rawFixedIP := range rawIPs {
 			if rawIP, ok := rawFixedIP.(map[string]interface{}); ok {
-				if subnetID, ok := rawIP["subnet_id"]; ok {
-					if subnet, ok := subnetID.(string); ok {
-						fixedIPs = append(fixedIPs, ports.IP{SubnetID: subnet})
-					}
+				fixedIP := ports.IP{}
+				if subnetID, ok := rawIP["subnet_id"].(string); ok {
+					fixedIP.SubnetID = subnetID
+				}
+				if ipAddress, ok := rawIP["ip_address"].(string); ok {
+					fixedIP.IPAddress = ipAddress
+				}
+				if fixedIP.SubnetID != "" || fixedIP.IPAddress != "" {
+					fixedIPs = append(fixedIPs, fixedIP)
 				}
 			}
 		}
@@ -240,7 +245,22 @@ func (m *MockClient) updatePort(w http.ResponseWriter, r *http.Request) {
 	if deviceID != nil {
 		port.DeviceID = fi.ValueOf(deviceID)
 	}
+	if update.Port.SecurityGroups != nil {
+		port.SecurityGroups = *update.Port.SecurityGroups
+	}
 	m.ports[portID] = port
 
 	w.WriteHeader(http.StatusOK)
+
+	resp := portGetResponse{
+		Port: port,
+	}
+	respB, err := json.Marshal(resp)
+	if err != nil {
+		panic(fmt.Sprintf("failed to marshal %+v", resp))
+	}
+	_, err = w.Write(respB)
+	if err != nil {
+		panic("failed to write body")
+	}
 }
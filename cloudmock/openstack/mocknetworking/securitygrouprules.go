@@ -133,6 +133,7 @@ func (m *MockClient) createSecurityGroupRule(w http.ResponseWriter, r *http.Requ
 		RemoteGroupID:  create.SecurityGroupRule.RemoteGroupID,
 		Direction:      string(create.SecurityGroupRule.Direction),
 		SecGroupID:     create.SecurityGroupRule.SecGroupID,
+		Description:    create.SecurityGroupRule.Description,
 	}
 	m.securityGroupRules[rule.ID] = rule
 
@@ -74,10 +74,14 @@ func (m *MockClient) listSecurityGroups(w http.ResponseWriter, vals url.Values)
 
 	sgs := make([]groups.SecGroup, 0)
 	nameFilter := vals.Get("name")
+	idFilter := vals.Get("id")
 	for _, s := range m.securityGroups {
 		if nameFilter != "" && s.Name != nameFilter {
 			continue
 		}
+		if idFilter != "" && s.ID != idFilter {
+			continue
+		}
 		sgs = append(sgs, s)
 	}
 
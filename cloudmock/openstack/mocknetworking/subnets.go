@@ -34,11 +34,20 @@ type subnetListResponse struct {
 }
 
 type subnetGetResponse struct {
-	Subnet subnets.Subnet `json:"subnet"`
+	Subnet segmentSubnet `json:"subnet"`
+}
+
+// segmentSubnet is a subnet with the segment of the routed network, which is not supported by subnets.Subnet
+type segmentSubnet struct {
+	subnets.Subnet
+	SegmentID *string `json:"segment_id"`
 }
 
 type subnetCreateRequest struct {
-	Subnet subnets.CreateOpts `json:"subnet"`
+	Subnet struct {
+		subnets.CreateOpts
+		SegmentID string `json:"segment_id"`
+	} `json:"subnet"`
 }
 
 func (m *MockClient) mockSubnets() {
@@ -94,7 +103,7 @@ func (m *MockClient) listSubnets(w http.ResponseWriter, vals url.Values) {
 func (m *MockClient) getSubnet(w http.ResponseWriter, subnetID string) {
 	if subnet, ok := m.subnets[subnetID]; ok {
 		resp := subnetGetResponse{
-			Subnet: subnet,
+			Subnet: m.segmentSubnet(subnet),
 		}
 		respB, err := json.Marshal(resp)
 		if err != nil {
@@ -109,9 +118,18 @@ func (m *MockClient) getSubnet(w http.ResponseWriter, subnetID string) {
 	}
 }
 
+func (m *MockClient) segmentSubnet(subnet subnets.Subnet) segmentSubnet {
+	s := segmentSubnet{Subnet: subnet}
+	if segmentID, ok := m.subnetSegments[subnet.ID]; ok {
+		s.SegmentID = &segmentID
+	}
+	return s
+}
+
 func (m *MockClient) deleteSubnet(w http.ResponseWriter, subnetID string) {
 	if _, ok := m.subnets[subnetID]; ok {
 		delete(m.subnets, subnetID)
+		delete(m.subnetSegments, subnetID)
 		w.WriteHeader(http.StatusOK)
 	} else {
 		w.WriteHeader(http.StatusNotFound)
@@ -128,19 +146,25 @@ func (m *MockClient) createSubnet(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 
 	subnet := subnets.Subnet{
-		ID:             uuid.New().String(),
-		Name:           create.Subnet.Name,
-		Description:    create.Subnet.Description,
-		NetworkID:      create.Subnet.NetworkID,
-		CIDR:           create.Subnet.CIDR,
-		DNSNameservers: create.Subnet.DNSNameservers,
-		EnableDHCP:     *create.Subnet.EnableDHCP,
-		IPVersion:      int(create.Subnet.IPVersion),
+		ID:              uuid.New().String(),
+		Name:            create.Subnet.Name,
+		Description:     create.Subnet.Description,
+		NetworkID:       create.Subnet.NetworkID,
+		CIDR:            create.Subnet.CIDR,
+		DNSNameservers:  create.Subnet.DNSNameservers,
+		EnableDHCP:      *create.Subnet.EnableDHCP,
+		IPVersion:       int(create.Subnet.IPVersion),
+		AllocationPools: create.Subnet.AllocationPools,
+		IPv6AddressMode: create.Subnet.IPv6AddressMode,
+		IPv6RAMode:      create.Subnet.IPv6RAMode,
 	}
 	m.subnets[subnet.ID] = subnet
+	if create.Subnet.SegmentID != "" {
+		m.subnetSegments[subnet.ID] = create.Subnet.SegmentID
+	}
 
 	resp := subnetGetResponse{
-		Subnet: subnet,
+		Subnet: m.segmentSubnet(subnet),
 	}
 	respB, err := json.Marshal(resp)
 	if err != nil {
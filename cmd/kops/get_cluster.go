@@ -54,6 +54,9 @@ var (
 
 	# Save a cluster desired configuration to YAML file
 	kops get cluster k8s-cluster.example.com -o yaml > cluster-desired-config.yaml
+
+	# Display the address of the API loadbalancer of an OpenStack cluster
+	kops get cluster k8s-cluster.example.com --show-lb-endpoint
 	`))
 
 	getClusterShort = i18n.T(`Get one or many clusters.`)
@@ -82,6 +85,9 @@ type GetClusterOptions struct {
 
 	// ClusterNames is a list of cluster names to show; if not specified all clusters will be shown
 	ClusterNames []string
+
+	// ShowLBEndpoint determines if we should output the endpoint of the API loadbalancer instead of the clusters
+	ShowLBEndpoint bool
 }
 
 func NewCmdGetCluster(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
@@ -114,6 +120,7 @@ func NewCmdGetCluster(f *util.Factory, out io.Writer, getOptions *GetOptions) *c
 	}
 
 	cmd.Flags().BoolVar(&options.FullSpec, "full", options.FullSpec, "Show fully populated configuration")
+	cmd.Flags().BoolVar(&options.ShowLBEndpoint, "show-lb-endpoint", options.ShowLBEndpoint, "Show the VIP and floating IPs of the API loadbalancer of OpenStack clusters")
 
 	return cmd
 }
@@ -156,6 +163,13 @@ func RunGetClusters(ctx context.Context, f commandutils.Factory, out io.Writer,
 		return fmt.Errorf("no clusters found")
 	}
 
+	if options.ShowLBEndpoint {
+		if options.FullSpec {
+			return fmt.Errorf("cannot use --full with --show-lb-endpoint")
+		}
+		return RunGetClusterLBEndpoints(out, options.Output, clusters)
+	}
+
 	if options.FullSpec {
 		var err error
 		clusters, err = fullClusterSpecs(ctx, client.VFSContext(), clusters)
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
+	"k8s.io/kops/util/pkg/tables"
+	"sigs.k8s.io/yaml"
+)
+
+type renderableLBEndpoint struct {
+	Cluster string `json:"cluster"`
+	openstacktasks.LBEndpoint
+}
+
+// RunGetClusterLBEndpoints outputs the endpoints of the API loadbalancers of the clusters, which must be OpenStack
+// clusters with a loadbalancer
+func RunGetClusterLBEndpoints(out io.Writer, output string, clusters []*kopsapi.Cluster) error {
+	var endpoints []*renderableLBEndpoint
+	for _, cluster := range clusters {
+		lbTask, err := apiLBTask(cluster)
+		if err != nil {
+			return err
+		}
+
+		cloud, err := cloudup.BuildCloud(cluster)
+		if err != nil {
+			return err
+		}
+		endpoint, err := openstacktasks.FindLBEndpoint(cloud.(openstack.OpenstackCloud), lbTask)
+		if err != nil {
+			return err
+		}
+		if endpoint == nil {
+			return fmt.Errorf("loadbalancer %s of cluster %q not found", fi.ValueOf(lbTask.Name), cluster.ObjectMeta.Name)
+		}
+		endpoints = append(endpoints, &renderableLBEndpoint{
+			Cluster:    cluster.ObjectMeta.Name,
+			LBEndpoint: *endpoint,
+		})
+	}
+
+	switch output {
+	case OutputTable:
+		t := &tables.Table{}
+		t.AddColumn("NAME", func(e *renderableLBEndpoint) string {
+			return e.Cluster
+		})
+		t.AddColumn("LOADBALANCER", func(e *renderableLBEndpoint) string {
+			return e.Name
+		})
+		t.AddColumn("VIP", func(e *renderableLBEndpoint) string {
+			return e.VIP
+		})
+		t.AddColumn("FLOATING-IPS", func(e *renderableLBEndpoint) string {
+			return strings.Join(e.FloatingIPs, ",")
+		})
+		return t.Render(endpoints, out, "NAME", "LOADBALANCER", "VIP", "FLOATING-IPS")
+	case OutputYaml:
+		y, err := yaml.Marshal(endpoints)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+		return nil
+	case OutputJSON:
+		j, err := json.Marshal(endpoints)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format: %q", output)
+	}
+}
+
+// apiLBTask returns the task of the API loadbalancer of the cluster, named as in the OpenStack model
+func apiLBTask(cluster *kopsapi.Cluster) (*openstacktasks.LB, error) {
+	if cluster.Spec.GetCloudProvider() != kopsapi.CloudProviderOpenstack {
+		return nil, fmt.Errorf("--show-lb-endpoint is only supported for OpenStack clusters, cluster %q uses %s", cluster.ObjectMeta.Name, cluster.Spec.GetCloudProvider())
+	}
+	lbSpec := cluster.Spec.CloudProvider.Openstack.Loadbalancer
+	if lbSpec == nil {
+		return nil, fmt.Errorf("cluster %q has no API loadbalancer", cluster.ObjectMeta.Name)
+	}
+
+	lbTask := &openstacktasks.LB{
+		Name:      fi.PtrTo("api." + cluster.ObjectMeta.Name),
+		Lifecycle: fi.LifecycleSync,
+	}
+	if cluster.Spec.API.PublicName != "" {
+		lbTask.Name = fi.PtrTo(cluster.Spec.API.PublicName)
+	}
+	if lbSpec.ID != nil {
+		lbTask.ID = lbSpec.ID
+		lbTask.Lifecycle = fi.LifecycleExistsAndWarnIfChanges
+	}
+	return lbTask, nil
+}
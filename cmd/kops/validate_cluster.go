@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
@@ -68,6 +70,7 @@ type ValidateClusterOptions struct {
 	count       int
 	interval    time.Duration
 	kubeconfig  string
+	lbStats     bool
 }
 
 func (o *ValidateClusterOptions) InitDefaults() {
@@ -109,6 +112,7 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().IntVar(&options.count, "count", options.count, "Number of consecutive successful validations required")
 	cmd.Flags().DurationVar(&options.interval, "interval", options.interval, "Time in duration to wait between validation attempts")
 	cmd.Flags().StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	cmd.Flags().BoolVar(&options.lbStats, "lb-stats", options.lbStats, "Show the statistics of the API loadbalancer of OpenStack clusters, if supported by the provider")
 
 	return cmd
 }
@@ -168,7 +172,9 @@ func RunValidateCluster(ctx context.Context, f *util.Factory, out io.Writer, opt
 
 	timeout := time.Now().Add(options.wait)
 
-	validator, err := validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
+	validator, err := validation.NewClusterValidatorWithOptions(cluster, cloud, list, config.Host, k8sClient, validation.ClusterValidatorOptions{
+		LoadBalancerStats: options.lbStats,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("unexpected error creating validatior: %v", err)
 	}
@@ -289,6 +295,73 @@ func validateClusterOutputTable(result *validation.ValidationCluster, cluster *k
 		}
 	}
 
+	if len(result.LoadBalancers) != 0 {
+		lbTable := &tables.Table{}
+		lbTable.AddColumn("NAME", func(lb *validation.ValidationLoadBalancer) string {
+			return lb.Name
+		})
+		lbTable.AddColumn("ID", func(lb *validation.ValidationLoadBalancer) string {
+			return lb.ID
+		})
+		lbTable.AddColumn("PROVISIONING", func(lb *validation.ValidationLoadBalancer) string {
+			return lb.ProvisioningStatus
+		})
+		lbTable.AddColumn("OPERATING", func(lb *validation.ValidationLoadBalancer) string {
+			return lb.OperatingStatus
+		})
+		// the age and time since the last change help to spot stale or recently recreated loadbalancers
+		lbTable.AddColumn("AGE", func(lb *validation.ValidationLoadBalancer) string {
+			if lb.CreatedAt == nil {
+				return ""
+			}
+			return duration.HumanDuration(time.Since(lb.CreatedAt.Time))
+		})
+		lbTable.AddColumn("UPDATED", func(lb *validation.ValidationLoadBalancer) string {
+			if lb.UpdatedAt == nil {
+				return ""
+			}
+			return duration.HumanDuration(time.Since(lb.UpdatedAt.Time))
+		})
+
+		columns := []string{"NAME", "ID", "PROVISIONING", "OPERATING", "AGE", "UPDATED"}
+		// the statistics are only shown if requested, loadbalancers without statistics are shown without values
+		for _, lb := range result.LoadBalancers {
+			if lb.Stats != nil {
+				lbTable.AddColumn("ACTIVE-CONNECTIONS", func(lb *validation.ValidationLoadBalancer) string {
+					if lb.Stats == nil {
+						return ""
+					}
+					return strconv.Itoa(lb.Stats.ActiveConnections)
+				})
+				lbTable.AddColumn("TOTAL-CONNECTIONS", func(lb *validation.ValidationLoadBalancer) string {
+					if lb.Stats == nil {
+						return ""
+					}
+					return strconv.Itoa(lb.Stats.TotalConnections)
+				})
+				lbTable.AddColumn("BYTES-IN", func(lb *validation.ValidationLoadBalancer) string {
+					if lb.Stats == nil {
+						return ""
+					}
+					return strconv.Itoa(lb.Stats.BytesIn)
+				})
+				lbTable.AddColumn("BYTES-OUT", func(lb *validation.ValidationLoadBalancer) string {
+					if lb.Stats == nil {
+						return ""
+					}
+					return strconv.Itoa(lb.Stats.BytesOut)
+				})
+				columns = append(columns, "ACTIVE-CONNECTIONS", "TOTAL-CONNECTIONS", "BYTES-IN", "BYTES-OUT")
+				break
+			}
+		}
+
+		fmt.Fprintln(out, "\nLOADBALANCER STATUS")
+		if err := lbTable.Render(result.LoadBalancers, out, columns...); err != nil {
+			return fmt.Errorf("cannot render loadbalancers for %q: %v", cluster.Name, err)
+		}
+	}
+
 	if len(result.Failures) != 0 {
 		failuresTable := &tables.Table{}
 		failuresTable.AddColumn("KIND", func(e *validation.ValidationError) string {
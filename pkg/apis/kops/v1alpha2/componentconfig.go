@@ -831,6 +831,59 @@ type OpenstackLoadbalancerConfig struct {
 	EnableIngressHostname *bool   `json:"enableIngressHostname,omitempty"`
 	IngressHostnameSuffix *string `json:"ingressHostnameSuffix,omitempty"`
 	FlavorID              *string `json:"flavorID,omitempty"`
+	// ID is the ID of an existing loadbalancer that is used for the API instead of creating one.
+	// kOps adds its listeners, pools and members to it, but never modifies or deletes the loadbalancer.
+	ID *string `json:"id,omitempty"`
+	// DeleteTimeout is the time to wait for the loadbalancer to be deleted together with the cluster, defaults to 5 minutes.
+	DeleteTimeout *metav1.Duration `json:"deleteTimeout,omitempty"`
+	// CascadeDelete deletes the listeners, pools and members together with the loadbalancer in a single request.
+	// Defaults to true when using Octavia.
+	CascadeDelete *bool `json:"cascadeDelete,omitempty"`
+	// VipQosPolicy is the name or ID of the Neutron QoS policy applied to the VIP port of the API loadbalancer.
+	VipQosPolicy *string `json:"vipQosPolicy,omitempty"`
+	// SkipActiveWait does not block while the API loadbalancer is provisioned. Its pool, listener and health monitor are
+	// created once it is ACTIVE, while the other resources of the cluster are created. Defaults to false.
+	SkipActiveWait *bool `json:"skipActiveWait,omitempty"`
+	// ManageVIPPortSecurityGroups removes all security groups that are not managed by kOps from the VIP port of the API
+	// loadbalancer. Octavia providers may attach their own security groups to the VIP port, only enable this if they are not needed.
+	ManageVIPPortSecurityGroups *bool `json:"manageVIPPortSecurityGroups,omitempty"`
+	// Region overrides the region of the loadbalancer API endpoint in the service catalog.
+	Region *string `json:"region,omitempty"`
+	// Endpoint is the URL of the loadbalancer API, it is used instead of the endpoint in the service catalog.
+	Endpoint *string `json:"endpoint,omitempty"`
+	// ConnLimit is the maximum number of concurrent connections of the API listener, -1 is unlimited.
+	ConnLimit *int `json:"connLimit,omitempty"`
+	// SessionPersistence is the session persistence of the API loadbalancer pool, it is disabled if not set.
+	SessionPersistence *OpenstackLoadbalancerSessionPersistence `json:"sessionPersistence,omitempty"`
+	// AdminStateUp is the administrative state of the API loadbalancer, setting it to false takes the VIP out of
+	// service, e.g. during maintenance. Defaults to true.
+	AdminStateUp *bool `json:"adminStateUp,omitempty"`
+	// TimeoutClientData is the idle timeout of the client connections of the API listener, e.g. of kubectl watches.
+	TimeoutClientData *metav1.Duration `json:"timeoutClientData,omitempty"`
+	// TimeoutMemberData is the idle timeout of the connections of the API listener to the control plane nodes.
+	TimeoutMemberData *metav1.Duration `json:"timeoutMemberData,omitempty"`
+	// TimeoutMemberConnect is the timeout of connecting to a control plane node.
+	TimeoutMemberConnect *metav1.Duration `json:"timeoutMemberConnect,omitempty"`
+	// TimeoutTCPInspect is the time the API listener waits for additional TCP packets for content inspection.
+	TimeoutTCPInspect *metav1.Duration `json:"timeoutTCPInspect,omitempty"`
+	// RecreateOnImmutableChange deletes and creates the API loadbalancer again if its VIP subnet, provider or flavor
+	// change, instead of failing the update. The VIP address changes, the floating IP is kept. Defaults to false.
+	RecreateOnImmutableChange *bool `json:"recreateOnImmutableChange,omitempty"`
+	// VipPortID is the ID of an existing Neutron port used as the VIP port of the API loadbalancer, e.g. with a fixed IP
+	// or allowed address pairs. kOps only changes its security groups if ManageVIPPortSecurityGroups is set.
+	VipPortID *string `json:"vipPortID,omitempty"`
+	// DNSRecordType is the type of the record of spec.api.publicName managed in Designate, A or AAAA. Defaults to A.
+	DNSRecordType *string `json:"dnsRecordType,omitempty"`
+	// DNSRecordTTL is the TTL in seconds of the record of spec.api.publicName managed in Designate. Defaults to 60.
+	DNSRecordTTL *int `json:"dnsRecordTTL,omitempty"`
+}
+
+// OpenstackLoadbalancerSessionPersistence defines the session persistence of a loadbalancer pool
+type OpenstackLoadbalancerSessionPersistence struct {
+	// Type is the persistence type, SOURCE_IP, HTTP_COOKIE or APP_COOKIE.
+	Type string `json:"type"`
+	// CookieName is the name of the application cookie, it is only used with APP_COOKIE.
+	CookieName string `json:"cookieName,omitempty"`
 }
 
 type OpenstackBlockStorageConfig struct {
@@ -874,6 +927,7 @@ type OpenstackNetwork struct {
 // OpenstackMetadata defines config for metadata service related settings
 type OpenstackMetadata struct {
 	// ConfigDrive specifies to use config drive for retrieving user data instead of the metadata service when launching instances
+	// Defaults to true if the cloud does not run a Neutron metadata agent.
 	ConfigDrive *bool `json:"configDrive,omitempty"`
 }
 
@@ -1024,6 +1024,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*OpenstackLoadbalancerSessionPersistence)(nil), (*kops.OpenstackLoadbalancerSessionPersistence)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_OpenstackLoadbalancerSessionPersistence_To_kops_OpenstackLoadbalancerSessionPersistence(a.(*OpenstackLoadbalancerSessionPersistence), b.(*kops.OpenstackLoadbalancerSessionPersistence), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.OpenstackLoadbalancerSessionPersistence)(nil), (*OpenstackLoadbalancerSessionPersistence)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_OpenstackLoadbalancerSessionPersistence_To_v1alpha3_OpenstackLoadbalancerSessionPersistence(a.(*kops.OpenstackLoadbalancerSessionPersistence), b.(*OpenstackLoadbalancerSessionPersistence), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*OpenstackMetadata)(nil), (*kops.OpenstackMetadata)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_OpenstackMetadata_To_kops_OpenstackMetadata(a.(*OpenstackMetadata), b.(*kops.OpenstackMetadata), scope)
 	}); err != nil {
@@ -6951,6 +6961,33 @@ func autoConvert_v1alpha3_OpenstackLoadbalancerConfig_To_kops_OpenstackLoadbalan
 	out.EnableIngressHostname = in.EnableIngressHostname
 	out.IngressHostnameSuffix = in.IngressHostnameSuffix
 	out.FlavorID = in.FlavorID
+	out.ID = in.ID
+	out.DeleteTimeout = in.DeleteTimeout
+	out.CascadeDelete = in.CascadeDelete
+	out.VipQosPolicy = in.VipQosPolicy
+	out.SkipActiveWait = in.SkipActiveWait
+	out.ManageVIPPortSecurityGroups = in.ManageVIPPortSecurityGroups
+	out.Region = in.Region
+	out.Endpoint = in.Endpoint
+	out.ConnLimit = in.ConnLimit
+	if in.SessionPersistence != nil {
+		in, out := &in.SessionPersistence, &out.SessionPersistence
+		*out = new(kops.OpenstackLoadbalancerSessionPersistence)
+		if err := Convert_v1alpha3_OpenstackLoadbalancerSessionPersistence_To_kops_OpenstackLoadbalancerSessionPersistence(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.SessionPersistence = nil
+	}
+	out.AdminStateUp = in.AdminStateUp
+	out.TimeoutClientData = in.TimeoutClientData
+	out.TimeoutMemberData = in.TimeoutMemberData
+	out.TimeoutMemberConnect = in.TimeoutMemberConnect
+	out.TimeoutTCPInspect = in.TimeoutTCPInspect
+	out.RecreateOnImmutableChange = in.RecreateOnImmutableChange
+	out.VipPortID = in.VipPortID
+	out.DNSRecordType = in.DNSRecordType
+	out.DNSRecordTTL = in.DNSRecordTTL
 	return nil
 }
 
@@ -6971,6 +7008,33 @@ func autoConvert_kops_OpenstackLoadbalancerConfig_To_v1alpha3_OpenstackLoadbalan
 	out.EnableIngressHostname = in.EnableIngressHostname
 	out.IngressHostnameSuffix = in.IngressHostnameSuffix
 	out.FlavorID = in.FlavorID
+	out.ID = in.ID
+	out.DeleteTimeout = in.DeleteTimeout
+	out.CascadeDelete = in.CascadeDelete
+	out.VipQosPolicy = in.VipQosPolicy
+	out.SkipActiveWait = in.SkipActiveWait
+	out.ManageVIPPortSecurityGroups = in.ManageVIPPortSecurityGroups
+	out.Region = in.Region
+	out.Endpoint = in.Endpoint
+	out.ConnLimit = in.ConnLimit
+	if in.SessionPersistence != nil {
+		in, out := &in.SessionPersistence, &out.SessionPersistence
+		*out = new(OpenstackLoadbalancerSessionPersistence)
+		if err := Convert_kops_OpenstackLoadbalancerSessionPersistence_To_v1alpha3_OpenstackLoadbalancerSessionPersistence(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.SessionPersistence = nil
+	}
+	out.AdminStateUp = in.AdminStateUp
+	out.TimeoutClientData = in.TimeoutClientData
+	out.TimeoutMemberData = in.TimeoutMemberData
+	out.TimeoutMemberConnect = in.TimeoutMemberConnect
+	out.TimeoutTCPInspect = in.TimeoutTCPInspect
+	out.RecreateOnImmutableChange = in.RecreateOnImmutableChange
+	out.VipPortID = in.VipPortID
+	out.DNSRecordType = in.DNSRecordType
+	out.DNSRecordTTL = in.DNSRecordTTL
 	return nil
 }
 
@@ -6979,6 +7043,28 @@ func Convert_kops_OpenstackLoadbalancerConfig_To_v1alpha3_OpenstackLoadbalancerC
 	return autoConvert_kops_OpenstackLoadbalancerConfig_To_v1alpha3_OpenstackLoadbalancerConfig(in, out, s)
 }
 
+func autoConvert_v1alpha3_OpenstackLoadbalancerSessionPersistence_To_kops_OpenstackLoadbalancerSessionPersistence(in *OpenstackLoadbalancerSessionPersistence, out *kops.OpenstackLoadbalancerSessionPersistence, s conversion.Scope) error {
+	out.Type = in.Type
+	out.CookieName = in.CookieName
+	return nil
+}
+
+// Convert_v1alpha3_OpenstackLoadbalancerSessionPersistence_To_kops_OpenstackLoadbalancerSessionPersistence is an autogenerated conversion function.
+func Convert_v1alpha3_OpenstackLoadbalancerSessionPersistence_To_kops_OpenstackLoadbalancerSessionPersistence(in *OpenstackLoadbalancerSessionPersistence, out *kops.OpenstackLoadbalancerSessionPersistence, s conversion.Scope) error {
+	return autoConvert_v1alpha3_OpenstackLoadbalancerSessionPersistence_To_kops_OpenstackLoadbalancerSessionPersistence(in, out, s)
+}
+
+func autoConvert_kops_OpenstackLoadbalancerSessionPersistence_To_v1alpha3_OpenstackLoadbalancerSessionPersistence(in *kops.OpenstackLoadbalancerSessionPersistence, out *OpenstackLoadbalancerSessionPersistence, s conversion.Scope) error {
+	out.Type = in.Type
+	out.CookieName = in.CookieName
+	return nil
+}
+
+// Convert_kops_OpenstackLoadbalancerSessionPersistence_To_v1alpha3_OpenstackLoadbalancerSessionPersistence is an autogenerated conversion function.
+func Convert_kops_OpenstackLoadbalancerSessionPersistence_To_v1alpha3_OpenstackLoadbalancerSessionPersistence(in *kops.OpenstackLoadbalancerSessionPersistence, out *OpenstackLoadbalancerSessionPersistence, s conversion.Scope) error {
+	return autoConvert_kops_OpenstackLoadbalancerSessionPersistence_To_v1alpha3_OpenstackLoadbalancerSessionPersistence(in, out, s)
+}
+
 func autoConvert_v1alpha3_OpenstackMetadata_To_kops_OpenstackMetadata(in *OpenstackMetadata, out *kops.OpenstackMetadata, s conversion.Scope) error {
 	out.ConfigDrive = in.ConfigDrive
 	return nil
@@ -5063,6 +5063,101 @@ func (in *OpenstackLoadbalancerConfig) DeepCopyInto(out *OpenstackLoadbalancerCo
 		*out = new(string)
 		**out = **in
 	}
+	if in.ID != nil {
+		in, out := &in.ID, &out.ID
+		*out = new(string)
+		**out = **in
+	}
+	if in.DeleteTimeout != nil {
+		in, out := &in.DeleteTimeout, &out.DeleteTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CascadeDelete != nil {
+		in, out := &in.CascadeDelete, &out.CascadeDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VipQosPolicy != nil {
+		in, out := &in.VipQosPolicy, &out.VipQosPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.SkipActiveWait != nil {
+		in, out := &in.SkipActiveWait, &out.SkipActiveWait
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ManageVIPPortSecurityGroups != nil {
+		in, out := &in.ManageVIPPortSecurityGroups, &out.ManageVIPPortSecurityGroups
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Region != nil {
+		in, out := &in.Region, &out.Region
+		*out = new(string)
+		**out = **in
+	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConnLimit != nil {
+		in, out := &in.ConnLimit, &out.ConnLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.SessionPersistence != nil {
+		in, out := &in.SessionPersistence, &out.SessionPersistence
+		*out = new(OpenstackLoadbalancerSessionPersistence)
+		**out = **in
+	}
+	if in.AdminStateUp != nil {
+		in, out := &in.AdminStateUp, &out.AdminStateUp
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TimeoutClientData != nil {
+		in, out := &in.TimeoutClientData, &out.TimeoutClientData
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TimeoutMemberData != nil {
+		in, out := &in.TimeoutMemberData, &out.TimeoutMemberData
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TimeoutMemberConnect != nil {
+		in, out := &in.TimeoutMemberConnect, &out.TimeoutMemberConnect
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TimeoutTCPInspect != nil {
+		in, out := &in.TimeoutTCPInspect, &out.TimeoutTCPInspect
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RecreateOnImmutableChange != nil {
+		in, out := &in.RecreateOnImmutableChange, &out.RecreateOnImmutableChange
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VipPortID != nil {
+		in, out := &in.VipPortID, &out.VipPortID
+		*out = new(string)
+		**out = **in
+	}
+	if in.DNSRecordType != nil {
+		in, out := &in.DNSRecordType, &out.DNSRecordType
+		*out = new(string)
+		**out = **in
+	}
+	if in.DNSRecordTTL != nil {
+		in, out := &in.DNSRecordTTL, &out.DNSRecordTTL
+		*out = new(int)
+		**out = **in
+	}
 	return
 }
 
@@ -5076,6 +5171,22 @@ func (in *OpenstackLoadbalancerConfig) DeepCopy() *OpenstackLoadbalancerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenstackLoadbalancerSessionPersistence) DeepCopyInto(out *OpenstackLoadbalancerSessionPersistence) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenstackLoadbalancerSessionPersistence.
+func (in *OpenstackLoadbalancerSessionPersistence) DeepCopy() *OpenstackLoadbalancerSessionPersistence {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenstackLoadbalancerSessionPersistence)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OpenstackMetadata) DeepCopyInto(out *OpenstackMetadata) {
 	*out = *in
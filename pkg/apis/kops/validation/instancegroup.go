@@ -291,6 +291,18 @@ func CrossValidateInstanceGroup(g *kops.InstanceGroup, cluster *kops.Cluster, cl
 		}
 	}
 
+	if cluster.Spec.GetCloudProvider() == kops.CloudProviderOpenstack {
+		for i, v := range g.Spec.Volumes {
+			path := field.NewPath("spec", "volumes").Index(i)
+			if v.IOPS != nil {
+				allErrs = append(allErrs, field.Forbidden(path.Child("iops"), "iops is not supported on openstack"))
+			}
+			if v.Throughput != nil {
+				allErrs = append(allErrs, field.Forbidden(path.Child("throughput"), "throughput is not supported on openstack"))
+			}
+		}
+	}
+
 	if g.Spec.Containerd != nil {
 		allErrs = append(allErrs, validateContainerdConfig(&cluster.Spec, g.Spec.Containerd, field.NewPath("spec", "containerd"), false)...)
 	}
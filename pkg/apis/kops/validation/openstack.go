@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"net/url"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func openstackValidateCluster(c *kops.Cluster) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if c.Spec.CloudProvider.Openstack == nil || c.Spec.CloudProvider.Openstack.Loadbalancer == nil {
+		return allErrs
+	}
+	lbConfig := c.Spec.CloudProvider.Openstack.Loadbalancer
+	fieldSpec := field.NewPath("spec", "cloudProvider", "openstack", "loadbalancer")
+
+	// the features of the Octavia providers differ, reject combinations that would fail when creating the loadbalancer
+	if fi.ValueOf(lbConfig.UseOctavia) && lbConfig.Provider != nil {
+		provider := fi.ValueOf(lbConfig.Provider)
+		if lbConfig.Method != nil {
+			if err := openstack.ValidateLBProviderMethod(provider, fi.ValueOf(lbConfig.Method)); err != nil {
+				allErrs = append(allErrs, field.Invalid(fieldSpec.Child("method"), fi.ValueOf(lbConfig.Method), err.Error()))
+			}
+		}
+		if lbConfig.FlavorID != nil {
+			if err := openstack.ValidateLBProviderFlavor(provider, fi.ValueOf(lbConfig.FlavorID)); err != nil {
+				allErrs = append(allErrs, field.Forbidden(fieldSpec.Child("flavorID"), err.Error()))
+			}
+		}
+	}
+
+	if lbConfig.ConnLimit != nil && fi.ValueOf(lbConfig.ConnLimit) < -1 {
+		allErrs = append(allErrs, field.Invalid(fieldSpec.Child("connLimit"), fi.ValueOf(lbConfig.ConnLimit), "must be -1 for unlimited or greater"))
+	}
+
+	if lbConfig.DNSRecordType != nil {
+		allErrs = append(allErrs, IsValidValue(fieldSpec.Child("dnsRecordType"), lbConfig.DNSRecordType, []string{"A", "AAAA"})...)
+	}
+	if lbConfig.DNSRecordTTL != nil && fi.ValueOf(lbConfig.DNSRecordTTL) <= 0 {
+		allErrs = append(allErrs, field.Invalid(fieldSpec.Child("dnsRecordTTL"), fi.ValueOf(lbConfig.DNSRecordTTL), "must be greater than 0"))
+	}
+
+	timeouts := map[string]*metav1.Duration{
+		"timeoutClientData":    lbConfig.TimeoutClientData,
+		"timeoutMemberData":    lbConfig.TimeoutMemberData,
+		"timeoutMemberConnect": lbConfig.TimeoutMemberConnect,
+		"timeoutTCPInspect":    lbConfig.TimeoutTCPInspect,
+	}
+	for name, timeout := range timeouts {
+		if timeout != nil && timeout.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(fieldSpec.Child(name), timeout.Duration.String(), "must not be negative"))
+		}
+	}
+
+	if lbConfig.SessionPersistence != nil {
+		if err := openstack.ValidateLBSessionPersistence(lbConfig.SessionPersistence.Type, lbConfig.SessionPersistence.CookieName); err != nil {
+			allErrs = append(allErrs, field.Invalid(fieldSpec.Child("sessionPersistence"), *lbConfig.SessionPersistence, err.Error()))
+		}
+	}
+
+	if lbConfig.Endpoint != nil {
+		endpoint, err := url.Parse(fi.ValueOf(lbConfig.Endpoint))
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fieldSpec.Child("endpoint"), fi.ValueOf(lbConfig.Endpoint), "must be an absolute http or https URL"))
+		}
+	}
+
+	return allErrs
+}
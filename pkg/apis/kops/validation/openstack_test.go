@@ -0,0 +1,282 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestOpenstackValidateLoadbalancerProvider(t *testing.T) {
+	grid := []struct {
+		Input          *kops.OpenstackLoadbalancerConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+				Provider:   fi.PtrTo("octavia"),
+				Method:     fi.PtrTo("ROUND_ROBIN"),
+				FlavorID:   fi.PtrTo("flavor"),
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+				Provider:   fi.PtrTo("ovn"),
+				Method:     fi.PtrTo("SOURCE_IP_PORT"),
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+				Provider:   fi.PtrTo("ovn"),
+				Method:     fi.PtrTo("ROUND_ROBIN"),
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.method"},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+				Provider:   fi.PtrTo("ovn"),
+				FlavorID:   fi.PtrTo("flavor"),
+			},
+			ExpectedErrors: []string{"Forbidden::spec.cloudProvider.openstack.loadbalancer.flavorID"},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(false),
+				Provider:   fi.PtrTo("haproxy"),
+				Method:     fi.PtrTo("ROUND_ROBIN"),
+			},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider: kops.CloudProviderSpec{
+					Openstack: &kops.OpenstackSpec{
+						Loadbalancer: g.Input,
+					},
+				},
+			},
+		}
+		errs := openstackValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
+func TestOpenstackValidateLoadbalancerConnLimit(t *testing.T) {
+	grid := []struct {
+		Input          *kops.OpenstackLoadbalancerConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				ConnLimit: fi.PtrTo(-1),
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				ConnLimit: fi.PtrTo(5000),
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				ConnLimit: fi.PtrTo(-5),
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.connLimit"},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider: kops.CloudProviderSpec{
+					Openstack: &kops.OpenstackSpec{
+						Loadbalancer: g.Input,
+					},
+				},
+			},
+		}
+		errs := openstackValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
+func TestOpenstackValidateLoadbalancerDNSRecord(t *testing.T) {
+	grid := []struct {
+		Input          *kops.OpenstackLoadbalancerConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				DNSRecordType: fi.PtrTo("AAAA"),
+				DNSRecordTTL:  fi.PtrTo(300),
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				DNSRecordType: fi.PtrTo("CNAME"),
+			},
+			ExpectedErrors: []string{"Unsupported value::spec.cloudProvider.openstack.loadbalancer.dnsRecordType"},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				DNSRecordTTL: fi.PtrTo(0),
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.dnsRecordTTL"},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider: kops.CloudProviderSpec{
+					Openstack: &kops.OpenstackSpec{
+						Loadbalancer: g.Input,
+					},
+				},
+			},
+		}
+		errs := openstackValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
+func TestOpenstackValidateLoadbalancerListenerTimeouts(t *testing.T) {
+	grid := []struct {
+		Input          *kops.OpenstackLoadbalancerConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				TimeoutClientData: &metav1.Duration{Duration: time.Hour},
+				TimeoutMemberData: &metav1.Duration{Duration: time.Hour},
+				TimeoutTCPInspect: &metav1.Duration{},
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				TimeoutMemberConnect: &metav1.Duration{Duration: -time.Second},
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.timeoutMemberConnect"},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider: kops.CloudProviderSpec{
+					Openstack: &kops.OpenstackSpec{
+						Loadbalancer: g.Input,
+					},
+				},
+			},
+		}
+		errs := openstackValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
+func TestOpenstackValidateLoadbalancerSessionPersistence(t *testing.T) {
+	grid := []struct {
+		Input          *kops.OpenstackLoadbalancerConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				SessionPersistence: &kops.OpenstackLoadbalancerSessionPersistence{Type: "SOURCE_IP"},
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				SessionPersistence: &kops.OpenstackLoadbalancerSessionPersistence{Type: "APP_COOKIE", CookieName: "session"},
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				SessionPersistence: &kops.OpenstackLoadbalancerSessionPersistence{Type: "HTTP_COOKIE", CookieName: "session"},
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.sessionPersistence"},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				SessionPersistence: &kops.OpenstackLoadbalancerSessionPersistence{},
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.sessionPersistence"},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider: kops.CloudProviderSpec{
+					Openstack: &kops.OpenstackSpec{
+						Loadbalancer: g.Input,
+					},
+				},
+			},
+		}
+		errs := openstackValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
+func TestOpenstackValidateLoadbalancerEndpoint(t *testing.T) {
+	grid := []struct {
+		Input          *kops.OpenstackLoadbalancerConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				Endpoint: fi.PtrTo("https://octavia.example.com:9876/"),
+			},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				Endpoint: fi.PtrTo("octavia.example.com"),
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.endpoint"},
+		},
+		{
+			Input: &kops.OpenstackLoadbalancerConfig{
+				Endpoint: fi.PtrTo("ftp://octavia.example.com"),
+			},
+			ExpectedErrors: []string{"Invalid value::spec.cloudProvider.openstack.loadbalancer.endpoint"},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider: kops.CloudProviderSpec{
+					Openstack: &kops.OpenstackSpec{
+						Loadbalancer: g.Input,
+					},
+				},
+			},
+		}
+		errs := openstackValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
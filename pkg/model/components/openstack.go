@@ -55,9 +55,7 @@ func (b *OpenStackOptionsBuilder) BuildOptions(o interface{}) error {
 	if openstack.Metadata == nil {
 		openstack.Metadata = &kops.OpenstackMetadata{}
 	}
-	if openstack.Metadata.ConfigDrive == nil {
-		openstack.Metadata.ConfigDrive = fi.PtrTo(false)
-	}
+	// Metadata.ConfigDrive is left unset, it is defaulted based on the availability of the metadata service
 
 	if clusterSpec.ExternalCloudControllerManager == nil {
 		clusterSpec.ExternalCloudControllerManager = &kops.CloudControllerManagerConfig{}
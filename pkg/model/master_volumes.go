@@ -344,6 +344,7 @@ func (b *MasterVolumeBuilder) addOpenstackVolume(c *fi.CloudupModelBuilderContex
 		SizeGB:           fi.PtrTo(int64(volumeSize)),
 		Tags:             tags,
 		Lifecycle:        b.Lifecycle,
+		Encrypted:        m.EncryptedVolume,
 	}
 	c.AddTask(t)
 
@@ -30,6 +30,8 @@ import (
 type OpenstackModelContext struct {
 	*model.KopsModelContext
 	cloud openstack.OpenstackCloud
+	// configDrive caches the default of the config drive, so that it is probed and logged once
+	configDrive *bool
 }
 
 func (c *OpenstackModelContext) createCloud() (openstack.OpenstackCloud, error) {
@@ -55,6 +57,36 @@ func (c *OpenstackModelContext) UseVIPACL() bool {
 	return use
 }
 
+// UseConfigDrive returns whether instances should get their user data from a config drive.
+// Unless configured explicitly, config drive is used when the cloud has no metadata service.
+func (c *OpenstackModelContext) UseConfigDrive() bool {
+	if metadata := c.Cluster.Spec.CloudProvider.Openstack.Metadata; metadata != nil && metadata.ConfigDrive != nil {
+		return *metadata.ConfigDrive
+	}
+	if c.configDrive == nil {
+		c.configDrive = fi.PtrTo(c.defaultConfigDrive())
+	}
+	return *c.configDrive
+}
+
+// defaultConfigDrive probes the metadata service, listing the Neutron agents usually requires admin permissions
+func (c *OpenstackModelContext) defaultConfigDrive() bool {
+	osCloud, err := c.createCloud()
+	if err != nil {
+		klog.Warningf("Could not determine if the metadata service is available, not using config drive, set spec.cloudProvider.openstack.metadata.configDrive to choose explicitly: %v", err)
+		return false
+	}
+	has, err := osCloud.HasMetadataService()
+	if err != nil {
+		klog.Warningf("Could not determine if the metadata service is available, not using config drive, set spec.cloudProvider.openstack.metadata.configDrive to choose explicitly: %v", err)
+		return false
+	}
+	if !has {
+		klog.Warningf("No Neutron metadata agent is running, using config drive, set spec.cloudProvider.openstack.metadata.configDrive to choose explicitly")
+	}
+	return !has
+}
+
 func (c *OpenstackModelContext) GetNetworkName() (string, error) {
 	if c.Cluster.Spec.Networking.NetworkID == "" {
 		return c.ClusterName(), nil
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstackmodel
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/model"
+	"k8s.io/kops/pkg/model/iam"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+// metadataServiceCloud is a mock cloud with a configurable metadata service probe
+type metadataServiceCloud struct {
+	*openstack.MockCloud
+	hasMetadataService bool
+	err                error
+	probes             int
+}
+
+func (c *metadataServiceCloud) HasMetadataService() (bool, error) {
+	c.probes++
+	return c.hasMetadataService, c.err
+}
+
+func TestUseConfigDrive(t *testing.T) {
+	tests := []struct {
+		desc               string
+		configDrive        *bool
+		hasMetadataService bool
+		err                error
+		expected           bool
+		expectedProbes     int
+	}{
+		{
+			desc:               "explicitly enabled",
+			configDrive:        fi.PtrTo(true),
+			hasMetadataService: true,
+			expected:           true,
+		},
+		{
+			desc:        "explicitly disabled",
+			configDrive: fi.PtrTo(false),
+			expected:    false,
+		},
+		{
+			desc:               "metadata agent running",
+			hasMetadataService: true,
+			expected:           false,
+			expectedProbes:     1,
+		},
+		{
+			desc:           "no metadata agent running",
+			expected:       true,
+			expectedProbes: 1,
+		},
+		{
+			desc:               "listing agents failed",
+			hasMetadataService: true,
+			err:                errors.New("error listing network agents: forbidden"),
+			expected:           false,
+			expectedProbes:     1,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cluster := &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					CloudProvider: kops.CloudProviderSpec{
+						Openstack: &kops.OpenstackSpec{},
+					},
+				},
+			}
+			if testCase.configDrive != nil {
+				cluster.Spec.CloudProvider.Openstack.Metadata = &kops.OpenstackMetadata{
+					ConfigDrive: testCase.configDrive,
+				}
+			}
+			cloud := &metadataServiceCloud{
+				MockCloud:          openstack.BuildMockOpenstackCloud("region"),
+				hasMetadataService: testCase.hasMetadataService,
+				err:                testCase.err,
+			}
+			c := &OpenstackModelContext{
+				KopsModelContext: &model.KopsModelContext{
+					IAMModelContext: iam.IAMModelContext{Cluster: cluster},
+				},
+				cloud: cloud,
+			}
+
+			for i := 0; i < 2; i++ {
+				if actual := c.UseConfigDrive(); actual != testCase.expected {
+					t.Errorf("expected config drive %v, got %v", testCase.expected, actual)
+				}
+			}
+			if cloud.probes != testCase.expectedProbes {
+				t.Errorf("expected %d probes of the metadata service, got %d", testCase.expectedProbes, cloud.probes)
+			}
+		})
+	}
+}
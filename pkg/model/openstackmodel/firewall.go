@@ -79,6 +79,7 @@ func (b *FirewallModelBuilder) addDirectionalGroupRule(c *fi.CloudupModelBuilder
 		RemoteIPPrefix: sgr.RemoteIPPrefix,
 		SecGroup:       source,
 		Delete:         fi.PtrTo(false),
+		Description:    fi.PtrTo(openstacktasks.ManagedRuleDescription(b.ClusterName())),
 	}
 
 	klog.V(8).Infof("Adding rule %v", fi.ValueOf(t.GetName()))
@@ -651,6 +652,11 @@ func (b *FirewallModelBuilder) getExistingRules(sgMap map[string]*openstacktasks
 			return err
 		}
 		for _, rule := range sgRules {
+			// rules added by others are kept
+			if !openstacktasks.IsManagedRule(rule) {
+				klog.V(4).Infof("Keeping existing rule %s with description %q", rule.ID, rule.Description)
+				continue
+			}
 
 			t := &openstacktasks.SecurityGroupRule{
 				ID:             fi.PtrTo(rule.ID),
@@ -664,6 +670,7 @@ func (b *FirewallModelBuilder) getExistingRules(sgMap map[string]*openstacktasks
 				Lifecycle:      b.Lifecycle,
 				SecGroup:       sgIdMap[rule.SecGroupID],
 				Delete:         fi.PtrTo(true),
+				Description:    fi.PtrTo(rule.Description),
 			}
 			klog.V(8).Infof("Adding existing rule %v", t)
 			b.Rules[fi.ValueOf(t.GetName())] = t
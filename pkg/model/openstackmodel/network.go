@@ -75,6 +75,8 @@ func (b *NetworkModelBuilder) Build(c *fi.CloudupModelBuilderContext) error {
 			DNSServers: make([]*string, 0),
 			Lifecycle:  b.Lifecycle,
 			Tag:        s(clusterName),
+			// existing subnets may be shared with resources outside of the cluster
+			Preserve: fi.PtrTo(sp.ID != ""),
 		}
 		if osSpec.Router != nil && osSpec.Router.DNSServers != nil {
 			dnsSplitted := strings.Split(fi.ValueOf(osSpec.Router.DNSServers), ",")
@@ -19,10 +19,13 @@ package openstackmodel
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/model"
@@ -121,8 +124,55 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.CloudupModelBuilderContex
 		igMeta[openstack.BOOT_FROM_VOLUME] = e
 	}
 
+	var bootVolumeSize *int64
 	if v, ok := ig.ObjectMeta.Annotations[openstack.OS_ANNOTATION+openstack.BOOT_VOLUME_SIZE]; ok {
 		igMeta[openstack.BOOT_VOLUME_SIZE] = v
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			// these values used to be ignored, the size of the image is used for one more release before they are rejected
+			klog.Warningf("Ignoring invalid value %q for annotation %s of instance group %s, the size of the image is used instead. Invalid values will be rejected in a future release.", v, openstack.OS_ANNOTATION+openstack.BOOT_VOLUME_SIZE, ig.Name)
+		} else {
+			bootVolumeSize = fi.PtrTo(size)
+		}
+	}
+
+	var bootVolumeType *string
+	if v, ok := ig.ObjectMeta.Annotations[openstack.OS_ANNOTATION+openstack.BOOT_VOLUME_TYPE]; ok {
+		bootVolumeType = fi.PtrTo(v)
+	}
+
+	var bootVolumeDelete *bool
+	if v, ok := ig.ObjectMeta.Annotations[openstack.OS_ANNOTATION+openstack.BOOT_VOLUME_DELETE]; ok {
+		deleteOnTermination, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for annotation %s of instance group %s", v, openstack.OS_ANNOTATION+openstack.BOOT_VOLUME_DELETE, ig.Name)
+		}
+		bootVolumeDelete = fi.PtrTo(deleteOnTermination)
+	}
+
+	var schedulerHints map[string]string
+	hintPrefix := openstack.OS_ANNOTATION + openstack.SCHEDULER_HINT + "/"
+	for key, value := range ig.ObjectMeta.Annotations {
+		if hint, ok := strings.CutPrefix(key, hintPrefix); ok {
+			if schedulerHints == nil {
+				schedulerHints = make(map[string]string)
+			}
+			schedulerHints[hint] = value
+		}
+	}
+
+	var extraServerTags []string
+	if v, ok := ig.ObjectMeta.Annotations[openstack.OS_ANNOTATION+openstack.SERVER_TAGS]; ok {
+		for _, tag := range strings.Split(v, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				continue
+			}
+			if strings.Contains(tag, "/") || len(tag) > MAX_TAG_LENGTH_OPENSTACK {
+				return fmt.Errorf("invalid server tag %q in annotation %s of instance group %s", tag, openstack.OS_ANNOTATION+openstack.SERVER_TAGS, ig.Name)
+			}
+			extraServerTags = append(extraServerTags, tag)
+		}
 	}
 
 	startupScript, err := b.BootstrapScriptBuilder.ResourceNodeUp(c, ig)
@@ -173,6 +223,38 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.CloudupModelBuilderContex
 			zone := ig.Spec.Zones[int(i)%len(ig.Spec.Zones)]
 			az = fi.PtrTo(zone)
 		}
+		var dataVolumes []*openstacktasks.InstanceVolume
+		for _, v := range ig.Spec.Volumes {
+			volumeAZ := az
+			if b.Cluster.Spec.CloudProvider.Openstack.BlockStorage != nil && b.Cluster.Spec.CloudProvider.Openstack.BlockStorage.OverrideAZ != nil {
+				volumeAZ = b.Cluster.Spec.CloudProvider.Openstack.BlockStorage.OverrideAZ
+			}
+			volumeName := fmt.Sprintf("%s-%s", strings.TrimPrefix(v.Device, "/dev/"), *instanceName)
+			volumeTags := map[string]string{
+				openstack.TagKopsInstanceGroup: ig.Name,
+				openstack.TagKopsName:          volumeName,
+			}
+			for k, v := range cloudTags {
+				volumeTags[k] = v
+			}
+			volumeTask := &openstacktasks.Volume{
+				Name:             fi.PtrTo(volumeName),
+				AvailabilityZone: volumeAZ,
+				VolumeType:       fi.PtrTo(v.Type),
+				SizeGB:           fi.PtrTo(v.Size),
+				Tags:             volumeTags,
+				Lifecycle:        b.Lifecycle,
+				Encrypted:        v.Encrypted,
+			}
+			c.AddTask(volumeTask)
+
+			dataVolumes = append(dataVolumes, &openstacktasks.InstanceVolume{
+				Volume:              volumeTask,
+				Device:              v.Device,
+				DeleteOnTermination: v.DeleteOnTermination == nil || fi.ValueOf(v.DeleteOnTermination),
+			})
+		}
+
 		// Create instance port task
 		portName := fmt.Sprintf("%s-%s", "port", *instanceName)
 		portTagKopsName := strings.Replace(
@@ -190,7 +272,7 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.CloudupModelBuilderContex
 			Tags: []string{
 				truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagKopsInstanceGroup, groupName), TRUNCATE_OPT),
 				truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagKopsName, portTagKopsName), TRUNCATE_OPT),
-				truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagClusterName, b.ClusterName()), TRUNCATE_OPT),
+				openstacktasks.ClusterTag(b.ClusterName()),
 			},
 			SecurityGroups:           securityGroups,
 			AdditionalSecurityGroups: ig.Spec.AdditionalSecurityGroups,
@@ -209,6 +291,17 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.CloudupModelBuilderContex
 			metaWithName[k] = v
 		}
 		metaWithName[openstack.TagKopsName] = fi.ValueOf(instanceName)
+		// the cluster tag is used to find the servers when deleting the cluster
+		serverTags := []string{
+			openstacktasks.ClusterTag(b.ClusterName()),
+			truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagKopsInstanceGroup, groupName), TRUNCATE_OPT),
+			truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagKopsName, fi.ValueOf(instanceName)), TRUNCATE_OPT),
+		}
+		for _, tag := range extraServerTags {
+			if !slices.Contains(serverTags, tag) {
+				serverTags = append(serverTags, tag)
+			}
+		}
 		instanceTask := &openstacktasks.Instance{
 			Name:             instanceName,
 			Lifecycle:        b.Lifecycle,
@@ -224,7 +317,14 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.CloudupModelBuilderContex
 			Metadata:         metaWithName,
 			SecurityGroups:   ig.Spec.AdditionalSecurityGroups,
 			AvailabilityZone: az,
-			ConfigDrive:      b.Cluster.Spec.CloudProvider.Openstack.Metadata.ConfigDrive,
+			ConfigDrive:      fi.PtrTo(b.UseConfigDrive()),
+
+			BootVolumeSizeGB:              bootVolumeSize,
+			BootVolumeType:                bootVolumeType,
+			BootVolumeDeleteOnTermination: bootVolumeDelete,
+			DataVolumes:                   dataVolumes,
+			ServerTags:                    serverTags,
+			SchedulerHints:                schedulerHints,
 		}
 		c.AddTask(instanceTask)
 
@@ -253,6 +353,29 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.CloudupModelBuilderContex
 	return nil
 }
 
+// milliseconds returns the duration in milliseconds as used by Octavia, nil if it is not set
+func milliseconds(d *metav1.Duration) *int {
+	if d == nil {
+		return nil
+	}
+	return fi.PtrTo(int(d.Milliseconds()))
+}
+
+// applyLBDefaults sets the Octavia provider and flavor of the cluster spec on a loadbalancer task that does not set its
+// own, so that the loadbalancers of the cluster are configured in one place
+func (b *ServerGroupModelBuilder) applyLBDefaults(lb *openstacktasks.LB) {
+	lbConfig := b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer
+	if lbConfig == nil {
+		return
+	}
+	if lb.Provider == nil {
+		lb.Provider = lbConfig.Provider
+	}
+	if lb.FlavorID == nil {
+		lb.FlavorID = lbConfig.FlavorID
+	}
+}
+
 func (b *ServerGroupModelBuilder) Build(c *fi.CloudupModelBuilderContext) error {
 	clusterName := b.ClusterName()
 
@@ -319,39 +442,107 @@ func (b *ServerGroupModelBuilder) Build(c *fi.CloudupModelBuilderContext) error
 			Lifecycle: b.Lifecycle,
 		}
 
-		if b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.FlavorID != nil {
-			lbTask.FlavorID = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.FlavorID
+		// a shared loadbalancer is only referenced, its lifecycle is managed outside of kOps
+		sharedLB := b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.ID != nil
+		if sharedLB {
+			lbTask.ID = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.ID
+			lbTask.Lifecycle = fi.LifecycleExistsAndWarnIfChanges
+		}
+
+		if !sharedLB {
+			b.applyLBDefaults(lbTask)
+			lbTask.Tags = openstacktasks.ClusterTags(b.ClusterName())
+			lbTask.Network = b.LinkToNetwork()
+		}
+		lbTask.SkipActiveWait = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.SkipActiveWait
+		lbTask.ManagePortSecurityGroups = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.ManageVIPPortSecurityGroups
+		if !sharedLB {
+			lbTask.AdminStateUp = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.AdminStateUp
+			lbTask.RecreateOnImmutableChange = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.RecreateOnImmutableChange
+			lbTask.VipPortID = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.VipPortID
+			if lbTask.VipPortID == nil {
+				lbTask.VipPortTags = openstacktasks.ClusterTags(b.ClusterName())
+			}
+		}
+
+		if b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.VipQosPolicy != nil && !sharedLB {
+			lbTask.VipQosPolicy = b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.VipQosPolicy
 		}
 
 		useVIPACL := b.UseVIPACL()
-		if !useVIPACL {
+		if !useVIPACL && !sharedLB {
 			lbTask.SecurityGroup = b.LinkToSecurityGroup(b.APIResourceName())
 		}
 
 		c.AddTask(lbTask)
 
 		lbfipTask := &openstacktasks.FloatingIP{
-			Name:      fi.PtrTo(fmt.Sprintf("%s-%s", "fip", *lbTask.Name)),
-			LB:        lbTask,
-			Lifecycle: b.Lifecycle,
+			Name:            fi.PtrTo(fmt.Sprintf("%s-%s", "fip", *lbTask.Name)),
+			LB:              lbTask,
+			Lifecycle:       b.Lifecycle,
+			FloatingNetwork: b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.FloatingNetwork,
+		}
+		if sharedLB {
+			// the floating IP of a shared loadbalancer belongs to its owner, it is only used as the address of the API
+			lbfipTask.Lifecycle = fi.LifecycleExistsAndWarnIfChanges
 		}
 		c.AddTask(lbfipTask)
 
 		lbfipTask.WellKnownServices = append(lbfipTask.WellKnownServices, wellknownservices.KubeAPIServer)
 
+		// The API is not published by dns-controller when using a loadbalancer, the record is managed in Designate
+		if b.Cluster.PublishesDNSRecords() && b.Cluster.Spec.API.LoadBalancer != nil && b.Cluster.Spec.API.PublicName != "" {
+			dnsTask := &openstacktasks.DNSRecordSet{
+				Name:        fi.PtrTo(b.Cluster.Spec.API.PublicName),
+				Type:        fi.PtrTo("A"),
+				TTL:         fi.PtrTo(60),
+				ClusterName: fi.PtrTo(b.ClusterName()),
+				Lifecycle:   b.Lifecycle,
+			}
+			if recordType := b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.DNSRecordType; recordType != nil {
+				dnsTask.Type = recordType
+			}
+			if ttl := b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.DNSRecordTTL; ttl != nil {
+				dnsTask.TTL = ttl
+			}
+			if b.Cluster.Spec.DNSZone != "" {
+				dnsTask.Zone = fi.PtrTo(b.Cluster.Spec.DNSZone)
+			}
+			if b.Cluster.Spec.API.LoadBalancer.Type == kops.LoadBalancerTypeInternal {
+				dnsTask.LB = lbTask
+			} else {
+				dnsTask.FloatingIP = lbfipTask
+			}
+			c.AddTask(dnsTask)
+		}
+
 		poolTask := &openstacktasks.LBPool{
 			Name:         fi.PtrTo(fmt.Sprintf("%s-https", fi.ValueOf(lbTask.Name))),
 			Loadbalancer: lbTask,
 			Lifecycle:    b.Lifecycle,
+			LBMethod:     b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.Method,
+			// session persistence is disabled if it is removed from the spec
+			SessionPersistence: &openstacktasks.LBSessionPersistence{},
+		}
+		if persistence := b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.SessionPersistence; persistence != nil {
+			poolTask.SessionPersistence = &openstacktasks.LBSessionPersistence{
+				Type:       persistence.Type,
+				CookieName: persistence.CookieName,
+			}
 		}
 		c.AddTask(poolTask)
 
 		nameForResource := fi.ValueOf(lbTask.Name)
 		listenerTask := &openstacktasks.LBListener{
-			Name:      fi.PtrTo(nameForResource),
-			Port:      fi.PtrTo(wellknownports.KubeAPIServer),
-			Lifecycle: b.Lifecycle,
-			Pool:      poolTask,
+			Name:                 fi.PtrTo(nameForResource),
+			Port:                 fi.PtrTo(wellknownports.KubeAPIServer),
+			Lifecycle:            b.Lifecycle,
+			Pool:                 poolTask,
+			ConnLimit:            b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.ConnLimit,
+			TimeoutClientData:    milliseconds(b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.TimeoutClientData),
+			TimeoutMemberData:    milliseconds(b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.TimeoutMemberData),
+			TimeoutMemberConnect: milliseconds(b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.TimeoutMemberConnect),
+			TimeoutTCPInspect:    milliseconds(b.Cluster.Spec.CloudProvider.Openstack.Loadbalancer.TimeoutTCPInspect),
 		}
 		if useVIPACL {
 			var AllowedCIDRs []string
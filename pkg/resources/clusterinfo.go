@@ -16,6 +16,8 @@ limitations under the License.
 
 package resources
 
+import "time"
+
 type ClusterInfo struct {
 	Name        string
 	UsesNoneDNS bool
@@ -24,4 +26,9 @@ type ClusterInfo struct {
 	AzureResourceGroupShared bool
 	AzureNetworkShared       bool
 	AzureRouteTableShared    bool
+	// OpenStack specific
+	OpenstackLBDeleteTimeout time.Duration
+	OpenstackLBCascadeDelete *bool
+	// OpenstackSharedLBID is the ID of a loadbalancer managed outside of kOps, it is never deleted with the cluster
+	OpenstackSharedLBID string
 }
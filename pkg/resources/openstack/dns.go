@@ -25,6 +25,7 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/dns/v2/zones"
 	"k8s.io/kops/pkg/resources"
 	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
 )
 
 const (
@@ -61,7 +62,10 @@ func (os *clusterDiscoveryOS) ListDNSRecordsets() ([]*resources.Resource, error)
 
 	var resourceTrackers []*resources.Resource
 	for _, rr := range rrs {
-		if rr.Type != "A" || !strings.HasSuffix(strings.TrimSuffix(rr.Name, "."), os.clusterName) {
+		clusterRecord := rr.Type == "A" && strings.HasSuffix(strings.TrimSuffix(rr.Name, "."), os.clusterName)
+		// records managed by the DNSRecordSet task are marked with the cluster name, their name might not include it
+		managedRecord := (rr.Type == "A" || rr.Type == "AAAA") && rr.Description == openstacktasks.DNSRecordSetDescription(os.clusterName)
+		if !clusterRecord && !managedRecord {
 			continue
 		}
 
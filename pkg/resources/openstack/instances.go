@@ -17,10 +17,13 @@ limitations under the License.
 package openstack
 
 import (
+	"slices"
+
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"k8s.io/kops/pkg/resources"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
 )
 
 const (
@@ -34,9 +37,11 @@ func (os *clusterDiscoveryOS) ListInstances() ([]*resources.Resource, error) {
 		return resourceTrackers, err
 	}
 
+	clusterTag := openstacktasks.ClusterTag(os.clusterName)
 	for _, instance := range instances {
 		val, ok := instance.Metadata["k8s"]
-		if ok && val == os.clusterName {
+		tagged := instance.Tags != nil && slices.Contains(*instance.Tags, clusterTag)
+		if (ok && val == os.clusterName) || tagged {
 			// Clean up any bound floating IP's
 			floatingIPs, err := os.listFloatingIPs(instance)
 			if err != nil {
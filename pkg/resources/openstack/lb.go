@@ -18,7 +18,6 @@ package openstack
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/monitors"
@@ -27,6 +26,7 @@ import (
 	"k8s.io/kops/pkg/resources"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
 )
 
 const (
@@ -39,10 +39,7 @@ const (
 func (os *clusterDiscoveryOS) DeleteSubnetLBs(subnet subnets.Subnet) ([]*resources.Resource, error) {
 	var resourceTrackers []*resources.Resource
 
-	preExistingSubnet := false
-	if !strings.HasSuffix(subnet.Name, os.clusterName) {
-		preExistingSubnet = true
-	}
+	preExistingSubnet := !isSubnetCreatedByCluster(subnet, os.clusterName)
 
 	opts := loadbalancers.ListOpts{
 		VipSubnetID: subnet.ID,
@@ -56,14 +53,24 @@ func (os *clusterDiscoveryOS) DeleteSubnetLBs(subnet subnets.Subnet) ([]*resourc
 	if preExistingSubnet {
 		// if we have preExistingSubnet, we cannot delete others than api LB
 		for _, lb := range lbs {
-			if lb.Name == fmt.Sprintf("api.%s", os.clusterName) {
+			if lb.ID == os.sharedLBID {
+				continue
+			}
+			if lb.Name == fmt.Sprintf("api.%s", os.clusterName) || openstacktasks.HasClusterTag(lb.Tags, os.clusterName) {
 				filteredLBs = append(filteredLBs, lb)
 			}
 		}
 	} else {
-		filteredLBs = lbs
+		for _, lb := range lbs {
+			// a shared loadbalancer is never deleted, even if it is named like the API loadbalancer of the cluster
+			if lb.ID != os.sharedLBID {
+				filteredLBs = append(filteredLBs, lb)
+			}
+		}
 	}
 
+	cascade := os.lbCascadeDelete
+	timeout := os.lbDeleteTimeout
 	for _, lb := range filteredLBs {
 		resourceTracker := &resources.Resource{
 			Name: lb.Name,
@@ -71,15 +78,19 @@ func (os *clusterDiscoveryOS) DeleteSubnetLBs(subnet subnets.Subnet) ([]*resourc
 			Type: typeLB,
 			Deleter: func(cloud fi.Cloud, r *resources.Resource) error {
 				opts := loadbalancers.DeleteOpts{
-					Cascade: true,
+					Cascade: cascade,
+				}
+				err := cloud.(openstack.OpenstackCloud).DeleteLB(r.ID, opts)
+				if err != nil {
+					return err
 				}
-				return cloud.(openstack.OpenstackCloud).DeleteLB(r.ID, opts)
+				return openstack.WaitLBDeleted(cloud.(openstack.OpenstackCloud), r.ID, timeout)
 			},
 		}
 		resourceTrackers = append(resourceTrackers, resourceTracker)
 
-		if os.osCloud.UseOctavia() {
-			klog.V(2).Info("skipping LB Children because using Octavia")
+		if cascade {
+			klog.V(2).Info("skipping LB Children because of cascade delete")
 			continue
 		}
 
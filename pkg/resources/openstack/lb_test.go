@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
+)
+
+func Test_DeleteSubnetLBs_SharedLB(t *testing.T) {
+	tests := []struct {
+		desc   string
+		subnet subnets.Subnet
+	}{
+		{
+			desc:   "subnet created by the cluster",
+			subnet: subnets.Subnet{ID: "subnet", Name: "utility.cluster"},
+		},
+		{
+			desc:   "existing subnet",
+			subnet: subnets.Subnet{ID: "subnet", Name: "shared"},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockLBClient: mockloadbalancer.CreateClient(),
+			}
+			// the shared loadbalancer is named and tagged like the API loadbalancer of the cluster
+			shared, err := cloud.CreateLB(loadbalancers.CreateOpts{
+				Name:        "api.cluster",
+				VipSubnetID: "subnet",
+				Tags:        openstacktasks.ClusterTags("cluster"),
+			})
+			if err != nil {
+				t.Fatalf("error creating loadbalancer: %v", err)
+			}
+			owned, err := cloud.CreateLB(loadbalancers.CreateOpts{
+				Name:        "owned",
+				VipSubnetID: "subnet",
+				Tags:        openstacktasks.ClusterTags("cluster"),
+			})
+			if err != nil {
+				t.Fatalf("error creating loadbalancer: %v", err)
+			}
+
+			os := &clusterDiscoveryOS{
+				cloud:           cloud,
+				osCloud:         cloud,
+				clusterName:     "cluster",
+				sharedLBID:      shared.ID,
+				lbCascadeDelete: true,
+			}
+			resources, err := os.DeleteSubnetLBs(testCase.subnet)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resources) != 1 || resources[0].ID != owned.ID {
+				t.Errorf("expected only loadbalancer %s to be deleted, got %v", owned.ID, resources)
+			}
+		})
+	}
+}
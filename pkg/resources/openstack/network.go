@@ -17,6 +17,7 @@ limitations under the License.
 package openstack
 
 import (
+	"slices"
 	"strings"
 
 	osrouter "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/routers"
@@ -26,6 +27,7 @@ import (
 	"k8s.io/kops/pkg/resources"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
 )
 
 const (
@@ -48,7 +50,7 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 
 	filteredNetwork := []networks.Network{}
 	for _, net := range projectNetworks {
-		if net.Name == os.clusterName || fi.ArrayContains(net.Tags, os.clusterName) {
+		if net.Name == os.clusterName || openstacktasks.HasClusterTag(net.Tags, os.clusterName) {
 			filteredNetwork = append(filteredNetwork, net)
 		}
 	}
@@ -59,6 +61,8 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 		if os.clusterName == network.Name {
 			preExistingNet = false
 		}
+		// a network created by kOps is only detached from the cluster if one of its subnets is kept, it cannot be deleted
+		keepNetwork := preExistingNet
 
 		optRouter := osrouter.ListOpts{
 			Name: routerName,
@@ -97,7 +101,7 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 		if preExistingNet {
 			// if we have preExistingNet, the subnet must have cluster tag
 			for _, sub := range networkSubnets {
-				if fi.ArrayContains(sub.Tags, os.clusterName) {
+				if openstacktasks.HasClusterTag(sub.Tags, os.clusterName) {
 					filteredSubnets = append(filteredSubnets, sub)
 				}
 			}
@@ -107,10 +111,7 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 
 		for _, subnet := range filteredSubnets {
 			// router interfaces
-			preExistingSubnet := false
-			if !strings.HasSuffix(subnet.Name, os.clusterName) {
-				preExistingSubnet = true
-			}
+			preExistingSubnet := !isSubnetCreatedByCluster(subnet, os.clusterName)
 
 			if !preExistingSubnet {
 				for _, router := range routers {
@@ -146,12 +147,23 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 				}
 				resourceTrackers = append(resourceTrackers, resourceTracker)
 			} else {
+				keepNetwork = true
+				// the subnet is only detached from the cluster by removing the tags of the cluster
+				tags := []string{os.clusterName}
+				if preserveTag := openstacktasks.PreserveTag(os.clusterName); slices.Contains(subnet.Tags, preserveTag) {
+					tags = append(tags, preserveTag)
+				}
 				resourceTracker := &resources.Resource{
 					Name: os.clusterName,
 					ID:   subnet.ID,
 					Type: typeSubnetTag,
 					Deleter: func(cloud fi.Cloud, r *resources.Resource) error {
-						return cloud.(openstack.OpenstackCloud).DeleteTag(openstack.ResourceTypeSubnet, r.ID, r.Name)
+						for _, tag := range tags {
+							if err := cloud.(openstack.OpenstackCloud).DeleteTag(openstack.ResourceTypeSubnet, r.ID, tag); err != nil {
+								return err
+							}
+						}
+						return nil
 					},
 				}
 				resourceTrackers = append(resourceTrackers, resourceTracker)
@@ -165,7 +177,7 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 		}
 		resourceTrackers = append(resourceTrackers, portTrackers...)
 
-		if !preExistingNet {
+		if !keepNetwork {
 			resourceTracker := &resources.Resource{
 				Name: network.Name,
 				ID:   network.ID,
@@ -189,3 +201,12 @@ func (os *clusterDiscoveryOS) ListNetwork() ([]*resources.Resource, error) {
 	}
 	return resourceTrackers, nil
 }
+
+// isSubnetCreatedByCluster returns true if the subnet was created by kOps for the cluster and is deleted with it.
+// Subnets created by older versions of kOps are not tagged, they are named after the cluster.
+func isSubnetCreatedByCluster(subnet subnets.Subnet, clusterName string) bool {
+	if slices.Contains(subnet.Tags, openstacktasks.PreserveTag(clusterName)) {
+		return false
+	}
+	return slices.Contains(subnet.Tags, openstacktasks.ClusterTag(clusterName)) || strings.HasSuffix(subnet.Name, clusterName)
+}
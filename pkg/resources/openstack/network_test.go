@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/pkg/resources"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
+)
+
+func Test_ListNetwork_PreservedSubnet(t *testing.T) {
+	tests := []struct {
+		desc          string
+		subnetTags    []string
+		deleteNetwork bool
+	}{
+		{
+			desc:          "all subnets created by the cluster",
+			subnetTags:    openstacktasks.ClusterTags("cluster"),
+			deleteNetwork: true,
+		},
+		{
+			desc:       "preserved subnet",
+			subnetTags: []string{"cluster", openstacktasks.PreserveTag("cluster")},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockNeutronClient: mocknetworking.CreateClient(),
+				MockLBClient:      mockloadbalancer.CreateClient(),
+			}
+			// the network is created by kOps, it is named after the cluster
+			network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+			if err != nil {
+				t.Fatalf("error creating network: %v", err)
+			}
+			if err := cloud.AppendTag(openstack.ResourceTypeNetwork, network.ID, "cluster"); err != nil {
+				t.Fatalf("error tagging network: %v", err)
+			}
+			owned, err := cloud.CreateSubnet(subnets.CreateOpts{NetworkID: network.ID, Name: "nodes.cluster", CIDR: "10.0.1.0/24", IPVersion: 4, EnableDHCP: fi.PtrTo(true)})
+			if err != nil {
+				t.Fatalf("error creating subnet: %v", err)
+			}
+			if err := cloud.AppendTag(openstack.ResourceTypeSubnet, owned.ID, openstacktasks.ClusterTag("cluster")); err != nil {
+				t.Fatalf("error tagging subnet: %v", err)
+			}
+			subnet, err := cloud.CreateSubnet(subnets.CreateOpts{NetworkID: network.ID, Name: "utility.cluster", CIDR: "10.0.2.0/24", IPVersion: 4, EnableDHCP: fi.PtrTo(true)})
+			if err != nil {
+				t.Fatalf("error creating subnet: %v", err)
+			}
+			for _, tag := range testCase.subnetTags {
+				if err := cloud.AppendTag(openstack.ResourceTypeSubnet, subnet.ID, tag); err != nil {
+					t.Fatalf("error tagging subnet: %v", err)
+				}
+			}
+
+			os := &clusterDiscoveryOS{
+				cloud:       cloud,
+				osCloud:     cloud,
+				clusterName: "cluster",
+			}
+			trackers, err := os.ListNetwork()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var networkTrackers []*resources.Resource
+			for _, tracker := range trackers {
+				if tracker.Type == typeNetwork || tracker.Type == typeNetworkTag {
+					networkTrackers = append(networkTrackers, tracker)
+				}
+			}
+			if len(networkTrackers) != 1 || networkTrackers[0].ID != network.ID {
+				t.Fatalf("expected one resource for network %s, got %v", network.ID, networkTrackers)
+			}
+			if testCase.deleteNetwork {
+				if networkTrackers[0].Type != typeNetwork {
+					t.Errorf("expected network to be deleted, got %s", networkTrackers[0].Type)
+				}
+				return
+			}
+			if networkTrackers[0].Type != typeNetworkTag {
+				t.Fatalf("expected network to be detached from the cluster, got %s", networkTrackers[0].Type)
+			}
+			if err := networkTrackers[0].Deleter(cloud, networkTrackers[0]); err != nil {
+				t.Fatalf("error detaching network: %v", err)
+			}
+			detached, err := cloud.GetNetwork(network.ID)
+			if err != nil {
+				t.Fatalf("error getting network: %v", err)
+			}
+			if slices.Contains(detached.Tags, "cluster") {
+				t.Errorf("expected the cluster tag to be removed from the network, got %v", detached.Tags)
+			}
+		})
+	}
+}
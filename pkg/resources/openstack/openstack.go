@@ -17,6 +17,8 @@ limitations under the License.
 package openstack
 
 import (
+	"time"
+
 	"k8s.io/kops/pkg/resources"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
@@ -28,6 +30,12 @@ type clusterDiscoveryOS struct {
 	cloud       fi.Cloud
 	osCloud     openstack.OpenstackCloud
 	clusterName string
+	// lbDeleteTimeout is the time to wait for a loadbalancer to be deleted
+	lbDeleteTimeout time.Duration
+	// lbCascadeDelete deletes the loadbalancer children together with the loadbalancer
+	lbCascadeDelete bool
+	// sharedLBID is the ID of the loadbalancer used by the cluster but managed outside of kOps
+	sharedLBID string
 }
 
 // ListResources lists the OpenStack resources kops manages
@@ -39,6 +47,16 @@ func ListResources(cloud openstack.OpenstackCloud, clusterInfo resources.Cluster
 		osCloud:     cloud,
 		clusterName: clusterInfo.Name,
 	}
+	os.sharedLBID = clusterInfo.OpenstackSharedLBID
+	os.lbDeleteTimeout = clusterInfo.OpenstackLBDeleteTimeout
+	if os.lbDeleteTimeout <= 0 {
+		os.lbDeleteTimeout = openstack.DefaultLBDeleteTimeout
+	}
+	if clusterInfo.OpenstackLBCascadeDelete != nil {
+		os.lbCascadeDelete = *clusterInfo.OpenstackLBCascadeDelete
+	} else {
+		os.lbCascadeDelete = cloud.UseOctavia()
+	}
 
 	listFunctions := []openstackListFn{
 		os.ListKeypairs,
@@ -22,6 +22,7 @@ import (
 	"k8s.io/kops/pkg/resources"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
 )
 
 const (
@@ -48,7 +49,7 @@ func (os *clusterDiscoveryOS) ListPorts(network networks.Network) ([]*resources.
 	if preExistingNet {
 		// if we have preExistingNet, the port must have cluster tag
 		for _, singlePort := range projectPorts {
-			if fi.ArrayContains(singlePort.Tags, os.clusterName) {
+			if openstacktasks.HasClusterTag(singlePort.Tags, os.clusterName) {
 				filteredPorts = append(filteredPorts, singlePort)
 			}
 		}
@@ -55,6 +55,14 @@ func ListResources(cloud fi.Cloud, cluster *kops.Cluster) (map[string]*resources
 	case kops.CloudProviderHetzner:
 		return hetzner.ListResources(cloud.(cloudhetzner.HetznerCloud), clusterInfo)
 	case kops.CloudProviderOpenstack:
+		if cluster.Spec.CloudProvider.Openstack != nil && cluster.Spec.CloudProvider.Openstack.Loadbalancer != nil {
+			lbConfig := cluster.Spec.CloudProvider.Openstack.Loadbalancer
+			if lbConfig.DeleteTimeout != nil {
+				clusterInfo.OpenstackLBDeleteTimeout = lbConfig.DeleteTimeout.Duration
+			}
+			clusterInfo.OpenstackLBCascadeDelete = lbConfig.CascadeDelete
+			clusterInfo.OpenstackSharedLBID = fi.ValueOf(lbConfig.ID)
+		}
 		return openstack.ListResources(cloud.(cloudopenstack.OpenstackCloud), clusterInfo)
 	case kops.CloudProviderAzure:
 		clusterInfo.AzureResourceGroupName = cluster.AzureResourceGroupName()
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+// ValidationLoadBalancer represents the status of a loadbalancer of the cluster
+type ValidationLoadBalancer struct {
+	Name               string `json:"name,omitempty"`
+	ID                 string `json:"id,omitempty"`
+	ProvisioningStatus string `json:"provisioningStatus,omitempty"`
+	OperatingStatus    string `json:"operatingStatus,omitempty"`
+	// CreatedAt and UpdatedAt are the times the loadbalancer was created and last changed, they are not set if
+	// Octavia does not report them
+	CreatedAt *metav1.Time `json:"createdAt,omitempty"`
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+	// Stats are the statistics of the loadbalancer, they are only fetched if requested
+	Stats *ValidationLoadBalancerStats `json:"stats,omitempty"`
+}
+
+// ValidationLoadBalancerStats are the statistics of a loadbalancer
+type ValidationLoadBalancerStats struct {
+	ActiveConnections int `json:"activeConnections"`
+	TotalConnections  int `json:"totalConnections"`
+	BytesIn           int `json:"bytesIn"`
+	BytesOut          int `json:"bytesOut"`
+	RequestErrors     int `json:"requestErrors"`
+}
+
+// newValidationLoadBalancerStats converts the statistics reported by Octavia
+func newValidationLoadBalancerStats(stats *loadbalancers.Stats) *ValidationLoadBalancerStats {
+	return &ValidationLoadBalancerStats{
+		ActiveConnections: stats.ActiveConnections,
+		TotalConnections:  stats.TotalConnections,
+		BytesIn:           stats.BytesIn,
+		BytesOut:          stats.BytesOut,
+		RequestErrors:     stats.RequestErrors,
+	}
+}
+
+// lbTime returns the timestamp of a loadbalancer, nil if it is not set
+func lbTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &metav1.Time{Time: t}
+}
+
+// validateOpenstackLoadBalancers reports the status of the API loadbalancers and flags them if they are not healthy.
+// The statistics of the loadbalancers are reported if requested, not every provider implements them.
+func (v *ValidationCluster) validateOpenstackLoadBalancers(cloud openstack.OpenstackCloud, cluster *kops.Cluster, withStats bool) error {
+	lbs, err := openstack.FindAPILoadBalancers(cloud, cluster)
+	if err != nil {
+		return err
+	}
+	if len(lbs) == 0 {
+		v.addError(&ValidationError{
+			Kind:    "LoadBalancer",
+			Name:    "apiserver",
+			Message: "loadbalancer of the API was not found",
+		})
+		return nil
+	}
+
+	for _, lb := range lbs {
+		statuses, err := cloud.GetLBStatuses(lb.ID)
+		if err != nil {
+			return fmt.Errorf("error getting statuses of loadbalancer %s: %v", lb.ID, err)
+		}
+		if statuses.Loadbalancer == nil {
+			return fmt.Errorf("statuses of loadbalancer %s are missing", lb.ID)
+		}
+		v.validateOpenstackLoadBalancer(statuses.Loadbalancer)
+		// the status tree does not contain the timestamps of the loadbalancer
+		v.LoadBalancers[len(v.LoadBalancers)-1].CreatedAt = lbTime(lb.CreatedAt)
+		v.LoadBalancers[len(v.LoadBalancers)-1].UpdatedAt = lbTime(lb.UpdatedAt)
+
+		if withStats {
+			stats, err := cloud.GetLBStats(lb.ID)
+			if err != nil {
+				klog.Warningf("statistics of loadbalancer %s are not available: %v", lb.ID, err)
+				continue
+			}
+			v.LoadBalancers[len(v.LoadBalancers)-1].Stats = newValidationLoadBalancerStats(stats)
+		}
+	}
+	return nil
+}
+
+// validateOpenstackLoadBalancer adds the status of the loadbalancer tree and failures for the loadbalancer and its
+// members if they are not healthy
+func (v *ValidationCluster) validateOpenstackLoadBalancer(lb *loadbalancers.LoadBalancer) {
+	v.LoadBalancers = append(v.LoadBalancers, &ValidationLoadBalancer{
+		Name:               lb.Name,
+		ID:                 lb.ID,
+		ProvisioningStatus: lb.ProvisioningStatus,
+		OperatingStatus:    lb.OperatingStatus,
+	})
+
+	if lb.ProvisioningStatus != "ACTIVE" {
+		v.addError(&ValidationError{
+			Kind:    "LoadBalancer",
+			Name:    lb.Name,
+			Message: fmt.Sprintf("loadbalancer %q has provisioning status %s", lb.Name, lb.ProvisioningStatus),
+		})
+	}
+	if lb.OperatingStatus != "ONLINE" {
+		v.addError(&ValidationError{
+			Kind:    "LoadBalancer",
+			Name:    lb.Name,
+			Message: fmt.Sprintf("loadbalancer %q has operating status %s", lb.Name, lb.OperatingStatus),
+		})
+	}
+
+	for _, listener := range lb.Listeners {
+		for _, pool := range listener.Pools {
+			for _, member := range pool.Members {
+				// members are ONLINE or NO_MONITOR if they are healthy
+				if member.OperatingStatus == "ERROR" || member.OperatingStatus == "OFFLINE" {
+					v.addError(&ValidationError{
+						Kind:    "LoadBalancer",
+						Name:    lb.Name,
+						Message: fmt.Sprintf("member %q of pool %q has operating status %s", member.Name, pool.Name, member.OperatingStatus),
+					})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/listeners"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_ValidateOpenstackLoadBalancer(t *testing.T) {
+	lbWithMembers := func(provisioningStatus, operatingStatus string, memberStatuses ...string) *loadbalancers.LoadBalancer {
+		var members []pools.Member
+		for i, status := range memberStatuses {
+			members = append(members, pools.Member{
+				Name:            []string{"master-a", "master-b", "master-c"}[i],
+				OperatingStatus: status,
+			})
+		}
+		return &loadbalancers.LoadBalancer{
+			ID:                 "lb-id",
+			Name:               "api.cluster",
+			ProvisioningStatus: provisioningStatus,
+			OperatingStatus:    operatingStatus,
+			Listeners: []listeners.Listener{
+				{
+					Pools: []pools.Pool{
+						{
+							Name:    "api.cluster-https",
+							Members: members,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		desc     string
+		lb       *loadbalancers.LoadBalancer
+		failures []*ValidationError
+	}{
+		{
+			desc: "healthy loadbalancer",
+			lb:   lbWithMembers("ACTIVE", "ONLINE", "ONLINE", "NO_MONITOR"),
+		},
+		{
+			desc: "degraded loadbalancer with member down",
+			lb:   lbWithMembers("ACTIVE", "DEGRADED", "ONLINE", "ERROR"),
+			failures: []*ValidationError{
+				{
+					Kind:    "LoadBalancer",
+					Name:    "api.cluster",
+					Message: `loadbalancer "api.cluster" has operating status DEGRADED`,
+				},
+				{
+					Kind:    "LoadBalancer",
+					Name:    "api.cluster",
+					Message: `member "master-b" of pool "api.cluster-https" has operating status ERROR`,
+				},
+			},
+		},
+		{
+			desc: "loadbalancer pending update",
+			lb:   lbWithMembers("PENDING_UPDATE", "ONLINE"),
+			failures: []*ValidationError{
+				{
+					Kind:    "LoadBalancer",
+					Name:    "api.cluster",
+					Message: `loadbalancer "api.cluster" has provisioning status PENDING_UPDATE`,
+				},
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			v := &ValidationCluster{}
+			v.validateOpenstackLoadBalancer(testCase.lb)
+
+			assert.Equal(t, testCase.failures, v.Failures)
+			assert.Equal(t, []*ValidationLoadBalancer{
+				{
+					Name:               "api.cluster",
+					ID:                 "lb-id",
+					ProvisioningStatus: testCase.lb.ProvisioningStatus,
+					OperatingStatus:    testCase.lb.OperatingStatus,
+				},
+			}, v.LoadBalancers)
+		})
+	}
+}
+
+func Test_ValidateOpenstackLoadBalancers_Stats(t *testing.T) {
+	tests := []struct {
+		desc      string
+		withStats bool
+		expected  *ValidationLoadBalancerStats
+	}{
+		{
+			desc: "statistics not requested",
+		},
+		{
+			desc:      "statistics requested",
+			withStats: true,
+			expected:  &ValidationLoadBalancerStats{},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockLBClient: mockloadbalancer.CreateClient(),
+			}
+			if _, err := cloud.CreateLB(loadbalancers.CreateOpts{Name: "api.cluster", VipSubnetID: "subnet-id"}); err != nil {
+				t.Fatalf("error creating loadbalancer: %v", err)
+			}
+			cluster := &kops.Cluster{}
+			cluster.Name = "cluster"
+			cluster.Spec.CloudProvider.Openstack = &kops.OpenstackSpec{
+				Loadbalancer: &kops.OpenstackLoadbalancerConfig{},
+			}
+
+			v := &ValidationCluster{}
+			if err := v.validateOpenstackLoadBalancers(cloud, cluster, testCase.withStats); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(v.LoadBalancers) != 1 {
+				t.Fatalf("expected 1 loadbalancer, got %v", v.LoadBalancers)
+			}
+			assert.Equal(t, testCase.expected, v.LoadBalancers[0].Stats)
+		})
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -42,6 +43,8 @@ type ValidationCluster struct {
 	Failures []*ValidationError `json:"failures,omitempty"`
 
 	Nodes []*ValidationNode `json:"nodes,omitempty"`
+
+	LoadBalancers []*ValidationLoadBalancer `json:"loadBalancers,omitempty"`
 }
 
 // ValidationError holds a validation failure
@@ -58,12 +61,19 @@ type ClusterValidator interface {
 	Validate() (*ValidationCluster, error)
 }
 
+// ClusterValidatorOptions are the optional checks of the cluster validation
+type ClusterValidatorOptions struct {
+	// LoadBalancerStats reports the statistics of the API loadbalancers of OpenStack clusters
+	LoadBalancerStats bool
+}
+
 type clusterValidatorImpl struct {
 	cluster        *kops.Cluster
 	cloud          fi.Cloud
 	instanceGroups []*kops.InstanceGroup
 	host           string
 	k8sClient      kubernetes.Interface
+	options        ClusterValidatorOptions
 }
 
 func (v *ValidationCluster) addError(failure *ValidationError) {
@@ -101,6 +111,11 @@ func hasPlaceHolderIP(host string) (string, error) {
 }
 
 func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupList *kops.InstanceGroupList, host string, k8sClient kubernetes.Interface) (ClusterValidator, error) {
+	return NewClusterValidatorWithOptions(cluster, cloud, instanceGroupList, host, k8sClient, ClusterValidatorOptions{})
+}
+
+// NewClusterValidatorWithOptions builds a validator that also runs the optional checks
+func NewClusterValidatorWithOptions(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupList *kops.InstanceGroupList, host string, k8sClient kubernetes.Interface, options ClusterValidatorOptions) (ClusterValidator, error) {
 	var instanceGroups []*kops.InstanceGroup
 
 	for i := range instanceGroupList.Items {
@@ -118,6 +133,7 @@ func NewClusterValidator(cluster *kops.Cluster, cloud fi.Cloud, instanceGroupLis
 		instanceGroups: instanceGroups,
 		host:           host,
 		k8sClient:      k8sClient,
+		options:        options,
 	}, nil
 }
 
@@ -170,6 +186,12 @@ func (v *clusterValidatorImpl) Validate() (*ValidationCluster, error) {
 		return nil, fmt.Errorf("cannot get pod health for %q: %v", v.cluster.Name, err)
 	}
 
+	if osCloud, ok := v.cloud.(openstack.OpenstackCloud); ok && v.cluster.Spec.CloudProvider.Openstack != nil && v.cluster.Spec.CloudProvider.Openstack.Loadbalancer != nil {
+		if err := validation.validateOpenstackLoadBalancers(osCloud, v.cluster, v.options.LoadBalancerStats); err != nil {
+			return nil, fmt.Errorf("cannot get loadbalancer health for %q: %v", v.cluster.Name, err)
+		}
+	}
+
 	return validation, nil
 }
 
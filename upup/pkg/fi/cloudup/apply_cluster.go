@@ -60,6 +60,7 @@ import (
 	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
 	"k8s.io/kops/upup/pkg/fi/cloudup/hetzner"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstacktasks"
 	"k8s.io/kops/upup/pkg/fi/cloudup/scaleway"
 	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
 	"k8s.io/kops/upup/pkg/fi/cloudup/terraformWriter"
@@ -462,6 +463,13 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 			if len(sshPublicKeys) != 1 {
 				return fmt.Errorf("exactly one 'admin' SSH public key can be specified when running with Openstack; please delete a key using `kops delete secret`")
 			}
+
+			if cluster.Spec.CloudProvider.Openstack != nil && cluster.Spec.CloudProvider.Openstack.Loadbalancer != nil {
+				osCloud := cloud.(openstack.OpenstackCloud)
+				if err := osCloud.CheckLoadBalancerService(); err != nil {
+					return fmt.Errorf("cluster %s requires an OpenStack loadbalancer, but the load balancer service (Octavia) is not available: %v; enable the load balancer service in the cloud or remove spec.cloudProvider.openstack.loadbalancer from the cluster spec", cluster.ObjectMeta.Name, err)
+				}
+			}
 		}
 
 	case kops.CloudProviderScaleway:
@@ -773,6 +781,13 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 		}
 	}
 
+	if c.TargetName == TargetDirect && cluster.Spec.GetCloudProvider() == kops.CloudProviderOpenstack {
+		// fail before creating anything instead of leaving a partial cluster behind
+		if err := openstacktasks.CheckQuota(cloud.(openstack.OpenstackCloud), c.TaskMap); err != nil {
+			return fmt.Errorf("cannot apply cluster %s: %w", cluster.ObjectMeta.Name, err)
+		}
+	}
+
 	context, err := fi.NewCloudupContext(ctx, deletionProcessingMode, target, cluster, cloud, keyStore, secretStore, configBase, c.TaskMap)
 	if err != nil {
 		return fmt.Errorf("error building context: %v", err)
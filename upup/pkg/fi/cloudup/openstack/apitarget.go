@@ -17,6 +17,7 @@ limitations under the License.
 package openstack
 
 import (
+	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 )
 
@@ -33,6 +34,7 @@ func NewOpenstackAPITarget(cloud OpenstackCloud) *OpenstackAPITarget {
 }
 
 func (t *OpenstackAPITarget) Finish(taskMap map[string]fi.CloudupTask) error {
+	klog.V(2).Infof("Made %d OpenStack API requests", APIRequestCount())
 	return nil
 }
 
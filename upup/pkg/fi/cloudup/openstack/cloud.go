@@ -17,11 +17,14 @@ limitations under the License.
 package openstack
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blang/semver/v4"
@@ -42,8 +45,10 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/monitors"
 	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/agents"
 	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/routers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/qos/policies"
 	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	sgr "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
@@ -70,6 +75,7 @@ const (
 	TagKopsNetwork           = "KopsNetwork"
 	TagKopsName              = "KopsName"
 	TagKopsRole              = "KopsRole"
+	TagKopsPreserve          = "KopsPreserve"
 	ResourceTypePort         = "ports"
 	ResourceTypeNetwork      = "networks"
 	ResourceTypeSubnet       = "subnets"
@@ -122,6 +128,18 @@ type OpenstackCloud interface {
 	// DeleteInstanceWithID will delete instance
 	DeleteInstanceWithID(instanceID string) error
 
+	// UpdateInstanceMetadata will set the given metadata keys on the server, other keys are kept
+	UpdateInstanceMetadata(instanceID string, metadata map[string]string) error
+
+	// DeleteInstanceMetadata will remove the metadata key from the server
+	DeleteInstanceMetadata(instanceID string, key string) error
+
+	// AddInstanceTag will add the tag to the server
+	AddInstanceTag(instanceID string, tag string) error
+
+	// DeleteInstanceTag will remove the tag from the server
+	DeleteInstanceTag(instanceID string, tag string) error
+
 	// SetVolumeTags will set the tags for the Cinder volume
 	SetVolumeTags(id string, tags map[string]string) error
 
@@ -138,9 +156,22 @@ type OpenstackCloud interface {
 	// DeleteVolume will delete volume
 	DeleteVolume(volumeID string) error
 
+	// ExtendVolume will grow the Cinder volume to the given size, also if it is attached
+	ExtendVolume(volumeID string, sizeGB int) error
+
+	// RetypeVolume will change the type of the Cinder volume, migrating it if needed
+	RetypeVolume(volumeID string, volumeType string) error
+
+	// IsVolumeTypeEncrypted returns whether the Cinder volume type, given by name or ID, has encryption configured
+	IsVolumeTypeEncrypted(volumeType string) (bool, error)
+
 	// ListSecurityGroups will return the Neutron security groups which match the options
 	ListSecurityGroups(opt sg.ListOpts) ([]sg.SecGroup, error)
 
+	// ListSecurityGroupsWithContext lists the security groups like ListSecurityGroups, it stops retrying and
+	// cancels the request when the context is done
+	ListSecurityGroupsWithContext(ctx context.Context, opt sg.ListOpts) ([]sg.SecGroup, error)
+
 	// CreateSecurityGroup will create a new Neutron security group
 	CreateSecurityGroup(opt sg.CreateOptsBuilder) (*sg.SecGroup, error)
 
@@ -165,12 +196,18 @@ type OpenstackCloud interface {
 	// GetSubnet returns subnet using subnet id
 	GetSubnet(subnetID string) (*subnets.Subnet, error)
 
+	// GetSubnetSegmentID returns the segment of the subnet on a routed provider network, or empty if it has none
+	GetSubnetSegmentID(subnetID string) (string, error)
+
 	// ListNetworks will return the Neutron networks which match the options
 	ListNetworks(opt networks.ListOptsBuilder) ([]networks.Network, error)
 
 	// GetExternalNetwork will return the Neutron networks with the router:external property
 	GetExternalNetwork() (*networks.Network, error)
 
+	// FindExternalNetwork returns the Neutron network with the router:external property and the name, nil if there is none
+	FindExternalNetwork(name string) (*networks.Network, error)
+
 	// GetExternalSubnet will return the subnet for floatingip which is used in external router
 	GetExternalSubnet() (*subnets.Subnet, error)
 
@@ -229,6 +266,16 @@ type OpenstackCloud interface {
 	// ListPorts will return the Neutron ports which match the options
 	ListPorts(opt ports.ListOptsBuilder) ([]ports.Port, error)
 
+	// ListQosPolicies will return the Neutron QoS policies which match the options
+	ListQosPolicies(opt policies.ListOpts) ([]policies.Policy, error)
+
+	// FindQosPolicy will return the Neutron QoS policy with the given name or id
+	FindQosPolicy(nameOrID string) (*policies.Policy, error)
+
+	// FindQosPolicyWithContext finds the QoS policy like FindQosPolicy, it stops retrying and cancels the request
+	// when the context is done
+	FindQosPolicyWithContext(ctx context.Context, nameOrID string) (*policies.Policy, error)
+
 	// DeletePort will delete a neutron port
 	DeletePort(portID string) error
 
@@ -250,14 +297,45 @@ type OpenstackCloud interface {
 	// ListDNSZones will list available DNS zones
 	ListDNSZones(opt zones.ListOptsBuilder) ([]zones.Zone, error)
 
+	// FindDNSZone will return the DNS zone with the given name or id, the result is cached
+	FindDNSZone(nameOrID string) (*zones.Zone, error)
+
 	// ListDNSRecordsets will list the DNS recordsets for the given zone id
 	ListDNSRecordsets(zoneID string, opt recordsets.ListOptsBuilder) ([]recordsets.RecordSet, error)
 	DeleteDNSRecordset(zoneID string, rrsetID string) error
 
+	// CreateDNSRecordset will create a DNS recordset in the given zone
+	CreateDNSRecordset(zoneID string, opt recordsets.CreateOptsBuilder) (*recordsets.RecordSet, error)
+
+	// UpdateDNSRecordset will update the DNS recordset in the given zone
+	UpdateDNSRecordset(zoneID string, rrsetID string, opt recordsets.UpdateOptsBuilder) (*recordsets.RecordSet, error)
+
 	GetLB(loadbalancerID string) (*loadbalancers.LoadBalancer, error)
 	GetLBStats(loadbalancerID string) (*loadbalancers.Stats, error)
+
+	// GetLBStatuses returns the status tree of the loadbalancer, including its listeners, pools and members
+	GetLBStatuses(loadbalancerID string) (*loadbalancers.StatusTree, error)
+
+	// GetLBFlavor returns the Octavia flavor
+	GetLBFlavor(flavorID string) (*LBFlavor, error)
+
+	// GetLBFlavorProfile returns the Octavia flavor profile, which by default can only be read by administrators
+	GetLBFlavorProfile(flavorProfileID string) (*LBFlavorProfile, error)
 	CreateLB(opt loadbalancers.CreateOptsBuilder) (*loadbalancers.LoadBalancer, error)
+
+	// UpdateLB will update the loadbalancer
+	UpdateLB(loadbalancerID string, opt loadbalancers.UpdateOpts) (*loadbalancers.LoadBalancer, error)
 	ListLBs(opt loadbalancers.ListOptsBuilder) ([]loadbalancers.LoadBalancer, error)
+
+	// FindLBsByName lists the loadbalancers with the name, bounding the loadbalancers fetched from clouds that ignore
+	// the name filter
+	FindLBsByName(name string) ([]loadbalancers.LoadBalancer, error)
+
+	// CheckLoadBalancerService verifies that the load balancer service is available in the cloud
+	CheckLoadBalancerService() error
+
+	// GetProjectQuotas returns the Nova, Cinder, Neutron and Octavia quotas of the project and their usage
+	GetProjectQuotas() (*ProjectQuotas, error)
 	UpdateMemberInPool(poolID string, memberID string, opts v2pools.UpdateMemberOptsBuilder) (*v2pools.Member, error)
 	ListPoolMembers(poolID string, opts v2pools.ListMembersOpts) ([]v2pools.Member, error)
 
@@ -270,6 +348,9 @@ type OpenstackCloud interface {
 	// Returns the availability zones for the service client passed (compute, volume, network)
 	ListAvailabilityZones(serviceClient *gophercloud.ServiceClient) ([]az.AvailabilityZone, error)
 	AssociateToPool(server *servers.Server, poolID string, opts v2pools.CreateMemberOpts) (*v2pools.Member, error)
+
+	// CreatePoolMember will create a member in the pool
+	CreatePoolMember(poolID string, opts v2pools.CreateMemberOpts) (*v2pools.Member, error)
 	CreatePool(opts v2pools.CreateOpts) (*v2pools.Pool, error)
 	CreatePoolMonitor(opts monitors.CreateOpts) (*monitors.Monitor, error)
 	GetPool(poolID string) (*v2pools.Pool, error)
@@ -299,6 +380,9 @@ type OpenstackCloud interface {
 	DeleteFloatingIP(id string) error
 	DeleteL3FloatingIP(id string) error
 	UseLoadBalancerVIPACL() (bool, error)
+
+	// HasMetadataService returns false if the cloud does not provide a metadata service to the instances
+	HasMetadataService() (bool, error)
 }
 
 type openstackCloud struct {
@@ -317,6 +401,10 @@ type openstackCloud struct {
 	zones           []string
 	floatingEnabled bool
 	useVIPACL       *bool
+	metadataService *bool
+
+	dnsZonesMutex sync.Mutex
+	dnsZones      map[string]*zones.Zone
 }
 
 var _ fi.Cloud = &openstackCloud{}
@@ -358,6 +446,7 @@ func NewOpenstackCloud(cluster *kops.Cluster, uagent string) (OpenstackCloud, er
 			Transport: transport,
 		}
 	}
+	provider.HTTPClient.Transport = instrumentTransport(provider.HTTPClient.Transport)
 
 	klog.V(2).Info("authenticating to keystone")
 
@@ -491,28 +580,54 @@ func buildLoadBalancerClient(c *openstackCloud, spec *kops.OpenstackSpec, provid
 	}
 	c.useOctavia = octavia
 
-	var lbClient *gophercloud.ServiceClient
+	lbClient, err := newLoadBalancerServiceClient(provider, spec.Loadbalancer, region, octavia)
+	if err != nil {
+		// the other resources of the cluster can still be managed, the loadbalancer tasks fail with ErrLBServiceNotAvailable
+		var notFound *gophercloud.ErrEndpointNotFound
+		if errors.As(err, &notFound) {
+			klog.Warningf("Loadbalancer support for OpenStack disabled, the load balancer service is not in the catalog of region %s", region)
+			return nil
+		}
+		return fmt.Errorf("error building lb client: %w", err)
+	}
+	c.lbClient = lbClient
+	return nil
+}
+
+// newLoadBalancerServiceClient returns the client of the Octavia or the deprecated neutron lbaasv2 API. The region of
+// the endpoint in the service catalog can be overridden, or an explicit endpoint can be used for clouds that publish
+// Octavia outside of the catalog of the networking API.
+func newLoadBalancerServiceClient(provider *gophercloud.ProviderClient, lbConfig *kops.OpenstackLoadbalancerConfig, region string, octavia bool) (*gophercloud.ServiceClient, error) {
+	if lbConfig.Region != nil {
+		region = fi.ValueOf(lbConfig.Region)
+	}
+
+	if lbConfig.Endpoint != nil {
+		endpoint := gophercloud.NormalizeURL(fi.ValueOf(lbConfig.Endpoint))
+		klog.V(2).Infof("Openstack using loadbalancer endpoint %s", endpoint)
+		client := &gophercloud.ServiceClient{
+			ProviderClient: provider,
+			Endpoint:       endpoint,
+			Type:           "network",
+		}
+		if octavia {
+			client.Type = "load-balancer"
+			endpoint = strings.Replace(endpoint, "v2.0/", "", -1)
+		}
+		client.ResourceBase = endpoint + "v2.0/"
+		return client, nil
+	}
+
 	if octavia {
 		klog.V(2).Infof("Openstack using Octavia lbaasv2 api")
-		client, err := openstack.NewLoadBalancerV2(provider, gophercloud.EndpointOpts{
+		return openstack.NewLoadBalancerV2(provider, gophercloud.EndpointOpts{
 			Region: region,
 		})
-		if err != nil {
-			return fmt.Errorf("error building lb client: %w", err)
-		}
-		lbClient = client
-	} else {
-		klog.V(2).Infof("Openstack using deprecated lbaasv2 api")
-		client, err := openstack.NewNetworkV2(provider, gophercloud.EndpointOpts{
-			Region: region,
-		})
-		if err != nil {
-			return fmt.Errorf("error building lb client: %w", err)
-		}
-		lbClient = client
 	}
-	c.lbClient = lbClient
-	return nil
+	klog.V(2).Infof("Openstack using deprecated lbaasv2 api")
+	return openstack.NewNetworkV2(provider, gophercloud.EndpointOpts{
+		Region: region,
+	})
 }
 
 // UseZones add unique zone names to openstackcloud
@@ -733,6 +848,38 @@ func useLoadBalancerVIPACL(c OpenstackCloud) (bool, error) {
 	return ver.Compare(semver.MustParse("2.12.0")) > 0, nil
 }
 
+func (c *openstackCloud) HasMetadataService() (bool, error) {
+	if c.metadataService != nil {
+		return *c.metadataService, nil
+	}
+	has, err := hasMetadataService(c)
+	if err != nil {
+		return true, err
+	}
+	c.metadataService = &has
+	return has, nil
+}
+
+// hasMetadataService looks for a running Neutron metadata agent.
+// Listing agents usually requires admin permissions, callers should assume the metadata service is available on errors.
+func hasMetadataService(c OpenstackCloud) (bool, error) {
+	allPages, err := agents.List(c.NetworkingClient(), agents.ListOpts{}).AllPages()
+	if err != nil {
+		return true, fmt.Errorf("error listing network agents: %v", err)
+	}
+	allAgents, err := agents.ExtractAgents(allPages)
+	if err != nil {
+		return true, fmt.Errorf("error extracting network agents: %v", err)
+	}
+	for _, agent := range allAgents {
+		// "Metadata agent" for ML2/OVS and "OVN Metadata agent" for ML2/OVN
+		if strings.HasSuffix(agent.AgentType, "Metadata agent") && agent.Alive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 type Address struct {
 	IPType string `mapstructure:"OS-EXT-IPS:type"`
 	Addr   string
@@ -752,17 +899,9 @@ func getApiIngressStatus(c OpenstackCloud, cluster *kops.Cluster) ([]fi.ApiIngre
 
 func getLoadBalancerIngressStatus(c OpenstackCloud, cluster *kops.Cluster) ([]fi.ApiIngressStatus, error) {
 	var ingresses []fi.ApiIngressStatus
-	lbName := "api." + cluster.Name
-	if cluster.Spec.API.PublicName != "" {
-		lbName = cluster.Spec.API.PublicName
-	}
-	// Note that this must match OpenstackModel lb name
-	klog.V(2).Infof("Querying Openstack to find Loadbalancers for API (%q)", cluster.Name)
-	lbList, err := c.ListLBs(loadbalancers.ListOpts{
-		Name: lbName,
-	})
+	lbList, err := FindAPILoadBalancers(c, cluster)
 	if err != nil {
-		return ingresses, fmt.Errorf("GetApiIngressStatus: Failed to list openstack loadbalancers: %v", err)
+		return ingresses, fmt.Errorf("GetApiIngressStatus: %v", err)
 	}
 	for _, lb := range lbList {
 		// Must Find Floating IP related to this lb
@@ -784,6 +923,32 @@ func getLoadBalancerIngressStatus(c OpenstackCloud, cluster *kops.Cluster) ([]fi
 	return ingresses, nil
 }
 
+// FindAPILoadBalancers returns the loadbalancers of the API of the cluster
+func FindAPILoadBalancers(c OpenstackCloud, cluster *kops.Cluster) ([]loadbalancers.LoadBalancer, error) {
+	if lbID := cluster.Spec.CloudProvider.Openstack.Loadbalancer.ID; lbID != nil {
+		// a shared loadbalancer is referenced by ID
+		lb, err := c.GetLB(fi.ValueOf(lbID))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get openstack loadbalancer %s: %v", fi.ValueOf(lbID), err)
+		}
+		return []loadbalancers.LoadBalancer{*lb}, nil
+	}
+
+	lbName := "api." + cluster.Name
+	if cluster.Spec.API.PublicName != "" {
+		lbName = cluster.Spec.API.PublicName
+	}
+	// Note that this must match OpenstackModel lb name
+	klog.V(2).Infof("Querying Openstack to find Loadbalancers for API (%q)", cluster.Name)
+	lbs, err := c.ListLBs(loadbalancers.ListOpts{
+		Name: lbName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list openstack loadbalancers: %v", err)
+	}
+	return lbs, nil
+}
+
 func getIPIngressStatus(c OpenstackCloud, cluster *kops.Cluster) (ingresses []fi.ApiIngressStatus, err error) {
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		instances, err := c.ListInstances(servers.ListOpts{})
@@ -828,16 +993,37 @@ func getIPIngressStatus(c OpenstackCloud, cluster *kops.Cluster) (ingresses []fi
 	}
 }
 
+// ErrLBServiceNotAvailable is returned if the cloud has no client of the load balancer service, e.g. because Octavia
+// is not published in the service catalog
+var ErrLBServiceNotAvailable = errors.New("OpenStack load balancer service not available in catalog, set spec.cloudProvider.openstack.loadbalancer.endpoint or region if it is published elsewhere")
+
+// CheckLoadBalancerClient returns ErrLBServiceNotAvailable if the cloud has no usable client of the load balancer service
+func CheckLoadBalancerClient(c OpenstackCloud) error {
+	if client := c.LoadBalancerClient(); client == nil || client.Endpoint == "" {
+		return ErrLBServiceNotAvailable
+	}
+	return nil
+}
+
+// IsNotFound returns true if the error, or any error it wraps, reports that the OpenStack resource does not exist.
+// Other errors, e.g. transient API failures, are never reported as not found.
+func IsNotFound(err error) bool {
+	return isNotFound(err)
+}
+
 func isNotFound(err error) bool {
-	if _, ok := err.(gophercloud.ErrDefault404); ok {
+	var err404 gophercloud.ErrDefault404
+	if errors.As(err, &err404) {
 		return true
 	}
 
-	if _, ok := err.(gophercloud.ErrResourceNotFound); ok {
+	var errNotFound gophercloud.ErrResourceNotFound
+	if errors.As(err, &errNotFound) {
 		return true
 	}
 
-	if errCode, ok := err.(gophercloud.ErrUnexpectedResponseCode); ok {
+	var errCode gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &errCode) {
 		if errCode.Actual == http.StatusNotFound {
 			return true
 		}
@@ -846,6 +1032,56 @@ func isNotFound(err error) bool {
 	return false
 }
 
+// IsConflict returns true if the error, or any error it wraps, reports that the request conflicts with the current
+// state of the OpenStack resource, e.g. because the resource already exists.
+func IsConflict(err error) bool {
+	var errCode gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &errCode) {
+		return errCode.Actual == http.StatusConflict
+	}
+	return false
+}
+
+// isRetryable returns true if the error is expected to be transient, e.g. a server side error or throttling.
+// Client errors like 400 Bad Request or 401 Unauthorized are caused by misconfiguration and are not retried.
+func isRetryable(err error) bool {
+	var errCode gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &errCode) {
+		return errCode.Actual >= http.StatusInternalServerError || errCode.Actual == http.StatusTooManyRequests
+	}
+	// requests that were cancelled or ran out of time fail again
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// errors without a response, e.g. connection errors, are retried
+	return true
+}
+
+// RunWithContext returns the result of the request, or the error of the context if it is done first. The request
+// uses the shared provider client, so that it keeps the token refreshed by a reauthentication, the vendored gophercloud
+// cannot cancel it and it runs to completion in the background.
+func RunWithContext[T any](ctx context.Context, request func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := request()
+		done <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
 func MakeCloudConfig(osc *kops.OpenstackSpec) []string {
 	var lines []string
 
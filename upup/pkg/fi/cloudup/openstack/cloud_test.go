@@ -17,18 +17,22 @@ limitations under the License.
 package openstack
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
 	l3floatingips "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/upup/pkg/fi"
@@ -662,3 +666,320 @@ func Test_BuildClients(t *testing.T) {
 		})
 	}
 }
+
+func Test_BuildLoadBalancerClientOverrides(t *testing.T) {
+	provider := &gophercloud.ProviderClient{
+		EndpointLocator: func(eo gophercloud.EndpointOpts) (string, error) {
+			return fmt.Sprintf("https://%s.%s.example.com/", eo.Type, eo.Region), nil
+		},
+	}
+
+	grid := []struct {
+		name                 string
+		lbConfig             *kops.OpenstackLoadbalancerConfig
+		expectedType         string
+		expectedEndpoint     string
+		expectedResourceBase string
+	}{
+		{
+			name: "Octavia endpoint of the cloud region",
+			lbConfig: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+			},
+			expectedType:         "load-balancer",
+			expectedEndpoint:     "https://load-balancer.region.example.com/",
+			expectedResourceBase: "https://load-balancer.region.example.com/v2.0/",
+		},
+		{
+			name: "Octavia endpoint of another region",
+			lbConfig: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+				Region:     fi.PtrTo("lb-region"),
+			},
+			expectedType:         "load-balancer",
+			expectedEndpoint:     "https://load-balancer.lb-region.example.com/",
+			expectedResourceBase: "https://load-balancer.lb-region.example.com/v2.0/",
+		},
+		{
+			name: "Explicit Octavia endpoint",
+			lbConfig: &kops.OpenstackLoadbalancerConfig{
+				UseOctavia: fi.PtrTo(true),
+				Region:     fi.PtrTo("lb-region"),
+				Endpoint:   fi.PtrTo("https://octavia.example.com:9876/v2.0"),
+			},
+			expectedType:         "load-balancer",
+			expectedEndpoint:     "https://octavia.example.com:9876/v2.0/",
+			expectedResourceBase: "https://octavia.example.com:9876/v2.0/",
+		},
+		{
+			name: "Explicit neutron lbaasv2 endpoint",
+			lbConfig: &kops.OpenstackLoadbalancerConfig{
+				Endpoint: fi.PtrTo("https://neutron.example.com:9696"),
+			},
+			expectedType:         "network",
+			expectedEndpoint:     "https://neutron.example.com:9696/",
+			expectedResourceBase: "https://neutron.example.com:9696/v2.0/",
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			client, err := newLoadBalancerServiceClient(provider, g.lbConfig, "region", fi.ValueOf(g.lbConfig.UseOctavia))
+			if err != nil {
+				t.Fatalf("failed to build lb client: %v", err)
+			}
+			if client.Type != g.expectedType {
+				t.Errorf("expected type %q, got %q", g.expectedType, client.Type)
+			}
+			if client.Endpoint != g.expectedEndpoint {
+				t.Errorf("expected endpoint %q, got %q", g.expectedEndpoint, client.Endpoint)
+			}
+			if client.ResourceBase != g.expectedResourceBase {
+				t.Errorf("expected resource base %q, got %q", g.expectedResourceBase, client.ResourceBase)
+			}
+		})
+	}
+}
+
+func Test_BuildLoadBalancerClientMissingFromCatalog(t *testing.T) {
+	provider := &gophercloud.ProviderClient{
+		EndpointLocator: func(eo gophercloud.EndpointOpts) (string, error) {
+			return "", &gophercloud.ErrEndpointNotFound{}
+		},
+	}
+	spec := &kops.OpenstackSpec{
+		Router: &kops.OpenstackRouter{},
+		Loadbalancer: &kops.OpenstackLoadbalancerConfig{
+			UseOctavia: fi.PtrTo(true),
+		},
+	}
+
+	c := &openstackCloud{}
+	if err := buildLoadBalancerClient(c, spec, provider, "region"); err != nil {
+		t.Fatalf("expected the cloud to be built without loadbalancer support, got %v", err)
+	}
+	if err := CheckLoadBalancerClient(c); !errors.Is(err, ErrLBServiceNotAvailable) {
+		t.Errorf("expected %v, got %v", ErrLBServiceNotAvailable, err)
+	}
+}
+
+func Test_IsNotFound(t *testing.T) {
+	tests := []struct {
+		desc     string
+		err      error
+		notFound bool
+	}{
+		{
+			desc:     "nil error",
+			err:      nil,
+			notFound: false,
+		},
+		{
+			desc:     "404 error",
+			err:      gophercloud.ErrDefault404{},
+			notFound: true,
+		},
+		{
+			desc:     "resource not found",
+			err:      gophercloud.ErrResourceNotFound{Name: "lb", ResourceType: "loadbalancer"},
+			notFound: true,
+		},
+		{
+			desc:     "wrapped 404 error",
+			err:      fmt.Errorf("failed to list loadbalancers: %w", gophercloud.ErrDefault404{}),
+			notFound: true,
+		},
+		{
+			desc:     "unexpected 404 response code",
+			err:      gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusNotFound},
+			notFound: true,
+		},
+		{
+			desc:     "transient 503 error",
+			err:      fmt.Errorf("failed to list loadbalancers: %w", gophercloud.ErrDefault503{}),
+			notFound: false,
+		},
+		{
+			desc:     "unwrapped 404 error message",
+			err:      fmt.Errorf("failed to list loadbalancers: %v", gophercloud.ErrDefault404{}),
+			notFound: false,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			if notFound := IsNotFound(testCase.err); notFound != testCase.notFound {
+				t.Errorf("expected IsNotFound to be %v, got %v", testCase.notFound, notFound)
+			}
+		})
+	}
+}
+
+// reauthNetworkCloud answers the Neutron calls with a test server, e.g. one that only accepts the token of the
+// reauthentication
+type reauthNetworkCloud struct {
+	*MockCloud
+	provider *gophercloud.ProviderClient
+	server   *httptest.Server
+}
+
+func (c *reauthNetworkCloud) NetworkingClient() *gophercloud.ServiceClient {
+	return &gophercloud.ServiceClient{
+		ProviderClient: c.provider,
+		Endpoint:       c.server.URL + "/",
+	}
+}
+
+func Test_RunWithContext_Reauthenticate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "refreshed" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/security-groups":
+			fmt.Fprint(w, `{"security_groups": [{"id": "sg-id", "name": "api.cluster"}]}`)
+		case "/qos/policies":
+			fmt.Fprint(w, `{"policies": [{"id": "policy-id", "name": "policy"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		desc   string
+		lookup func(ctx context.Context, cloud OpenstackCloud) (string, error)
+	}{
+		{
+			desc: "security groups",
+			lookup: func(ctx context.Context, cloud OpenstackCloud) (string, error) {
+				groups, err := listSecurityGroups(ctx, cloud, sg.ListOpts{Name: "api.cluster"})
+				if err != nil || len(groups) != 1 {
+					return "", err
+				}
+				return groups[0].ID, nil
+			},
+		},
+		{
+			desc: "QoS policy",
+			lookup: func(ctx context.Context, cloud OpenstackCloud) (string, error) {
+				policy, err := findQosPolicy(ctx, cloud, "policy-id")
+				if err != nil {
+					return "", err
+				}
+				return policy.ID, nil
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			// the token expires while the lookup runs, the provider client of the cloud is reauthenticated
+			provider := &gophercloud.ProviderClient{TokenID: "expired"}
+			reauthentications := 0
+			provider.ReauthFunc = func() error {
+				reauthentications++
+				provider.SetToken("refreshed")
+				return nil
+			}
+			cloud := &reauthNetworkCloud{MockCloud: &MockCloud{}, provider: provider, server: server}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			id, err := testCase.lookup(ctx, cloud)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id == "" {
+				t.Errorf("expected the lookup to find the resource after the reauthentication")
+			}
+			if reauthentications != 1 {
+				t.Errorf("expected 1 reauthentication, got %d", reauthentications)
+			}
+			if token := provider.Token(); token != "refreshed" {
+				t.Errorf("expected the provider to keep the refreshed token, got %q", token)
+			}
+		})
+	}
+}
+
+func Test_RunWithContext_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := RunWithContext(ctx, func() (string, error) {
+		<-release
+		return "done", nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+}
+
+func Test_HasMetadataService(t *testing.T) {
+	tests := []struct {
+		desc          string
+		status        int
+		agents        string
+		expected      bool
+		expectedError bool
+	}{
+		{
+			desc:     "ML2/OVS metadata agent",
+			status:   http.StatusOK,
+			agents:   `[{"id": "dhcp", "agent_type": "DHCP agent", "alive": true}, {"id": "metadata", "agent_type": "Metadata agent", "alive": true}]`,
+			expected: true,
+		},
+		{
+			desc:     "ML2/OVN metadata agent",
+			status:   http.StatusOK,
+			agents:   `[{"id": "controller", "agent_type": "OVN Controller agent", "alive": true}, {"id": "metadata", "agent_type": "OVN Metadata agent", "alive": true}]`,
+			expected: true,
+		},
+		{
+			desc:     "only dead metadata agents",
+			status:   http.StatusOK,
+			agents:   `[{"id": "metadata", "agent_type": "Metadata agent", "alive": false}, {"id": "ovn-metadata", "agent_type": "OVN Metadata agent", "alive": false}]`,
+			expected: false,
+		},
+		{
+			desc:     "no metadata agent",
+			status:   http.StatusOK,
+			agents:   `[{"id": "dhcp", "agent_type": "DHCP agent", "alive": true}]`,
+			expected: false,
+		},
+		{
+			desc:          "listing agents forbidden",
+			status:        http.StatusForbidden,
+			expected:      true,
+			expectedError: true,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/agents" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(testCase.status)
+				if testCase.status == http.StatusOK {
+					fmt.Fprintf(w, `{"agents": %s}`, testCase.agents)
+				}
+			}))
+			defer server.Close()
+			cloud := &reauthNetworkCloud{MockCloud: &MockCloud{}, provider: &gophercloud.ProviderClient{}, server: server}
+
+			has, err := hasMetadataService(cloud)
+			if testCase.expectedError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", testCase.expectedError, err)
+			}
+			if has != testCase.expected {
+				t.Errorf("expected metadata service %v, got %v", testCase.expected, has)
+			}
+		})
+	}
+}
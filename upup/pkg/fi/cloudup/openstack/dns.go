@@ -18,6 +18,7 @@ package openstack
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/dns/v2/recordsets"
 	"github.com/gophercloud/gophercloud/openstack/dns/v2/zones"
@@ -54,6 +55,47 @@ func listDNSZones(c OpenstackCloud, opt zones.ListOptsBuilder) ([]zones.Zone, er
 	}
 }
 
+// FindDNSZone will return the DNS zone with the given name or id, zones are looked up once per cloud instance
+func (c *openstackCloud) FindDNSZone(nameOrID string) (*zones.Zone, error) {
+	c.dnsZonesMutex.Lock()
+	defer c.dnsZonesMutex.Unlock()
+
+	if zone, ok := c.dnsZones[nameOrID]; ok {
+		return zone, nil
+	}
+	zone, err := findDNSZone(c, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	if c.dnsZones == nil {
+		c.dnsZones = make(map[string]*zones.Zone)
+	}
+	c.dnsZones[nameOrID] = zone
+	return zone, nil
+}
+
+func findDNSZone(c OpenstackCloud, nameOrID string) (*zones.Zone, error) {
+	zs, err := c.ListDNSZones(zones.ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	findName := strings.TrimSuffix(nameOrID, ".")
+	var matches []zones.Zone
+	for _, zone := range zs {
+		if zone.ID == nameOrID || strings.TrimSuffix(zone.Name, ".") == findName {
+			matches = append(matches, zone)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("DNS zone %q not found", nameOrID)
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("found multiple DNS zones matching %q", nameOrID)
+	}
+	return &matches[0], nil
+}
+
 func deleteDNSRecordset(c OpenstackCloud, zoneID string, rrsetID string) error {
 	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
 		err := recordsets.Delete(c.DNSClient(), zoneID, rrsetID).ExtractErr()
@@ -104,3 +146,53 @@ func listDNSRecordsets(c OpenstackCloud, zoneID string, opt recordsets.ListOptsB
 		return rrs, wait.ErrWaitTimeout
 	}
 }
+
+// CreateDNSRecordset will create a DNS recordset in zone
+func (c *openstackCloud) CreateDNSRecordset(zoneID string, opt recordsets.CreateOptsBuilder) (*recordsets.RecordSet, error) {
+	return createDNSRecordset(c, zoneID, opt)
+}
+
+func createDNSRecordset(c OpenstackCloud, zoneID string, opt recordsets.CreateOptsBuilder) (*recordsets.RecordSet, error) {
+	var rrs *recordsets.RecordSet
+
+	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
+		r, err := recordsets.Create(c.DNSClient(), zoneID, opt).Extract()
+		if err != nil {
+			return false, fmt.Errorf("failed to create dns recordset: %s", err)
+		}
+		rrs = r
+		return true, nil
+	})
+	if err != nil {
+		return rrs, err
+	} else if done {
+		return rrs, nil
+	} else {
+		return rrs, wait.ErrWaitTimeout
+	}
+}
+
+// UpdateDNSRecordset will update the DNS recordset in zone
+func (c *openstackCloud) UpdateDNSRecordset(zoneID string, rrsetID string, opt recordsets.UpdateOptsBuilder) (*recordsets.RecordSet, error) {
+	return updateDNSRecordset(c, zoneID, rrsetID, opt)
+}
+
+func updateDNSRecordset(c OpenstackCloud, zoneID string, rrsetID string, opt recordsets.UpdateOptsBuilder) (*recordsets.RecordSet, error) {
+	var rrs *recordsets.RecordSet
+
+	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
+		r, err := recordsets.Update(c.DNSClient(), zoneID, rrsetID, opt).Extract()
+		if err != nil {
+			return false, fmt.Errorf("failed to update dns recordset: %s", err)
+		}
+		rrs = r
+		return true, nil
+	})
+	if err != nil {
+		return rrs, err
+	} else if done {
+		return rrs, nil
+	} else {
+		return rrs, wait.ErrWaitTimeout
+	}
+}
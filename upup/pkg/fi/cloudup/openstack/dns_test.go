@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/dns/v2/zones"
+	"k8s.io/kops/cloudmock/openstack/mockdns"
+)
+
+func TestFindDNSZone(t *testing.T) {
+	c := &MockCloud{
+		MockDNSClient: mockdns.CreateClient(),
+	}
+	for _, name := range []string{"example.com.", "sub.example.com."} {
+		if err := zones.Create(c.DNSClient(), zones.CreateOpts{Name: name}).Err; err != nil {
+			t.Fatalf("error creating zone: %v", err)
+		}
+	}
+	zs, err := c.ListDNSZones(zones.ListOpts{})
+	if err != nil {
+		t.Fatalf("error listing zones: %v", err)
+	}
+	var zone zones.Zone
+	for _, z := range zs {
+		if z.Name == "example.com." {
+			zone = z
+		}
+	}
+
+	for _, nameOrID := range []string{"example.com.", "example.com", zone.ID} {
+		found, err := c.FindDNSZone(nameOrID)
+		if err != nil {
+			t.Fatalf("unexpected error finding zone %q: %v", nameOrID, err)
+		}
+		if found.ID != zone.ID {
+			t.Errorf("expected zone %q for %q, got %q", zone.ID, nameOrID, found.ID)
+		}
+	}
+
+	if _, err := c.FindDNSZone("missing.com."); err == nil || err.Error() != `DNS zone "missing.com." not found` {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
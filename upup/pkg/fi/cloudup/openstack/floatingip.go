@@ -53,7 +53,7 @@ func createL3FloatingIP(c OpenstackCloud, opts l3floatingip.CreateOpts) (fip *l3
 	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
 		fip, err = l3floatingip.Create(c.NetworkingClient(), opts).Extract()
 		if err != nil {
-			return false, fmt.Errorf("CreateL3FloatingIP: create L3 floating IP failed: %v", err)
+			return false, fmt.Errorf("CreateL3FloatingIP: create L3 floating IP failed: %w", err)
 		}
 		return true, nil
 	})
@@ -25,6 +25,7 @@ import (
 
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/tags"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
 	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
@@ -43,9 +44,15 @@ const (
 	OS_ANNOTATION             = "openstack.kops.io/"
 	BOOT_FROM_VOLUME          = "osVolumeBoot"
 	BOOT_VOLUME_SIZE          = "osVolumeSize"
+	BOOT_VOLUME_TYPE          = "osVolumeType"
+	BOOT_VOLUME_DELETE        = "osVolumeDeleteOnTermination"
 	SERVER_GROUP_AFFINITY     = "serverGroupAffinity"
 	ALLOWED_ADDRESS_PAIR      = "allowedAddressPair"
 	SERVER_GROUP_NAME         = "serverGroupName"
+	MANAGED_METADATA          = "kops_managed_metadata"
+	SERVER_TAGS               = "serverTags"
+	SCHEDULER_HINT            = "schedulerHint"
+	SCHEDULER_HINTS           = "kops_scheduler_hints"
 
 	defaultActiveTimeout = time.Second * 120
 	activeStatus         = "ACTIVE"
@@ -214,6 +221,90 @@ func deleteInstanceWithID(c OpenstackCloud, instanceID string) error {
 	}
 }
 
+func (c *openstackCloud) UpdateInstanceMetadata(instanceID string, metadata map[string]string) error {
+	return updateInstanceMetadata(c, instanceID, metadata)
+}
+
+func updateInstanceMetadata(c OpenstackCloud, instanceID string, metadata map[string]string) error {
+	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
+		_, err := servers.UpdateMetadata(c.ComputeClient(), instanceID, servers.MetadataOpts(metadata)).Extract()
+		if err != nil {
+			return false, fmt.Errorf("error updating metadata of instance %s: %v", instanceID, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	} else if done {
+		return nil
+	} else {
+		return wait.ErrWaitTimeout
+	}
+}
+
+func (c *openstackCloud) DeleteInstanceMetadata(instanceID string, key string) error {
+	return deleteInstanceMetadata(c, instanceID, key)
+}
+
+func deleteInstanceMetadata(c OpenstackCloud, instanceID string, key string) error {
+	done, err := vfs.RetryWithBackoff(deleteBackoff, func() (bool, error) {
+		err := servers.DeleteMetadatum(c.ComputeClient(), instanceID, key).ExtractErr()
+		if err != nil && !isNotFound(err) {
+			return false, fmt.Errorf("error deleting metadata %s of instance %s: %v", key, instanceID, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	} else if done {
+		return nil
+	} else {
+		return wait.ErrWaitTimeout
+	}
+}
+
+func (c *openstackCloud) AddInstanceTag(instanceID string, tag string) error {
+	return addInstanceTag(c, instanceID, tag)
+}
+
+func addInstanceTag(c OpenstackCloud, instanceID string, tag string) error {
+	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
+		err := tags.Add(c.ComputeClient(), instanceID, tag).ExtractErr()
+		if err != nil {
+			return false, fmt.Errorf("error adding tag %s to instance %s: %v", tag, instanceID, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	} else if done {
+		return nil
+	} else {
+		return wait.ErrWaitTimeout
+	}
+}
+
+func (c *openstackCloud) DeleteInstanceTag(instanceID string, tag string) error {
+	return deleteInstanceTag(c, instanceID, tag)
+}
+
+func deleteInstanceTag(c OpenstackCloud, instanceID string, tag string) error {
+	done, err := vfs.RetryWithBackoff(deleteBackoff, func() (bool, error) {
+		err := tags.Delete(c.ComputeClient(), instanceID, tag).ExtractErr()
+		if err != nil && !isNotFound(err) {
+			return false, fmt.Errorf("error deleting tag %s of instance %s: %v", tag, instanceID, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	} else if done {
+		return nil
+	} else {
+		return wait.ErrWaitTimeout
+	}
+}
+
 // DeregisterInstance drains a cloud instance and loadbalancers.
 func (c *openstackCloud) DeregisterInstance(i *cloudinstances.CloudInstance) error {
 	return deregisterInstance(c, i.ID)
@@ -264,7 +355,7 @@ func drainSingleLB(c OpenstackCloud, lb loadbalancers.LoadBalancer, instanceName
 		return err
 	}
 
-	draining := false
+	var draining []drainingMember
 	pools, err := c.ListPools(v2pools.ListOpts{
 		LoadbalancerID: lb.ID,
 	})
@@ -287,14 +378,16 @@ func drainSingleLB(c OpenstackCloud, lb loadbalancers.LoadBalancer, instanceName
 				if err != nil {
 					return err
 				}
-				draining = true
+				draining = append(draining, drainingMember{PoolID: pool.ID, Member: member})
 				break
 			}
 		}
 	}
 
-	if draining {
-		time.Sleep(20 * time.Second)
+	if len(draining) > 0 {
+		if err := waitForMembersDrained(c, draining); err != nil {
+			return err
+		}
 
 		newStats, err := c.GetLBStats(lb.ID)
 		if err != nil {
@@ -307,6 +400,60 @@ func drainSingleLB(c OpenstackCloud, lb loadbalancers.LoadBalancer, instanceName
 	return nil
 }
 
+// drainingMember is a pool member whose weight was set to 0
+type drainingMember struct {
+	PoolID string
+	Member v2pools.Member
+}
+
+var (
+	// memberDrainTimeout is the maximum time to wait for the health monitor to report that a member is drained
+	memberDrainTimeout = 2 * time.Minute
+	// memberDrainPollInterval is the interval the operating status of a draining member is read
+	memberDrainPollInterval = 5 * time.Second
+	// memberDrainDelay is the time the connections of a member are given to finish when the pool has no health monitor
+	memberDrainDelay = 20 * time.Second
+)
+
+// waitForMembersDrained waits for the health monitor to report the members as DRAINING or OFFLINE, so that the instance
+// is only terminated after the loadbalancer stopped sending new connections to it. Members of pools without a health
+// monitor report NO_MONITOR, the connections of those members are given a fixed time to finish instead. The rolling
+// update proceeds with a warning if a member is not reported as drained in time.
+func waitForMembersDrained(c OpenstackCloud, members []drainingMember) error {
+	unmonitored := false
+	for _, m := range members {
+		err := wait.PollUntilContextTimeout(context.Background(), memberDrainPollInterval, memberDrainTimeout, true, func(ctx context.Context) (bool, error) {
+			member, err := c.GetPoolMember(m.PoolID, m.Member.ID)
+			if err != nil {
+				if isNotFound(err) {
+					// the member was removed from the pool
+					return true, nil
+				}
+				return false, err
+			}
+			switch member.OperatingStatus {
+			case "DRAINING", "OFFLINE":
+				klog.V(2).Infof("Member %s (%s) of pool %s is %s", member.Name, member.Address, m.PoolID, member.OperatingStatus)
+				return true, nil
+			case "NO_MONITOR":
+				unmonitored = true
+				return true, nil
+			}
+			klog.V(4).Infof("Waiting for member %s (%s) of pool %s to drain, it is %s", member.Name, member.Address, m.PoolID, member.OperatingStatus)
+			return false, nil
+		})
+		if wait.Interrupted(err) {
+			klog.Warningf("Member %s (%s) of pool %s was not reported as drained within %v, continuing", m.Member.Name, m.Member.Address, m.PoolID, memberDrainTimeout)
+		} else if err != nil {
+			return fmt.Errorf("error waiting for member %s of pool %s to drain: %w", m.Member.Name, m.PoolID, err)
+		}
+	}
+	if unmonitored {
+		time.Sleep(memberDrainDelay)
+	}
+	return nil
+}
+
 // DetachInstance is not implemented yet. It needs to cause a cloud instance to no longer be counted against the group's size limits.
 func (c *openstackCloud) DetachInstance(i *cloudinstances.CloudInstance) error {
 	return detachInstance(c, i)
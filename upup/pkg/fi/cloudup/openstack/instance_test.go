@@ -18,11 +18,14 @@ package openstack
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"time"
 
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
 	"k8s.io/kops/upup/pkg/fi"
 )
 
@@ -87,3 +90,94 @@ func Test_WaitForStatusActiveResultsInTimeout(t *testing.T) {
 	expectedErr := fmt.Errorf("A timeout occurred")
 	assertTestResults(t, nil, actualErr, expectedErr)
 }
+
+type drainMock struct {
+	OpenstackCloud
+	// statuses are the operating statuses reported for a member, the last one is repeated
+	statuses map[string][]string
+	reads    map[string]int
+}
+
+func (c *drainMock) GetPoolMember(poolID string, memberID string) (*v2pools.Member, error) {
+	statuses, ok := c.statuses[memberID]
+	if !ok {
+		return nil, gophercloud.ErrDefault404{}
+	}
+	i := min(c.reads[memberID], len(statuses)-1)
+	c.reads[memberID]++
+	return &v2pools.Member{ID: memberID, OperatingStatus: statuses[i]}, nil
+}
+
+func Test_WaitForMembersDrained(t *testing.T) {
+	defer func(interval, timeout, delay time.Duration) {
+		memberDrainPollInterval, memberDrainTimeout, memberDrainDelay = interval, timeout, delay
+	}(memberDrainPollInterval, memberDrainTimeout, memberDrainDelay)
+	memberDrainPollInterval = time.Millisecond
+	memberDrainTimeout = 50 * time.Millisecond
+	memberDrainDelay = time.Millisecond
+
+	tests := []struct {
+		desc          string
+		statuses      map[string][]string
+		members       []string
+		expectedReads map[string]int
+	}{
+		{
+			desc:          "drained after the monitor checked the member",
+			statuses:      map[string][]string{"member-1": {"ONLINE", "ONLINE", "DRAINING"}},
+			members:       []string{"member-1"},
+			expectedReads: map[string]int{"member-1": 3},
+		},
+		{
+			desc:          "offline members are drained",
+			statuses:      map[string][]string{"member-1": {"OFFLINE"}, "member-2": {"ONLINE", "OFFLINE"}},
+			members:       []string{"member-1", "member-2"},
+			expectedReads: map[string]int{"member-1": 1, "member-2": 2},
+		},
+		{
+			desc:          "pool without health monitor",
+			statuses:      map[string][]string{"member-1": {"NO_MONITOR"}},
+			members:       []string{"member-1"},
+			expectedReads: map[string]int{"member-1": 1},
+		},
+		{
+			desc:          "removed member",
+			statuses:      map[string][]string{},
+			members:       []string{"member-1"},
+			expectedReads: map[string]int{},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			c := &drainMock{
+				statuses: testCase.statuses,
+				reads:    make(map[string]int),
+			}
+			var members []drainingMember
+			for _, id := range testCase.members {
+				members = append(members, drainingMember{PoolID: "pool", Member: v2pools.Member{ID: id}})
+			}
+			err := waitForMembersDrained(c, members)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(c.reads, testCase.expectedReads) {
+				t.Errorf("Member reads differ:\n%v\n\tinstead of\n%v", c.reads, testCase.expectedReads)
+			}
+		})
+	}
+
+	t.Run("member not drained in time", func(t *testing.T) {
+		c := &drainMock{
+			statuses: map[string][]string{"member-1": {"ONLINE"}},
+			reads:    make(map[string]int),
+		}
+		err := waitForMembersDrained(c, []drainingMember{{PoolID: "pool", Member: v2pools.Member{ID: "member-1"}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.reads["member-1"] < 2 {
+			t.Errorf("expected the member to be read until the timeout, it was read %d times", c.reads["member-1"])
+		}
+	})
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// LBFlavor is an Octavia flavor, its flavor profile selects the provider and the provider options, e.g. the compute
+// flavor and image of the amphorae
+type LBFlavor struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Enabled         bool   `json:"enabled"`
+	FlavorProfileID string `json:"flavor_profile_id"`
+}
+
+// LBFlavorProfile is the flavor profile of an Octavia flavor, by default it can only be read by administrators
+type LBFlavorProfile struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ProviderName string `json:"provider_name"`
+	// FlavorData are the provider options in JSON, e.g. {"compute_flavor": "...", "amp_image_tag": "..."}
+	FlavorData string `json:"flavor_data"`
+}
+
+func (c *openstackCloud) GetLBFlavor(flavorID string) (*LBFlavor, error) {
+	return getLBFlavor(c, flavorID)
+}
+
+// getLBFlavor reads the flavor directly from the API, gophercloud does not implement the Octavia flavors
+func getLBFlavor(c OpenstackCloud, flavorID string) (flavor *LBFlavor, err error) {
+	if c.LoadBalancerClient() == nil {
+		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
+	}
+
+	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
+		var body struct {
+			Flavor LBFlavor `json:"flavor"`
+		}
+		_, err := c.LoadBalancerClient().Get(c.LoadBalancerClient().ServiceURL("lbaas", "flavors", flavorID), &body, nil)
+		if err != nil {
+			return !isRetryable(err), fmt.Errorf("error getting loadbalancer flavor %s: %w", flavorID, err)
+		}
+		flavor = &body.Flavor
+		return true, nil
+	})
+	if !done {
+		if err == nil {
+			err = wait.ErrWaitTimeout
+		}
+		return nil, err
+	}
+	return flavor, err
+}
+
+func (c *openstackCloud) GetLBFlavorProfile(flavorProfileID string) (*LBFlavorProfile, error) {
+	return getLBFlavorProfile(c, flavorProfileID)
+}
+
+func getLBFlavorProfile(c OpenstackCloud, flavorProfileID string) (profile *LBFlavorProfile, err error) {
+	if c.LoadBalancerClient() == nil {
+		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
+	}
+
+	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
+		var body struct {
+			FlavorProfile LBFlavorProfile `json:"flavorprofile"`
+		}
+		_, err := c.LoadBalancerClient().Get(c.LoadBalancerClient().ServiceURL("lbaas", "flavorprofiles", flavorProfileID), &body, nil)
+		if err != nil {
+			return !isRetryable(err), fmt.Errorf("error getting loadbalancer flavor profile %s: %w", flavorProfileID, err)
+		}
+		profile = &body.FlavorProfile
+		return true, nil
+	})
+	if !done {
+		if err == nil {
+			err = wait.ErrWaitTimeout
+		}
+		return nil, err
+	}
+	return profile, err
+}
+
+// lbProviderAliases are the names Octavia accepts for a provider driver
+var lbProviderAliases = map[string]string{
+	"octavia": "amphora",
+}
+
+func canonicalLBProvider(provider string) string {
+	if canonical, ok := lbProviderAliases[provider]; ok {
+		return canonical
+	}
+	return provider
+}
+
+// SameLBProvider returns true if both names refer to the same provider driver
+func SameLBProvider(a, b string) bool {
+	return canonicalLBProvider(a) == canonicalLBProvider(b)
+}
+
+// ValidateLBFlavor returns an error if the flavor does not exist, is disabled, or its flavor profile belongs to another
+// provider than the loadbalancer is created with. The provider is only checked if it is set and the flavor profile
+// can be read, which by default requires the admin role.
+func ValidateLBFlavor(c OpenstackCloud, flavorID string, provider string) error {
+	flavor, err := c.GetLBFlavor(flavorID)
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("loadbalancer flavor %q not found", flavorID)
+		}
+		return err
+	}
+	if !flavor.Enabled {
+		return fmt.Errorf("loadbalancer flavor %s (%s) is disabled", flavor.Name, flavor.ID)
+	}
+	if provider == "" {
+		return nil
+	}
+
+	profile, err := c.GetLBFlavorProfile(flavor.FlavorProfileID)
+	if err != nil {
+		var errCode gophercloud.ErrUnexpectedResponseCode
+		if isNotFound(err) || (errors.As(err, &errCode) && errCode.Actual == http.StatusForbidden) {
+			klog.V(2).Infof("Not checking the provider of loadbalancer flavor %s, its flavor profile %s cannot be read: %v", flavor.Name, flavor.FlavorProfileID, err)
+			return nil
+		}
+		return err
+	}
+	if canonicalLBProvider(profile.ProviderName) != canonicalLBProvider(provider) {
+		return fmt.Errorf("loadbalancer flavor %s (%s) uses flavor profile %s of provider %q, it cannot be used with provider %q",
+			flavor.Name, flavor.ID, profile.Name, profile.ProviderName, provider)
+	}
+	klog.V(2).Infof("Using loadbalancer flavor %s with flavor profile %s: %s", flavor.Name, profile.Name, profile.FlavorData)
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_ValidateLBFlavor(t *testing.T) {
+	tests := []struct {
+		desc          string
+		flavor        string
+		flavorStatus  int
+		profile       string
+		profileStatus int
+		provider      string
+		expectedError error
+	}{
+		{
+			desc:          "flavor of the provider",
+			flavor:        `{"flavor": {"id": "flavor-id", "name": "large", "enabled": true, "flavor_profile_id": "profile-id"}}`,
+			flavorStatus:  http.StatusOK,
+			profile:       `{"flavorprofile": {"id": "profile-id", "name": "amphora-large", "provider_name": "amphora", "flavor_data": "{\"compute_flavor\": \"m1.large\"}"}}`,
+			profileStatus: http.StatusOK,
+			provider:      "amphora",
+		},
+		{
+			desc:          "provider alias",
+			flavor:        `{"flavor": {"id": "flavor-id", "name": "large", "enabled": true, "flavor_profile_id": "profile-id"}}`,
+			flavorStatus:  http.StatusOK,
+			profile:       `{"flavorprofile": {"id": "profile-id", "name": "amphora-large", "provider_name": "amphora"}}`,
+			profileStatus: http.StatusOK,
+			provider:      "octavia",
+		},
+		{
+			desc:          "flavor of another provider",
+			flavor:        `{"flavor": {"id": "flavor-id", "name": "large", "enabled": true, "flavor_profile_id": "profile-id"}}`,
+			flavorStatus:  http.StatusOK,
+			profile:       `{"flavorprofile": {"id": "profile-id", "name": "ovn-default", "provider_name": "ovn"}}`,
+			profileStatus: http.StatusOK,
+			provider:      "amphora",
+			expectedError: fmt.Errorf("loadbalancer flavor large (flavor-id) uses flavor profile ovn-default of provider \"ovn\", it cannot be used with provider \"amphora\""),
+		},
+		{
+			desc:          "flavor profile cannot be read",
+			flavor:        `{"flavor": {"id": "flavor-id", "name": "large", "enabled": true, "flavor_profile_id": "profile-id"}}`,
+			flavorStatus:  http.StatusOK,
+			profile:       `{"faultstring": "Policy does not allow this request to be performed."}`,
+			profileStatus: http.StatusForbidden,
+			provider:      "amphora",
+		},
+		{
+			desc:          "provider is not checked without a provider",
+			flavor:        `{"flavor": {"id": "flavor-id", "name": "large", "enabled": true, "flavor_profile_id": "profile-id"}}`,
+			flavorStatus:  http.StatusOK,
+			profile:       `{"flavorprofile": {"id": "profile-id", "name": "ovn-default", "provider_name": "ovn"}}`,
+			profileStatus: http.StatusOK,
+		},
+		{
+			desc:          "disabled flavor",
+			flavor:        `{"flavor": {"id": "flavor-id", "name": "large", "enabled": false, "flavor_profile_id": "profile-id"}}`,
+			flavorStatus:  http.StatusOK,
+			provider:      "amphora",
+			expectedError: fmt.Errorf("loadbalancer flavor large (flavor-id) is disabled"),
+		},
+		{
+			desc:          "missing flavor",
+			flavor:        `{"faultstring": "Flavor flavor-id could not be found."}`,
+			flavorStatus:  http.StatusNotFound,
+			provider:      "amphora",
+			expectedError: fmt.Errorf("loadbalancer flavor \"flavor-id\" not found"),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			mux := http.NewServeMux()
+			testServer := httptest.NewServer(mux)
+			defer testServer.Close()
+			fixture(mux, "/lbaas/flavors/flavor-id", http.MethodGet, testCase.flavor, testCase.flavorStatus)
+			if testCase.profile != "" {
+				fixture(mux, "/lbaas/flavorprofiles/profile-id", http.MethodGet, testCase.profile, testCase.profileStatus)
+			}
+
+			cloud := &openstackCloud{
+				lbClient: serviceClient(testServer.URL),
+			}
+			err := ValidateLBFlavor(cloud, "flavor-id", testCase.provider)
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"slices"
+)
+
+// LBProviderCapabilities describes the loadbalancer features supported by an Octavia provider driver
+type LBProviderCapabilities struct {
+	// LBMethods are the supported pool algorithms, all algorithms are supported if empty
+	LBMethods []string
+	// Flavors is true if loadbalancers can be created with a flavor
+	Flavors bool
+	// AllowedCIDRs is true if listeners can restrict the allowed source addresses
+	AllowedCIDRs bool
+}
+
+// allLBProviderCapabilities are the capabilities of the amphora provider, they are assumed for unknown providers
+var allLBProviderCapabilities = LBProviderCapabilities{
+	Flavors:      true,
+	AllowedCIDRs: true,
+}
+
+// lbProviderCapabilities are the capabilities of the providers that only support a subset of the Octavia features
+var lbProviderCapabilities = map[string]LBProviderCapabilities{
+	"ovn": {
+		LBMethods: []string{"SOURCE_IP_PORT"},
+	},
+}
+
+// GetLBProviderCapabilities returns the capabilities of the Octavia provider
+func GetLBProviderCapabilities(provider string) LBProviderCapabilities {
+	if capabilities, ok := lbProviderCapabilities[provider]; ok {
+		return capabilities
+	}
+	return allLBProviderCapabilities
+}
+
+// ValidateLBProviderMethod returns an error if the Octavia provider does not support the pool algorithm
+func ValidateLBProviderMethod(provider string, method string) error {
+	capabilities := GetLBProviderCapabilities(provider)
+	if len(capabilities.LBMethods) > 0 && !slices.Contains(capabilities.LBMethods, method) {
+		return fmt.Errorf("loadbalancer provider %q does not support the %s algorithm, supported algorithms are %v", provider, method, capabilities.LBMethods)
+	}
+	return nil
+}
+
+// ValidateLBProviderFlavor returns an error if the Octavia provider does not support loadbalancer flavors
+func ValidateLBProviderFlavor(provider string, flavorID string) error {
+	if flavorID != "" && !GetLBProviderCapabilities(provider).Flavors {
+		return fmt.Errorf("loadbalancer provider %q does not support flavors, flavor %q cannot be used", provider, flavorID)
+	}
+	return nil
+}
+
+// LBSessionPersistenceTypes are the session persistence types of Octavia pools
+var LBSessionPersistenceTypes = []string{"SOURCE_IP", "HTTP_COOKIE", "APP_COOKIE"}
+
+// ValidateLBSessionPersistence returns an error if the session persistence type is unknown, or the cookie name does not
+// match the type. The cookie name is required for and only used by APP_COOKIE persistence.
+func ValidateLBSessionPersistence(persistenceType string, cookieName string) error {
+	if !slices.Contains(LBSessionPersistenceTypes, persistenceType) {
+		return fmt.Errorf("unsupported session persistence type %q, supported types are %v", persistenceType, LBSessionPersistenceTypes)
+	}
+	if persistenceType == "APP_COOKIE" && cookieName == "" {
+		return fmt.Errorf("session persistence type APP_COOKIE requires a cookie name")
+	}
+	if persistenceType != "APP_COOKIE" && cookieName != "" {
+		return fmt.Errorf("cookie name %q is only supported by session persistence type APP_COOKIE, not %s", cookieName, persistenceType)
+	}
+	return nil
+}
+
+// ValidateLBMonitorType returns an error if the health monitor type cannot be used with the pool protocol, UDP pools
+// can only be monitored with UDP-CONNECT.
+func ValidateLBMonitorType(poolProtocol string, monitorType string) error {
+	if poolProtocol == "UDP" && monitorType != "UDP-CONNECT" {
+		return fmt.Errorf("health monitor type %s is not supported by UDP pools, UDP-CONNECT is required", monitorType)
+	}
+	if poolProtocol != "UDP" && monitorType == "UDP-CONNECT" {
+		return fmt.Errorf("health monitor type UDP-CONNECT is only supported by UDP pools, not %s", poolProtocol)
+	}
+	return nil
+}
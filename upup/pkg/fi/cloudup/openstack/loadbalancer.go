@@ -26,6 +26,7 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/monitors"
 	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/gophercloud/gophercloud/pagination"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/util/pkg/vfs"
@@ -75,11 +76,11 @@ func listMonitors(c OpenstackCloud, opts monitors.ListOpts) (monitorList []monit
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		allPages, err := monitors.List(c.LoadBalancerClient(), opts).AllPages()
 		if err != nil {
-			return false, fmt.Errorf("failed to list monitors: %s", err)
+			return false, fmt.Errorf("failed to list monitors: %w", err)
 		}
 		monitorList, err = monitors.ExtractMonitors(allPages)
 		if err != nil {
-			return false, fmt.Errorf("failed to extract monitor pages: %s", err)
+			return false, fmt.Errorf("failed to extract monitor pages: %w", err)
 		}
 		return true, nil
 	})
@@ -189,10 +190,8 @@ func deleteLB(c OpenstackCloud, lbID string, opts loadbalancers.DeleteOpts) erro
 		if err != nil && !isNotFound(err) {
 			return false, fmt.Errorf("error deleting loadbalancer: %v", err)
 		}
-		if isNotFound(err) {
-			return true, nil
-		}
-		return false, nil
+		// the deletion is asynchronous, WaitLBDeleted waits for the loadbalancer to be gone
+		return true, nil
 	})
 	if err != nil {
 		return err
@@ -203,6 +202,51 @@ func deleteLB(c OpenstackCloud, lbID string, opts loadbalancers.DeleteOpts) erro
 	}
 }
 
+// DefaultLBDeleteTimeout is the default time to wait for a loadbalancer to be deleted
+const DefaultLBDeleteTimeout = 5 * time.Minute
+
+// lbDeletePollInterval is the interval for polling a loadbalancer until it is deleted
+var lbDeletePollInterval = 5 * time.Second
+
+// WaitLBDeleted polls the loadbalancer until it no longer exists, it returns an error if the loadbalancer still
+// exists after the timeout or has gone into ERROR state.
+func WaitLBDeleted(c OpenstackCloud, lbID string, timeout time.Duration) error {
+	if c.LoadBalancerClient() == nil {
+		return fmt.Errorf("loadbalancer support not available in this deployment")
+	}
+
+	backoff := wait.Backoff{
+		Duration: lbDeletePollInterval,
+		Factor:   1,
+		Jitter:   0.1,
+		Steps:    int(timeout/lbDeletePollInterval) + 1,
+	}
+	provisioningStatus := ""
+	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		lb, err := loadbalancers.Get(c.LoadBalancerClient(), lbID).Extract()
+		if isNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			if !isRetryable(err) {
+				return false, fmt.Errorf("error getting loadbalancer %s: %w", lbID, err)
+			}
+			klog.V(2).Infof("error getting loadbalancer %s, will retry: %v", lbID, err)
+			return false, nil
+		}
+		provisioningStatus = lb.ProvisioningStatus
+		if provisioningStatus == "ERROR" {
+			return false, fmt.Errorf("loadbalancer %s has gone into ERROR state while deleting", lbID)
+		}
+		klog.V(2).Infof("Waiting for loadbalancer %s to be deleted (provisioning status %q)...", lbID, provisioningStatus)
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("loadbalancer %s was not deleted within %v (provisioning status %q)", lbID, timeout, provisioningStatus)
+	}
+	return err
+}
+
 func (c *openstackCloud) CreateLB(opt loadbalancers.CreateOptsBuilder) (*loadbalancers.LoadBalancer, error) {
 	return createLB(c, opt)
 }
@@ -212,11 +256,32 @@ func createLB(c OpenstackCloud, opt loadbalancers.CreateOptsBuilder) (*loadbalan
 		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
 	}
 
+	// conflicts and other client errors are returned without retrying
+	var i *loadbalancers.LoadBalancer
+	err := WriteRetryPolicy.Do(func() (err error) {
+		i, err = loadbalancers.Create(c.LoadBalancerClient(), opt).Extract()
+		return err
+	})
+	if err != nil {
+		return i, fmt.Errorf("error creating loadbalancer: %w", err)
+	}
+	return i, nil
+}
+
+func (c *openstackCloud) UpdateLB(loadbalancerID string, opt loadbalancers.UpdateOpts) (*loadbalancers.LoadBalancer, error) {
+	return updateLB(c, loadbalancerID, opt)
+}
+
+func updateLB(c OpenstackCloud, loadbalancerID string, opt loadbalancers.UpdateOpts) (*loadbalancers.LoadBalancer, error) {
+	if c.LoadBalancerClient() == nil {
+		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
+	}
+
 	var i *loadbalancers.LoadBalancer
 	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
-		v, err := loadbalancers.Create(c.LoadBalancerClient(), opt).Extract()
+		v, err := loadbalancers.Update(c.LoadBalancerClient(), loadbalancerID, opt).Extract()
 		if err != nil {
-			return false, fmt.Errorf("error creating loadbalancer: %v", err)
+			return false, fmt.Errorf("error updating loadbalancer: %v", err)
 		}
 		i = v
 		return true, nil
@@ -239,10 +304,37 @@ func getLB(c OpenstackCloud, loadbalancerID string) (lb *loadbalancers.LoadBalan
 		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
 	}
 
-	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
+	err = ReadRetryPolicy.Do(func() (err error) {
 		lb, err = loadbalancers.Get(c.LoadBalancerClient(), loadbalancerID).Extract()
-		if err != nil {
+		return err
+	})
+	return lb, err
+}
+
+// ListLBs will list load balancers
+func (c *openstackCloud) ListLBs(opt loadbalancers.ListOptsBuilder) (lbs []loadbalancers.LoadBalancer, err error) {
+	return listLBs(c, opt)
+}
+
+func (c *openstackCloud) CheckLoadBalancerService() error {
+	return checkLoadBalancerService(c)
+}
+
+// checkLoadBalancerService lists a single loadbalancer to verify that the load balancer endpoint is in the catalog
+// and that the service answers requests.
+func checkLoadBalancerService(c OpenstackCloud) error {
+	if c.LoadBalancerClient() == nil {
+		return fmt.Errorf("no load balancer endpoint is configured")
+	}
+
+	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
+		err := loadbalancers.List(c.LoadBalancerClient(), loadbalancers.ListOpts{Limit: 1}).EachPage(func(page pagination.Page) (bool, error) {
+			_, err := loadbalancers.ExtractLoadBalancers(page)
+			// the first page is enough to know that the service works
 			return false, err
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to list loadbalancers: %w", err)
 		}
 		return true, nil
 	})
@@ -250,14 +342,9 @@ func getLB(c OpenstackCloud, loadbalancerID string) (lb *loadbalancers.LoadBalan
 		if err == nil {
 			err = wait.ErrWaitTimeout
 		}
-		return lb, err
+		return err
 	}
-	return lb, nil
-}
-
-// ListLBs will list load balancers
-func (c *openstackCloud) ListLBs(opt loadbalancers.ListOptsBuilder) (lbs []loadbalancers.LoadBalancer, err error) {
-	return listLBs(c, opt)
+	return nil
 }
 
 func listLBs(c OpenstackCloud, opt loadbalancers.ListOptsBuilder) (lbs []loadbalancers.LoadBalancer, err error) {
@@ -269,11 +356,12 @@ func listLBs(c OpenstackCloud, opt loadbalancers.ListOptsBuilder) (lbs []loadbal
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		allPages, err := loadbalancers.List(c.LoadBalancerClient(), opt).AllPages()
 		if err != nil {
-			return false, fmt.Errorf("failed to list loadbalancers: %s", err)
+			// errors that are not transient are returned without retrying
+			return !isRetryable(err), fmt.Errorf("failed to list loadbalancers: %w", err)
 		}
 		lbs, err = loadbalancers.ExtractLoadBalancers(allPages)
 		if err != nil {
-			return false, fmt.Errorf("failed to extract loadbalancer pages: %s", err)
+			return false, fmt.Errorf("failed to extract loadbalancer pages: %w", err)
 		}
 		return true, nil
 	})
@@ -283,6 +371,60 @@ func listLBs(c OpenstackCloud, opt loadbalancers.ListOptsBuilder) (lbs []loadbal
 		}
 		return lbs, err
 	}
+	return lbs, err
+}
+
+const (
+	// lbListPageSize is the number of loadbalancers requested per page when looking up loadbalancers by name
+	lbListPageSize = 100
+	// lbListMaxPages bounds the pages fetched when looking up loadbalancers by name. Clouds that ignore the name
+	// filter return every loadbalancer of the project, which can take very long in crowded projects.
+	lbListMaxPages = 20
+)
+
+// FindLBsByName lists the loadbalancers with the name
+func (c *openstackCloud) FindLBsByName(name string) ([]loadbalancers.LoadBalancer, error) {
+	return findLBsByName(c, name)
+}
+
+// findLBsByName lists the loadbalancers with the name. The name is filtered by the server and matched again by the
+// client for clouds that ignore the filter, the pages fetched are bounded.
+func findLBsByName(c OpenstackCloud, name string) (lbs []loadbalancers.LoadBalancer, err error) {
+	if c.LoadBalancerClient() == nil {
+		// skip error because cluster delete will otherwise fail
+		return lbs, nil
+	}
+
+	pages := 0
+	err = ReadRetryPolicy.Do(func() error {
+		lbs = nil
+		pages = 0
+		return loadbalancers.List(c.LoadBalancerClient(), loadbalancers.ListOpts{
+			Name:  name,
+			Limit: lbListPageSize,
+		}).EachPage(func(page pagination.Page) (bool, error) {
+			pages++
+			if pages > lbListMaxPages {
+				return false, nil
+			}
+			pageLBs, err := loadbalancers.ExtractLoadBalancers(page)
+			if err != nil {
+				return false, fmt.Errorf("failed to extract loadbalancer pages: %w", err)
+			}
+			for _, lb := range pageLBs {
+				if lb.Name == name {
+					lbs = append(lbs, lb)
+				}
+			}
+			return true, nil
+		})
+	})
+	if err != nil {
+		return lbs, fmt.Errorf("failed to list loadbalancers: %w", err)
+	}
+	if pages > lbListMaxPages {
+		return lbs, fmt.Errorf("more than %d loadbalancers returned for name %s, the cloud may not support filtering loadbalancers by name", lbListMaxPages*lbListPageSize, name)
+	}
 	return lbs, nil
 }
 
@@ -298,7 +440,8 @@ func getLBStats(c OpenstackCloud, loadbalancerID string) (stats *loadbalancers.S
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		stats, err = loadbalancers.GetStats(c.LoadBalancerClient(), loadbalancerID).Extract()
 		if err != nil {
-			return false, fmt.Errorf("Error getting load balancer stats %v", err)
+			// stats that are not implemented by the provider are not retried
+			return !isRetryable(err), fmt.Errorf("Error getting load balancer stats %w", err)
 		}
 		return true, nil
 	})
@@ -311,6 +454,31 @@ func getLBStats(c OpenstackCloud, loadbalancerID string) (stats *loadbalancers.S
 	return stats, nil
 }
 
+func (c *openstackCloud) GetLBStatuses(loadbalancerID string) (*loadbalancers.StatusTree, error) {
+	return getLBStatuses(c, loadbalancerID)
+}
+
+func getLBStatuses(c OpenstackCloud, loadbalancerID string) (statuses *loadbalancers.StatusTree, err error) {
+	if c.LoadBalancerClient() == nil {
+		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
+	}
+
+	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
+		statuses, err = loadbalancers.GetStatuses(c.LoadBalancerClient(), loadbalancerID).Extract()
+		if err != nil {
+			return false, fmt.Errorf("error getting load balancer statuses: %v", err)
+		}
+		return true, nil
+	})
+	if !done {
+		if err == nil {
+			err = wait.ErrWaitTimeout
+		}
+		return statuses, err
+	}
+	return statuses, nil
+}
+
 func (c *openstackCloud) GetPool(poolID string) (pool *v2pools.Pool, err error) {
 	return getPool(c, poolID)
 }
@@ -347,6 +515,10 @@ func getPoolMember(c OpenstackCloud, poolID string, memberID string) (member *v2
 
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		member, err = v2pools.GetMember(c.LoadBalancerClient(), poolID, memberID).Extract()
+		if isNotFound(err) {
+			// not found is not retried, it is returned to the caller
+			return true, err
+		}
 		if err != nil {
 			return false, err
 		}
@@ -358,7 +530,7 @@ func getPoolMember(c OpenstackCloud, poolID string, memberID string) (member *v2
 		}
 		return member, err
 	}
-	return member, nil
+	return member, err
 }
 
 func (c *openstackCloud) UpdateMemberInPool(poolID string, memberID string, opts v2pools.UpdateMemberOptsBuilder) (association *v2pools.Member, err error) {
@@ -427,6 +599,37 @@ func associateToPool(c OpenstackCloud, server *servers.Server, poolID string, op
 	return association, nil
 }
 
+func (c *openstackCloud) CreatePoolMember(poolID string, opts v2pools.CreateMemberOpts) (member *v2pools.Member, err error) {
+	return createPoolMember(c, poolID, opts)
+}
+
+func createPoolMember(c OpenstackCloud, poolID string, opts v2pools.CreateMemberOpts) (member *v2pools.Member, err error) {
+	if c.LoadBalancerClient() == nil {
+		return nil, fmt.Errorf("loadbalancer support not available in this deployment")
+	}
+
+	done, err := vfs.RetryWithBackoff(memberBackoff, func() (bool, error) {
+		member, err = v2pools.CreateMember(c.LoadBalancerClient(), poolID, opts).Extract()
+		if err != nil {
+			// pool is currently in immutable state, try to retry
+			errCode, ok := err.(gophercloud.ErrDefault409)
+			if ok {
+				klog.Infof("got error %v retrying...", errCode)
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to create pool member: %v", err)
+		}
+		return true, nil
+	})
+	if !done {
+		if err == nil {
+			err = wait.ErrWaitTimeout
+		}
+		return member, err
+	}
+	return member, nil
+}
+
 func (c *openstackCloud) CreatePool(opts v2pools.CreateOpts) (pool *v2pools.Pool, err error) {
 	return createPool(c, opts)
 }
@@ -464,11 +667,11 @@ func listPoolMembers(c OpenstackCloud, poolID string, opts v2pools.ListMembersOp
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		memberPage, err := v2pools.ListMembers(c.LoadBalancerClient(), poolID, opts).AllPages()
 		if err != nil {
-			return false, fmt.Errorf("failed to list members: %v", err)
+			return false, fmt.Errorf("failed to list members: %w", err)
 		}
 		memberList, err = v2pools.ExtractMembers(memberPage)
 		if err != nil {
-			return false, fmt.Errorf("failed to extract members: %v", err)
+			return false, fmt.Errorf("failed to extract members: %w", err)
 		}
 		return true, nil
 	})
@@ -493,11 +696,11 @@ func listPools(c OpenstackCloud, opts v2pools.ListOpts) (poolList []v2pools.Pool
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		poolPage, err := v2pools.List(c.LoadBalancerClient(), opts).AllPages()
 		if err != nil {
-			return false, fmt.Errorf("failed to list pools: %v", err)
+			return false, fmt.Errorf("failed to list pools: %w", err)
 		}
 		poolList, err = v2pools.ExtractPools(poolPage)
 		if err != nil {
-			return false, fmt.Errorf("failed to extract pools: %v", err)
+			return false, fmt.Errorf("failed to extract pools: %w", err)
 		}
 		return true, nil
 	})
@@ -522,11 +725,11 @@ func listListeners(c OpenstackCloud, opts listeners.ListOpts) (listenerList []li
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		listenerPage, err := listeners.List(c.LoadBalancerClient(), opts).AllPages()
 		if err != nil {
-			return false, fmt.Errorf("failed to list listeners: %v", err)
+			return false, fmt.Errorf("failed to list listeners: %w", err)
 		}
 		listenerList, err = listeners.ExtractListeners(listenerPage)
 		if err != nil {
-			return false, fmt.Errorf("failed to extract listeners: %v", err)
+			return false, fmt.Errorf("failed to extract listeners: %w", err)
 		}
 		return true, nil
 	})
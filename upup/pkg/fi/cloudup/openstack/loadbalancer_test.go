@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+)
+
+func TestCheckLoadBalancerService(t *testing.T) {
+	c := &MockCloud{
+		MockLBClient: mockloadbalancer.CreateClient(),
+	}
+	if err := c.CheckLoadBalancerService(); err != nil {
+		t.Errorf("unexpected error checking load balancer service: %v", err)
+	}
+}
+
+func Test_ListLBs_Retries(t *testing.T) {
+	tests := []struct {
+		desc          string
+		statusCodes   []int
+		expectedCalls int
+		expectedLBs   int
+		expectError   bool
+	}{
+		{
+			desc:          "transient errors are retried",
+			statusCodes:   []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expectedCalls: 3,
+			expectedLBs:   1,
+		},
+		{
+			desc:          "client errors are not retried",
+			statusCodes:   []int{http.StatusBadRequest},
+			expectedCalls: 1,
+			expectError:   true,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			calls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/lbaas/loadbalancers", func(w http.ResponseWriter, r *http.Request) {
+				statusCode := testCase.statusCodes[len(testCase.statusCodes)-1]
+				if calls < len(testCase.statusCodes) {
+					statusCode = testCase.statusCodes[calls]
+				}
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(statusCode)
+				if statusCode == http.StatusOK {
+					w.Write([]byte(`{"loadbalancers": [{"id": "lb-id", "name": "lb"}]}`))
+				}
+			})
+			testServer := httptest.NewServer(mux)
+			defer testServer.Close()
+
+			cloud := &openstackCloud{
+				lbClient: serviceClient(testServer.URL),
+			}
+			lbs, err := cloud.ListLBs(loadbalancers.ListOpts{Name: "lb"})
+			if testCase.expectError && err == nil {
+				t.Errorf("expected error listing loadbalancers")
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("unexpected error listing loadbalancers: %v", err)
+			}
+			if calls != testCase.expectedCalls {
+				t.Errorf("expected %d calls, got %d", testCase.expectedCalls, calls)
+			}
+			if len(lbs) != testCase.expectedLBs {
+				t.Errorf("expected %d loadbalancers, got %d", testCase.expectedLBs, len(lbs))
+			}
+		})
+	}
+}
+
+func Test_FindLBsByName(t *testing.T) {
+	tests := []struct {
+		desc          string
+		pages         int
+		expectedCalls int
+		expectedLBs   int
+		expectError   bool
+	}{
+		{
+			desc:          "loadbalancers filtered by the server",
+			pages:         1,
+			expectedCalls: 1,
+			expectedLBs:   1,
+		},
+		{
+			desc:          "loadbalancers matched by the client",
+			pages:         3,
+			expectedCalls: 3,
+			expectedLBs:   3,
+		},
+		{
+			desc:          "pages are bounded",
+			pages:         lbListMaxPages + 1,
+			expectedCalls: lbListMaxPages + 1,
+			expectError:   true,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			calls := 0
+			mux := http.NewServeMux()
+			testServer := httptest.NewServer(mux)
+			defer testServer.Close()
+			// every page has a loadbalancer with the name and one with another name, as if the filter was ignored
+			mux.HandleFunc("/lbaas/loadbalancers", func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if r.URL.Query().Get("name") != "lb" {
+					t.Errorf("expected the name to be filtered, got query %q", r.URL.RawQuery)
+				}
+				next := ""
+				if calls < testCase.pages {
+					next = fmt.Sprintf(`{"rel": "next", "href": "%s/lbaas/loadbalancers?name=lb&marker=%d"}`, testServer.URL, calls)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintf(w, `{"loadbalancers": [{"id": "lb-%d", "name": "lb"}, {"id": "other-%d", "name": "other"}], "loadbalancers_links": [%s]}`, calls, calls, next)
+			})
+
+			cloud := &openstackCloud{
+				lbClient: serviceClient(testServer.URL),
+			}
+			lbs, err := cloud.FindLBsByName("lb")
+			if testCase.expectError && err == nil {
+				t.Errorf("expected error listing loadbalancers")
+			}
+			if !testCase.expectError && err != nil {
+				t.Errorf("unexpected error listing loadbalancers: %v", err)
+			}
+			if calls != testCase.expectedCalls {
+				t.Errorf("expected %d calls, got %d", testCase.expectedCalls, calls)
+			}
+			if !testCase.expectError && len(lbs) != testCase.expectedLBs {
+				t.Errorf("expected %d loadbalancers, got %d", testCase.expectedLBs, len(lbs))
+			}
+			for _, lb := range lbs {
+				if lb.Name != "lb" {
+					t.Errorf("unexpected loadbalancer %s", lb.Name)
+				}
+			}
+		})
+	}
+}
+
+func Test_CreateLB_Conflict(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lbaas/loadbalancers", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+	})
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	cloud := &openstackCloud{
+		lbClient: serviceClient(testServer.URL),
+	}
+	_, err := cloud.CreateLB(loadbalancers.CreateOpts{Name: "lb", VipSubnetID: "subnet"})
+	if !IsConflict(err) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	if IsNotFound(err) {
+		t.Errorf("conflict error reported as not found: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected conflicts not to be retried, got %d calls", calls)
+	}
+}
+
+func Test_WaitLBDeleted(t *testing.T) {
+	defer func(interval time.Duration) { lbDeletePollInterval = interval }(lbDeletePollInterval)
+	lbDeletePollInterval = time.Millisecond
+
+	tests := []struct {
+		desc          string
+		statuses      []string
+		timeout       time.Duration
+		expectedError string
+	}{
+		{
+			desc:     "loadbalancer deleted",
+			statuses: []string{"PENDING_DELETE", "PENDING_DELETE", ""},
+			timeout:  time.Second,
+		},
+		{
+			desc:          "loadbalancer in error state",
+			statuses:      []string{"PENDING_DELETE", "ERROR"},
+			timeout:       time.Second,
+			expectedError: `loadbalancer lb-id has gone into ERROR state while deleting`,
+		},
+		{
+			desc:          "loadbalancer not deleted within timeout",
+			statuses:      []string{"PENDING_DELETE"},
+			timeout:       5 * time.Millisecond,
+			expectedError: `loadbalancer lb-id was not deleted within 5ms (provisioning status "PENDING_DELETE")`,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			calls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/lbaas/loadbalancers/lb-id", func(w http.ResponseWriter, r *http.Request) {
+				status := testCase.statuses[len(testCase.statuses)-1]
+				if calls < len(testCase.statuses) {
+					status = testCase.statuses[calls]
+				}
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				if status == "" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintf(w, `{"loadbalancer": {"id": "lb-id", "provisioning_status": %q}}`, status)
+			})
+			testServer := httptest.NewServer(mux)
+			defer testServer.Close()
+
+			cloud := &openstackCloud{
+				lbClient: serviceClient(testServer.URL),
+			}
+			err := WaitLBDeleted(cloud, "lb-id", testCase.timeout)
+			if testCase.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error waiting for loadbalancer deletion: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Errorf("expected error %q, got %v", testCase.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var (
+	renderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kops",
+		Subsystem: "openstack",
+		Name:      "task_render_duration_seconds",
+		Help:      "Duration of rendering OpenStack tasks, by task type.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"task"})
+
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kops",
+		Subsystem: "openstack",
+		Name:      "api_requests_total",
+		Help:      "Number of OpenStack API requests, by endpoint and HTTP method.",
+	}, []string{"endpoint", "method"})
+
+	// apiRequestCount is the number of OpenStack API requests made by the process
+	apiRequestCount atomic.Int64
+)
+
+// RegisterMetrics registers the OpenStack task and API metrics, they are only exported if registered
+func RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{renderDuration, apiRequests} {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// APIRequestCount returns the number of OpenStack API requests made by the process
+func APIRequestCount() int64 {
+	return apiRequestCount.Load()
+}
+
+// RecordRender starts timing the rendering of a task, the returned function records the duration and the number of
+// API requests made in the meantime. Tasks are rendered concurrently, so the request count includes the requests of
+// other tasks rendered at the same time.
+func RecordRender(task string, name string) func() {
+	start := time.Now()
+	requests := APIRequestCount()
+	return func() {
+		duration := time.Since(start)
+		renderDuration.WithLabelValues(task).Observe(duration.Seconds())
+		klog.V(2).Infof("Rendered %s %q in %v with %d API requests", task, name, duration.Round(time.Millisecond), APIRequestCount()-requests)
+	}
+}
+
+// instrumentedTransport counts the requests sent to the OpenStack APIs
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiRequestCount.Add(1)
+	apiRequests.WithLabelValues(req.URL.Host, req.Method).Inc()
+	return t.next.RoundTrip(req)
+}
+
+// instrumentTransport wraps the transport of the OpenStack clients to count the API requests
+func instrumentTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{next: next}
+}
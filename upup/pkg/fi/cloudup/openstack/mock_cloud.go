@@ -17,6 +17,7 @@ limitations under the License.
 package openstack
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
@@ -37,6 +38,7 @@ import (
 	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
 	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/routers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/qos/policies"
 	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	sgr "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
@@ -105,6 +107,10 @@ func (c *MockCloud) NetworkingClient() *gophercloud.ServiceClient {
 }
 
 func (c *MockCloud) LoadBalancerClient() *gophercloud.ServiceClient {
+	// like a cloud without load balancer service in the catalog
+	if c.MockLBClient == nil {
+		return nil
+	}
 	client := c.MockLBClient.ServiceClient()
 	client.UserAgent.Prepend("loadbalancer")
 	return client
@@ -189,6 +195,10 @@ func (c *MockCloud) CreateLB(opt loadbalancers.CreateOptsBuilder) (*loadbalancer
 	return createLB(c, opt)
 }
 
+func (c *MockCloud) UpdateLB(loadbalancerID string, opt loadbalancers.UpdateOpts) (*loadbalancers.LoadBalancer, error) {
+	return updateLB(c, loadbalancerID, opt)
+}
+
 func (c *MockCloud) CreateListener(opts listeners.CreateOpts) (listener *listeners.Listener, err error) {
 	return createListener(c, opts)
 }
@@ -201,6 +211,10 @@ func (c *MockCloud) CreatePool(opts v2pools.CreateOpts) (pool *v2pools.Pool, err
 	return createPool(c, opts)
 }
 
+func (c *MockCloud) CreatePoolMember(poolID string, opts v2pools.CreateMemberOpts) (*v2pools.Member, error) {
+	return createPoolMember(c, poolID, opts)
+}
+
 func (c *MockCloud) CreatePoolMonitor(opts monitors.CreateOpts) (*monitors.Monitor, error) {
 	return createPoolMonitor(c, opts)
 }
@@ -229,6 +243,10 @@ func (c *MockCloud) CreateServerGroup(opt servergroups.CreateOptsBuilder) (*serv
 	return createServerGroup(c, opt)
 }
 
+func (c *MockCloud) GetSubnetSegmentID(subnetID string) (string, error) {
+	return getSubnetSegmentID(c, subnetID)
+}
+
 func (c *MockCloud) CreateSubnet(opt subnets.CreateOptsBuilder) (*subnets.Subnet, error) {
 	return createSubnet(c, opt)
 }
@@ -249,6 +267,22 @@ func (c *MockCloud) DeleteInstanceWithID(instanceID string) error {
 	return deleteInstanceWithID(c, instanceID)
 }
 
+func (c *MockCloud) UpdateInstanceMetadata(instanceID string, metadata map[string]string) error {
+	return updateInstanceMetadata(c, instanceID, metadata)
+}
+
+func (c *MockCloud) DeleteInstanceMetadata(instanceID string, key string) error {
+	return deleteInstanceMetadata(c, instanceID, key)
+}
+
+func (c *MockCloud) AddInstanceTag(instanceID string, tag string) error {
+	return addInstanceTag(c, instanceID, tag)
+}
+
+func (c *MockCloud) DeleteInstanceTag(instanceID string, tag string) error {
+	return deleteInstanceTag(c, instanceID, tag)
+}
+
 func (c *MockCloud) DeleteKeyPair(name string) error {
 	return deleteKeyPair(c, name)
 }
@@ -313,6 +347,18 @@ func (c *MockCloud) DeleteVolume(volumeID string) error {
 	return deleteVolume(c, volumeID)
 }
 
+func (c *MockCloud) ExtendVolume(volumeID string, sizeGB int) error {
+	return extendVolume(c, volumeID, sizeGB)
+}
+
+func (c *MockCloud) RetypeVolume(volumeID string, volumeType string) error {
+	return retypeVolume(c, volumeID, volumeType)
+}
+
+func (c *MockCloud) IsVolumeTypeEncrypted(volumeType string) (bool, error) {
+	return isVolumeTypeEncrypted(c, volumeType)
+}
+
 func (c *MockCloud) FindClusterStatus(cluster *kops.Cluster) (*kops.ClusterStatus, error) {
 	return findClusterStatus(c, cluster)
 }
@@ -333,6 +379,10 @@ func (c *MockCloud) GetExternalNetwork() (net *networks.Network, err error) {
 	return getExternalNetwork(c, *c.extNetworkName)
 }
 
+func (c *MockCloud) FindExternalNetwork(name string) (*networks.Network, error) {
+	return findExternalNetwork(c, name)
+}
+
 func (c *MockCloud) GetExternalSubnet() (subnet *subnets.Subnet, err error) {
 	return getExternalSubnet(c, c.extSubnetName)
 }
@@ -381,6 +431,18 @@ func (c *MockCloud) GetPort(id string) (*ports.Port, error) {
 	return getPort(c, id)
 }
 
+func (c *MockCloud) ListQosPolicies(opt policies.ListOpts) ([]policies.Policy, error) {
+	return listQosPolicies(context.Background(), c, opt)
+}
+
+func (c *MockCloud) FindQosPolicy(nameOrID string) (*policies.Policy, error) {
+	return findQosPolicy(context.Background(), c, nameOrID)
+}
+
+func (c *MockCloud) FindQosPolicyWithContext(ctx context.Context, nameOrID string) (*policies.Policy, error) {
+	return findQosPolicy(ctx, c, nameOrID)
+}
+
 func (c *MockCloud) UpdatePort(id string, opt ports.UpdateOptsBuilder) (*ports.Port, error) {
 	return updatePort(c, id, opt)
 }
@@ -401,6 +463,10 @@ func (c *MockCloud) ListDNSZones(opt zones.ListOptsBuilder) ([]zones.Zone, error
 	return listDNSZones(c, opt)
 }
 
+func (c *MockCloud) FindDNSZone(nameOrID string) (*zones.Zone, error) {
+	return findDNSZone(c, nameOrID)
+}
+
 func (c *MockCloud) ListDNSRecordsets(zoneID string, opt recordsets.ListOptsBuilder) ([]recordsets.RecordSet, error) {
 	return listDNSRecordsets(c, zoneID, opt)
 }
@@ -409,6 +475,14 @@ func (c *MockCloud) DeleteDNSRecordset(zoneID string, rrsetID string) error {
 	return deleteDNSRecordset(c, zoneID, rrsetID)
 }
 
+func (c *MockCloud) CreateDNSRecordset(zoneID string, opt recordsets.CreateOptsBuilder) (*recordsets.RecordSet, error) {
+	return createDNSRecordset(c, zoneID, opt)
+}
+
+func (c *MockCloud) UpdateDNSRecordset(zoneID string, rrsetID string, opt recordsets.UpdateOptsBuilder) (*recordsets.RecordSet, error) {
+	return updateDNSRecordset(c, zoneID, rrsetID, opt)
+}
+
 func (c *MockCloud) ListInstances(opt servers.ListOptsBuilder) ([]servers.Server, error) {
 	return listInstances(c, opt)
 }
@@ -429,14 +503,42 @@ func (c *MockCloud) GetLBStats(loadbalancerID string) (*loadbalancers.Stats, err
 	return getLBStats(c, loadbalancerID)
 }
 
+func (c *MockCloud) GetLBStatuses(loadbalancerID string) (*loadbalancers.StatusTree, error) {
+	return getLBStatuses(c, loadbalancerID)
+}
+
+func (c *MockCloud) GetLBFlavor(flavorID string) (*LBFlavor, error) {
+	return getLBFlavor(c, flavorID)
+}
+
+func (c *MockCloud) GetLBFlavorProfile(flavorProfileID string) (*LBFlavorProfile, error) {
+	return getLBFlavorProfile(c, flavorProfileID)
+}
+
+func (c *MockCloud) GetProjectQuotas() (*ProjectQuotas, error) {
+	projectID, err := currentProjectID(c)
+	if err != nil {
+		return nil, err
+	}
+	return getProjectQuotas(c, projectID)
+}
+
 func (c *MockCloud) ListPoolMembers(poolID string, opts v2pools.ListMembersOpts) ([]v2pools.Member, error) {
 	return listPoolMembers(c, poolID, opts)
 }
 
+func (c *MockCloud) FindLBsByName(name string) ([]loadbalancers.LoadBalancer, error) {
+	return findLBsByName(c, name)
+}
+
 func (c *MockCloud) ListLBs(opt loadbalancers.ListOptsBuilder) (lbs []loadbalancers.LoadBalancer, err error) {
 	return listLBs(c, opt)
 }
 
+func (c *MockCloud) CheckLoadBalancerService() error {
+	return checkLoadBalancerService(c)
+}
+
 func (c *MockCloud) ListListeners(opts listeners.ListOpts) (listenerList []listeners.Listener, err error) {
 	return listListeners(c, opts)
 }
@@ -462,7 +564,11 @@ func (c *MockCloud) ListRouters(opt routers.ListOpts) ([]routers.Router, error)
 }
 
 func (c *MockCloud) ListSecurityGroups(opt sg.ListOpts) ([]sg.SecGroup, error) {
-	return listSecurityGroups(c, opt)
+	return listSecurityGroups(context.Background(), c, opt)
+}
+
+func (c *MockCloud) ListSecurityGroupsWithContext(ctx context.Context, opt sg.ListOpts) ([]sg.SecGroup, error) {
+	return listSecurityGroups(ctx, c, opt)
 }
 
 func (c *MockCloud) ListSecurityGroupRules(opt sgr.ListOpts) ([]sgr.SecGroupRule, error) {
@@ -512,3 +618,7 @@ func (c *MockCloud) UseZones(zones []string) {
 func (c *MockCloud) UseLoadBalancerVIPACL() (bool, error) {
 	return true, nil
 }
+
+func (c *MockCloud) HasMetadataService() (bool, error) {
+	return true, nil
+}
@@ -35,7 +35,8 @@ func appendTag(c OpenstackCloud, resource string, id string, tag string) error {
 	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
 		err := attributestags.Add(c.NetworkingClient(), resource, id, tag).ExtractErr()
 		if err != nil {
-			return false, fmt.Errorf("error appending tag %s: %v", tag, err)
+			// e.g. a missing resource or a Neutron without tag support fails again
+			return !isRetryable(err), fmt.Errorf("error appending tag %s: %w", tag, err)
 		}
 		return true, nil
 	})
@@ -190,6 +191,39 @@ func getExternalNetwork(c OpenstackCloud, networkName string) (net *networks.Net
 	}
 }
 
+func (c *openstackCloud) FindExternalNetwork(name string) (*networks.Network, error) {
+	return findExternalNetwork(c, name)
+}
+
+// findExternalNetwork looks the network up once, unlike getExternalNetwork it does not wait for the network to appear
+func findExternalNetwork(c OpenstackCloud, name string) (*networks.Network, error) {
+	type NetworkWithExternalExt struct {
+		networks.Network
+		external.NetworkExternalExt
+	}
+
+	page, err := networks.List(c.NetworkingClient(), networks.ListOpts{Name: name}).AllPages()
+	if err != nil {
+		return nil, fmt.Errorf("error listing networks named %q: %v", name, err)
+	}
+	var nets []NetworkWithExternalExt
+	if err := networks.ExtractNetworksInto(page, &nets); err != nil {
+		return nil, fmt.Errorf("error extracting networks named %q: %v", name, err)
+	}
+
+	var found *networks.Network
+	for i := range nets {
+		if !nets[i].External || nets[i].Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("found multiple external networks named %q", name)
+		}
+		found = &nets[i].Network
+	}
+	return found, nil
+}
+
 func (c *openstackCloud) CreateNetwork(opt networks.CreateOptsBuilder) (*networks.Network, error) {
 	return createNetwork(c, opt)
 }
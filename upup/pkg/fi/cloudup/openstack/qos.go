@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/qos/policies"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func (c *openstackCloud) ListQosPolicies(opt policies.ListOpts) ([]policies.Policy, error) {
+	return listQosPolicies(context.Background(), c, opt)
+}
+
+func listQosPolicies(ctx context.Context, c OpenstackCloud, opt policies.ListOpts) ([]policies.Policy, error) {
+	var ps []policies.Policy
+	var lastErr error
+
+	err := wait.ExponentialBackoffWithContext(ctx, readBackoff, func(ctx context.Context) (bool, error) {
+		allPages, err := RunWithContext(ctx, policies.List(c.NetworkingClient(), opt).AllPages)
+		if err != nil {
+			lastErr = fmt.Errorf("error listing QoS policies: %w", err)
+			if !isRetryable(err) {
+				return false, lastErr
+			}
+			return false, nil
+		}
+		r, err := policies.ExtractPolicies(allPages)
+		if err != nil {
+			return false, fmt.Errorf("error extracting QoS policies from pages: %v", err)
+		}
+		ps = r
+		return true, nil
+	})
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("error listing QoS policies: %w", ctx.Err())
+	}
+	if err != nil {
+		if lastErr != nil && wait.Interrupted(err) {
+			return nil, lastErr
+		}
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (c *openstackCloud) FindQosPolicy(nameOrID string) (*policies.Policy, error) {
+	return findQosPolicy(context.Background(), c, nameOrID)
+}
+
+func (c *openstackCloud) FindQosPolicyWithContext(ctx context.Context, nameOrID string) (*policies.Policy, error) {
+	return findQosPolicy(ctx, c, nameOrID)
+}
+
+func findQosPolicy(ctx context.Context, c OpenstackCloud, nameOrID string) (*policies.Policy, error) {
+	ps, err := listQosPolicies(ctx, c, policies.ListOpts{ID: nameOrID})
+	if err != nil {
+		return nil, err
+	}
+	if len(ps) == 0 {
+		ps, err = listQosPolicies(ctx, c, policies.ListOpts{Name: nameOrID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ps) == 0 {
+		return nil, fmt.Errorf("QoS policy %q not found", nameOrID)
+	}
+	if len(ps) > 1 {
+		return nil, fmt.Errorf("found multiple QoS policies matching %q", nameOrID)
+	}
+	return &ps[0], nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/qos/policies"
+)
+
+func TestFindQosPolicy(t *testing.T) {
+	existing := []policies.Policy{
+		{ID: "policy-1", Name: "apiserver"},
+		{ID: "policy-2", Name: "duplicate"},
+		{ID: "policy-3", Name: "duplicate"},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/qos/policies", func(w http.ResponseWriter, r *http.Request) {
+		var matches []policies.Policy
+		for _, p := range existing {
+			if id := r.URL.Query().Get("id"); id != "" && p.ID != id {
+				continue
+			}
+			if name := r.URL.Query().Get("name"); name != "" && p.Name != name {
+				continue
+			}
+			matches = append(matches, p)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"policies": matches})
+	})
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	cloud := &openstackCloud{
+		neutronClient: serviceClient(testServer.URL),
+	}
+
+	for _, nameOrID := range []string{"policy-1", "apiserver"} {
+		policy, err := cloud.FindQosPolicy(nameOrID)
+		if err != nil {
+			t.Errorf("unexpected error finding QoS policy %q: %v", nameOrID, err)
+		} else if policy.ID != "policy-1" {
+			t.Errorf("expected QoS policy policy-1 for %q, got %q", nameOrID, policy.ID)
+		}
+	}
+	if _, err := cloud.FindQosPolicy("missing"); err == nil || err.Error() != `QoS policy "missing" not found` {
+		t.Errorf("unexpected error finding missing QoS policy: %v", err)
+	}
+	if _, err := cloud.FindQosPolicy("duplicate"); err == nil || err.Error() != `found multiple QoS policies matching "duplicate"` {
+		t.Errorf("unexpected error finding duplicate QoS policy: %v", err)
+	}
+}
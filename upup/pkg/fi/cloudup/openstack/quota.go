@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	cinderquotas "github.com/gophercloud/gophercloud/openstack/blockstorage/extensions/quotasets"
+	novaquotas "github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/quotasets"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	octaviaquotas "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/quotas"
+	neutronquotas "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/quotas"
+	"k8s.io/klog/v2"
+)
+
+// Quota is the limit and the usage of a resource of the project, a negative limit means that it is unlimited
+type Quota struct {
+	Limit int
+	InUse int
+}
+
+// ProjectQuotas are the quotas of the project that limit the resources kOps creates. The quota of a resource is nil
+// if it could not be read, e.g. because the service does not support quotas or the user is not allowed to read them.
+type ProjectQuotas struct {
+	Instances       *Quota
+	Volumes         *Quota
+	VolumeGigabytes *Quota
+	Ports           *Quota
+	FloatingIPs     *Quota
+	LoadBalancers   *Quota
+}
+
+// QuotaRequest is the number of resources of each kind that will be created
+type QuotaRequest struct {
+	Instances       int
+	Volumes         int
+	VolumeGigabytes int
+	Ports           int
+	FloatingIPs     int
+	LoadBalancers   int
+}
+
+// Check returns an error for every quota the request would exceed
+func (q *ProjectQuotas) Check(r QuotaRequest) error {
+	checks := []struct {
+		name      string
+		quota     *Quota
+		requested int
+	}{
+		{"Nova instances", q.Instances, r.Instances},
+		{"Cinder volumes", q.Volumes, r.Volumes},
+		{"Cinder gigabytes", q.VolumeGigabytes, r.VolumeGigabytes},
+		{"Neutron port", q.Ports, r.Ports},
+		{"Neutron floating IP", q.FloatingIPs, r.FloatingIPs},
+		{"Octavia loadbalancer", q.LoadBalancers, r.LoadBalancers},
+	}
+
+	var exceeded []string
+	for _, check := range checks {
+		if check.quota == nil || check.quota.Limit < 0 || check.requested == 0 {
+			continue
+		}
+		if over := check.quota.InUse + check.requested - check.quota.Limit; over > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("would exceed %s quota by %d (limit %d, in use %d, requested %d)",
+				check.name, over, check.quota.Limit, check.quota.InUse, check.requested))
+		}
+	}
+	if len(exceeded) > 0 {
+		return fmt.Errorf("not enough quota in the project: %s", strings.Join(exceeded, "; "))
+	}
+	return nil
+}
+
+func (c *openstackCloud) GetProjectQuotas() (*ProjectQuotas, error) {
+	projectID, err := currentProjectID(c)
+	if err != nil {
+		return nil, err
+	}
+	return getProjectQuotas(c, projectID)
+}
+
+// currentProjectID returns the project the token is scoped to, or the project of the environment if the token does
+// not tell it
+func currentProjectID(c OpenstackCloud) (string, error) {
+	if provider := c.ComputeClient().ProviderClient; provider != nil {
+		if result, ok := provider.GetAuthResult().(tokens.CreateResult); ok {
+			project, err := result.ExtractProject()
+			if err == nil && project != nil && project.ID != "" {
+				return project.ID, nil
+			}
+		}
+	}
+	for _, env := range []string{"OS_PROJECT_ID", "OS_TENANT_ID"} {
+		if projectID := os.Getenv(env); projectID != "" {
+			return projectID, nil
+		}
+	}
+	return "", fmt.Errorf("unable to determine the OpenStack project, set OS_PROJECT_ID")
+}
+
+// getProjectQuotas reads the quotas from Nova, Cinder, Neutron and Octavia, quotas that are not available are skipped
+func getProjectQuotas(c OpenstackCloud, projectID string) (*ProjectQuotas, error) {
+	q := &ProjectQuotas{}
+
+	var nova novaquotas.QuotaDetailSet
+	err := ReadRetryPolicy.Do(func() (err error) {
+		nova, err = novaquotas.GetDetail(c.ComputeClient(), projectID).Extract()
+		return err
+	})
+	if ok, err := quotaAvailable("Nova", err); err != nil {
+		return nil, err
+	} else if ok {
+		q.Instances = &Quota{Limit: nova.Instances.Limit, InUse: nova.Instances.InUse + nova.Instances.Reserved}
+	}
+
+	var cinder cinderquotas.QuotaUsageSet
+	err = ReadRetryPolicy.Do(func() (err error) {
+		cinder, err = cinderquotas.GetUsage(c.BlockStorageClient(), projectID).Extract()
+		return err
+	})
+	if ok, err := quotaAvailable("Cinder", err); err != nil {
+		return nil, err
+	} else if ok {
+		q.Volumes = &Quota{Limit: cinder.Volumes.Limit, InUse: cinder.Volumes.InUse + cinder.Volumes.Reserved}
+		q.VolumeGigabytes = &Quota{Limit: cinder.Gigabytes.Limit, InUse: cinder.Gigabytes.InUse + cinder.Gigabytes.Reserved}
+	}
+
+	var neutron *neutronquotas.QuotaDetailSet
+	err = ReadRetryPolicy.Do(func() (err error) {
+		neutron, err = neutronquotas.GetDetail(c.NetworkingClient(), projectID).Extract()
+		return err
+	})
+	if ok, err := quotaAvailable("Neutron", err); err != nil {
+		return nil, err
+	} else if ok {
+		q.Ports = &Quota{Limit: neutron.Port.Limit, InUse: neutron.Port.Used + neutron.Port.Reserved}
+		q.FloatingIPs = &Quota{Limit: neutron.FloatingIP.Limit, InUse: neutron.FloatingIP.Used + neutron.FloatingIP.Reserved}
+	}
+
+	// the Octavia quota API does not return the usage, the loadbalancers of the project are counted instead
+	if c.LoadBalancerClient() != nil && c.UseOctavia() {
+		var octavia *octaviaquotas.Quota
+		err = ReadRetryPolicy.Do(func() (err error) {
+			octavia, err = octaviaquotas.Get(c.LoadBalancerClient(), projectID).Extract()
+			return err
+		})
+		if ok, err := quotaAvailable("Octavia", err); err != nil {
+			return nil, err
+		} else if ok {
+			lbs, err := c.ListLBs(loadbalancers.ListOpts{ProjectID: projectID})
+			if err != nil {
+				return nil, err
+			}
+			q.LoadBalancers = &Quota{Limit: octavia.Loadbalancer, InUse: len(lbs)}
+		}
+	}
+
+	return q, nil
+}
+
+// quotaAvailable returns false without an error if the service does not implement quotas or the user may not read them
+func quotaAvailable(service string, err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	var errCode gophercloud.ErrUnexpectedResponseCode
+	if isNotFound(err) || (errors.As(err, &errCode) && errCode.Actual == http.StatusForbidden) {
+		klog.V(2).Infof("Skipping %s quota, it is not available: %v", service, err)
+		return false, nil
+	}
+	return false, fmt.Errorf("error reading %s quota: %w", service, err)
+}
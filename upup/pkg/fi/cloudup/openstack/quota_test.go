@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const (
+	novaQuotas     = `{"quota_set": {"id": "project", "instances": {"in_use": 3, "limit": 10, "reserved": 1}}}`
+	cinderQuotas   = `{"quota_set": {"id": "project", "volumes": {"in_use": 4, "limit": 10, "reserved": 0}, "gigabytes": {"in_use": 200, "limit": 1000, "reserved": 0}}}`
+	neutronQuotas  = `{"quota": {"port": {"used": 45, "limit": 50, "reserved": 2}, "floatingip": {"used": 1, "limit": -1, "reserved": 0}}}`
+	octaviaQuotas  = `{"quota": {"loadbalancer": 2, "listener": -1, "member": -1, "pool": -1, "healthmonitor": -1}}`
+	projectLBs     = `{"loadbalancers": [{"id": "lb-1", "name": "api"}]}`
+	quotaForbidden = `{"forbidden": {"code": 403, "message": "Policy doesn't allow this request."}}`
+	quotaNotFound  = `{"NeutronError": {"type": "HTTPNotFound", "message": "The resource could not be found."}}`
+)
+
+func Test_GetProjectQuotas(t *testing.T) {
+	tests := []struct {
+		desc           string
+		nova           string
+		novaStatus     int
+		neutron        string
+		neutronStatus  int
+		octavia        string
+		octaviaStatus  int
+		expectedQuotas *ProjectQuotas
+		// expectedError is formatted with the URL of the loadbalancer service
+		expectedError string
+	}{
+		{
+			desc:          "all quotas",
+			nova:          novaQuotas,
+			novaStatus:    http.StatusOK,
+			neutron:       neutronQuotas,
+			neutronStatus: http.StatusOK,
+			octavia:       octaviaQuotas,
+			octaviaStatus: http.StatusOK,
+			expectedQuotas: &ProjectQuotas{
+				Instances:       &Quota{Limit: 10, InUse: 4},
+				Volumes:         &Quota{Limit: 10, InUse: 4},
+				VolumeGigabytes: &Quota{Limit: 1000, InUse: 200},
+				Ports:           &Quota{Limit: 50, InUse: 47},
+				FloatingIPs:     &Quota{Limit: -1, InUse: 1},
+				LoadBalancers:   &Quota{Limit: 2, InUse: 1},
+			},
+		},
+		{
+			desc:          "quotas that may not be read are skipped",
+			nova:          quotaForbidden,
+			novaStatus:    http.StatusForbidden,
+			neutron:       quotaNotFound,
+			neutronStatus: http.StatusNotFound,
+			octavia:       octaviaQuotas,
+			octaviaStatus: http.StatusOK,
+			expectedQuotas: &ProjectQuotas{
+				Volumes:         &Quota{Limit: 10, InUse: 4},
+				VolumeGigabytes: &Quota{Limit: 1000, InUse: 200},
+				LoadBalancers:   &Quota{Limit: 2, InUse: 1},
+			},
+		},
+		{
+			desc:          "error reading a quota",
+			nova:          novaQuotas,
+			novaStatus:    http.StatusOK,
+			neutron:       neutronQuotas,
+			neutronStatus: http.StatusOK,
+			octavia:       `{"faultstring": "Invalid project"}`,
+			octaviaStatus: http.StatusBadRequest,
+			expectedError: "error reading Octavia quota: Bad request with: [GET %s/quotas/project], error message: {\"faultstring\": \"Invalid project\"}",
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			novaMux := http.NewServeMux()
+			novaServer := httptest.NewServer(novaMux)
+			defer novaServer.Close()
+			fixture(novaMux, "/os-quota-sets/project/detail", http.MethodGet, testCase.nova, testCase.novaStatus)
+
+			cinderMux := http.NewServeMux()
+			cinderServer := httptest.NewServer(cinderMux)
+			defer cinderServer.Close()
+			fixture(cinderMux, "/os-quota-sets/project", http.MethodGet, cinderQuotas, http.StatusOK)
+
+			neutronMux := http.NewServeMux()
+			neutronServer := httptest.NewServer(neutronMux)
+			defer neutronServer.Close()
+			fixture(neutronMux, "/quotas/project/details.json", http.MethodGet, testCase.neutron, testCase.neutronStatus)
+
+			lbMux := http.NewServeMux()
+			lbServer := httptest.NewServer(lbMux)
+			defer lbServer.Close()
+			fixture(lbMux, "/quotas/project", http.MethodGet, testCase.octavia, testCase.octaviaStatus)
+			fixture(lbMux, "/lbaas/loadbalancers", http.MethodGet, projectLBs, http.StatusOK)
+
+			cloud := &openstackCloud{
+				novaClient:    serviceClient(novaServer.URL),
+				cinderClient:  serviceClient(cinderServer.URL),
+				neutronClient: serviceClient(neutronServer.URL),
+				lbClient:      serviceClient(lbServer.URL),
+				useOctavia:    true,
+			}
+			quotas, err := getProjectQuotas(cloud, "project")
+			if testCase.expectedError != "" {
+				compareErrors(t, err, fmt.Errorf(testCase.expectedError, lbServer.URL))
+				return
+			}
+			compareErrors(t, err, nil)
+			if !reflect.DeepEqual(quotas, testCase.expectedQuotas) {
+				t.Errorf("Quotas differ:\n%+v\n\tinstead of\n%+v", quotas, testCase.expectedQuotas)
+			}
+		})
+	}
+}
+
+func Test_ProjectQuotas_Check(t *testing.T) {
+	quotas := &ProjectQuotas{
+		Instances:     &Quota{Limit: 10, InUse: 8},
+		Ports:         &Quota{Limit: 50, InUse: 47},
+		FloatingIPs:   &Quota{Limit: -1, InUse: 100},
+		LoadBalancers: &Quota{Limit: 2, InUse: 2},
+	}
+	tests := []struct {
+		desc          string
+		request       QuotaRequest
+		expectedError error
+	}{
+		{
+			desc:    "within the quotas",
+			request: QuotaRequest{Instances: 2, Ports: 3},
+		},
+		{
+			desc:    "unlimited and unknown quotas",
+			request: QuotaRequest{FloatingIPs: 10, Volumes: 100, VolumeGigabytes: 10000},
+		},
+		{
+			desc:          "one quota exceeded",
+			request:       QuotaRequest{Instances: 2, Ports: 5},
+			expectedError: fmt.Errorf("not enough quota in the project: would exceed Neutron port quota by 2 (limit 50, in use 47, requested 5)"),
+		},
+		{
+			desc:          "several quotas exceeded",
+			request:       QuotaRequest{Instances: 3, LoadBalancers: 1},
+			expectedError: fmt.Errorf("not enough quota in the project: would exceed Nova instances quota by 1 (limit 10, in use 8, requested 3); would exceed Octavia loadbalancer quota by 1 (limit 2, in use 2, requested 1)"),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			err := quotas.Check(testCase.request)
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// RetryPolicy retries the OpenStack calls that fail with transient errors, e.g. server side errors, throttling or
+// connection errors. Client errors like 400 Bad Request or 404 Not Found are returned without retrying.
+type RetryPolicy struct {
+	// Backoff is the backoff between the calls, its steps bound the number of calls
+	Backoff wait.Backoff
+	// RetryConflicts retries 409 Conflict responses. Conflicts are transient for resources that are immutable while
+	// they are provisioned, but not when creating a resource that already exists.
+	RetryConflicts bool
+}
+
+// ReadRetryPolicy is the retry policy of calls reading resources
+var ReadRetryPolicy = RetryPolicy{Backoff: readBackoff}
+
+// WriteRetryPolicy is the retry policy of calls creating or updating resources
+var WriteRetryPolicy = RetryPolicy{Backoff: writeBackoff}
+
+// IsTransient returns true if the error of a call is retried by the policy
+func (p RetryPolicy) IsTransient(err error) bool {
+	if IsConflict(err) {
+		return p.RetryConflicts
+	}
+	return isRetryable(err)
+}
+
+// Do calls fn until it succeeds, it returns an error that is not transient or the backoff is exhausted. The error of
+// the last call is returned.
+func (p RetryPolicy) Do(fn func() error) error {
+	done, err := vfs.RetryWithBackoff(p.Backoff, func() (bool, error) {
+		if err := fn(); err != nil {
+			return !p.IsTransient(err), err
+		}
+		return true, nil
+	})
+	if !done && err == nil {
+		return wait.ErrWaitTimeout
+	}
+	return err
+}
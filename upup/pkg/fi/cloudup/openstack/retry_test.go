@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func responseError(status int) error {
+	return gophercloud.ErrUnexpectedResponseCode{Method: http.MethodPost, Actual: status}
+}
+
+func Test_RetryPolicy_Do(t *testing.T) {
+	tests := []struct {
+		desc           string
+		retryConflicts bool
+		errs           []error
+		expectedCalls  int
+		expectedError  string
+	}{
+		{
+			desc:          "success",
+			errs:          []error{nil},
+			expectedCalls: 1,
+		},
+		{
+			desc:          "server errors are retried",
+			errs:          []error{responseError(http.StatusInternalServerError), responseError(http.StatusServiceUnavailable), nil},
+			expectedCalls: 3,
+		},
+		{
+			desc:          "throttling is retried",
+			errs:          []error{responseError(http.StatusTooManyRequests), nil},
+			expectedCalls: 2,
+		},
+		{
+			desc:          "connection errors are retried",
+			errs:          []error{fmt.Errorf("connection refused"), nil},
+			expectedCalls: 2,
+		},
+		{
+			desc:          "client errors are not retried",
+			errs:          []error{responseError(http.StatusBadRequest), nil},
+			expectedCalls: 1,
+			expectedError: responseError(http.StatusBadRequest).Error(),
+		},
+		{
+			desc:          "conflicts are not retried by default",
+			errs:          []error{responseError(http.StatusConflict), nil},
+			expectedCalls: 1,
+			expectedError: responseError(http.StatusConflict).Error(),
+		},
+		{
+			desc:           "conflicts are retried",
+			retryConflicts: true,
+			errs:           []error{responseError(http.StatusConflict), nil},
+			expectedCalls:  2,
+		},
+		{
+			desc:          "last error is returned",
+			errs:          []error{fmt.Errorf("first"), fmt.Errorf("second"), fmt.Errorf("third")},
+			expectedCalls: 3,
+			expectedError: "third",
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			policy := RetryPolicy{
+				Backoff:        wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3},
+				RetryConflicts: testCase.retryConflicts,
+			}
+			calls := 0
+			err := policy.Do(func() error {
+				err := testCase.errs[calls]
+				calls++
+				return err
+			})
+			if testCase.expectedError == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if testCase.expectedError != "" && fmt.Sprint(err) != testCase.expectedError {
+				t.Errorf("expected error %q, got %v", testCase.expectedError, err)
+			}
+			if calls != testCase.expectedCalls {
+				t.Errorf("expected %d calls, got %d", testCase.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func Test_RetryPolicy_IsTransient(t *testing.T) {
+	notFound := gophercloud.ErrDefault404{ErrUnexpectedResponseCode: gophercloud.ErrUnexpectedResponseCode{Actual: http.StatusNotFound}}
+	if ReadRetryPolicy.IsTransient(fmt.Errorf("error getting loadbalancer: %w", notFound)) {
+		t.Errorf("expected wrapped not found errors not to be transient")
+	}
+	if !WriteRetryPolicy.IsTransient(fmt.Errorf("error creating loadbalancer: %w", responseError(http.StatusBadGateway))) {
+		t.Errorf("expected wrapped server errors to be transient")
+	}
+	if WriteRetryPolicy.IsTransient(fmt.Errorf("error creating loadbalancer: %w", responseError(http.StatusConflict))) {
+		t.Errorf("expected conflicts not to be transient for writes")
+	}
+}
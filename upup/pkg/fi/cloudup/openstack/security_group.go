@@ -17,6 +17,7 @@ limitations under the License.
 package openstack
 
 import (
+	"context"
 	"fmt"
 
 	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
@@ -26,16 +27,25 @@ import (
 )
 
 func (c *openstackCloud) ListSecurityGroups(opt sg.ListOpts) ([]sg.SecGroup, error) {
-	return listSecurityGroups(c, opt)
+	return listSecurityGroups(context.Background(), c, opt)
 }
 
-func listSecurityGroups(c OpenstackCloud, opt sg.ListOpts) ([]sg.SecGroup, error) {
+func (c *openstackCloud) ListSecurityGroupsWithContext(ctx context.Context, opt sg.ListOpts) ([]sg.SecGroup, error) {
+	return listSecurityGroups(ctx, c, opt)
+}
+
+func listSecurityGroups(ctx context.Context, c OpenstackCloud, opt sg.ListOpts) ([]sg.SecGroup, error) {
 	var groups []sg.SecGroup
+	var lastErr error
 
-	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
-		allPages, err := sg.List(c.NetworkingClient(), opt).AllPages()
+	err := wait.ExponentialBackoffWithContext(ctx, readBackoff, func(ctx context.Context) (bool, error) {
+		allPages, err := RunWithContext(ctx, sg.List(c.NetworkingClient(), opt).AllPages)
 		if err != nil {
-			return false, fmt.Errorf("error listing security groups %v: %v", opt, err)
+			lastErr = fmt.Errorf("error listing security groups %v: %w", opt, err)
+			if !isRetryable(err) {
+				return false, lastErr
+			}
+			return false, nil
 		}
 
 		gs, err := sg.ExtractGroups(allPages)
@@ -45,13 +55,16 @@ func listSecurityGroups(c OpenstackCloud, opt sg.ListOpts) ([]sg.SecGroup, error
 		groups = gs
 		return true, nil
 	})
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("error listing security groups %v: %w", opt, ctx.Err())
+	}
 	if err != nil {
-		return groups, err
-	} else if done {
-		return groups, nil
-	} else {
-		return groups, wait.ErrWaitTimeout
+		if lastErr != nil && wait.Interrupted(err) {
+			return nil, lastErr
+		}
+		return nil, err
 	}
+	return groups, nil
 }
 
 func (c *openstackCloud) CreateSecurityGroup(opt sg.CreateOptsBuilder) (*sg.SecGroup, error) {
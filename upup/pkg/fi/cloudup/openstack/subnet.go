@@ -77,6 +77,33 @@ func getSubnet(c OpenstackCloud, subnetID string) (*subnets.Subnet, error) {
 	}
 }
 
+func (c *openstackCloud) GetSubnetSegmentID(subnetID string) (string, error) {
+	return getSubnetSegmentID(c, subnetID)
+}
+
+func getSubnetSegmentID(c OpenstackCloud, subnetID string) (string, error) {
+	var segmentID string
+	done, err := vfs.RetryWithBackoff(readBackoff, func() (bool, error) {
+		var body struct {
+			Subnet struct {
+				SegmentID *string `json:"segment_id"`
+			} `json:"subnet"`
+		}
+		if err := subnets.Get(c.NetworkingClient(), subnetID).ExtractInto(&body); err != nil {
+			return false, fmt.Errorf("error retrieving subnet: %v", err)
+		}
+		segmentID = fi.ValueOf(body.Subnet.SegmentID)
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	} else if done {
+		return segmentID, nil
+	} else {
+		return "", wait.ErrWaitTimeout
+	}
+}
+
 func (c *openstackCloud) CreateSubnet(opt subnets.CreateOptsBuilder) (*subnets.Subnet, error) {
 	return createSubnet(c, opt)
 }
@@ -18,8 +18,12 @@ package openstack
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/extensions/volumeactions"
 	cinder "github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumetypes"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/volumeattach"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
@@ -131,6 +135,123 @@ func setVolumeTags(c OpenstackCloud, id string, tags map[string]string) error {
 	}
 }
 
+func (c *openstackCloud) ExtendVolume(volumeID string, sizeGB int) error {
+	return extendVolume(c, volumeID, sizeGB)
+}
+
+func extendVolume(c OpenstackCloud, volumeID string, sizeGB int) error {
+	// extending attached volumes requires microversion 3.42
+	client := *c.BlockStorageClient()
+	client.Microversion = "3.42"
+
+	opt := volumeactions.ExtendSizeOpts{NewSize: sizeGB}
+	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
+		err := volumeactions.ExtendSize(&client, volumeID, opt).ExtractErr()
+		if err != nil {
+			return false, fmt.Errorf("error extending cinder volume %q to %dGB: %v", volumeID, sizeGB, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	} else if done {
+		return nil
+	} else {
+		return wait.ErrWaitTimeout
+	}
+}
+
+func (c *openstackCloud) RetypeVolume(volumeID string, volumeType string) error {
+	return retypeVolume(c, volumeID, volumeType)
+}
+
+func retypeVolume(c OpenstackCloud, volumeID string, volumeType string) error {
+	opt := volumeactions.ChangeTypeOpts{
+		NewType:         volumeType,
+		MigrationPolicy: volumeactions.MigrationPolicyOnDemand,
+	}
+	done, err := vfs.RetryWithBackoff(writeBackoff, func() (bool, error) {
+		err := volumeactions.ChangeType(c.BlockStorageClient(), volumeID, opt).ExtractErr()
+		if err != nil {
+			return false, fmt.Errorf("error changing type of cinder volume %q to %q: %v", volumeID, volumeType, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	} else if done {
+		return nil
+	} else {
+		return wait.ErrWaitTimeout
+	}
+}
+
+// volumePollInterval is the interval for polling a volume until an action on it has completed
+var volumePollInterval = 5 * time.Second
+
+// WaitVolumeAvailable polls the volume until its status is in-use or available again after an action such as an extend,
+// it returns an error if the volume is still busy after the timeout or has gone into an error state.
+func WaitVolumeAvailable(c OpenstackCloud, volumeID string, timeout time.Duration) error {
+	backoff := wait.Backoff{
+		Duration: volumePollInterval,
+		Factor:   1,
+		Jitter:   0.1,
+		Steps:    int(timeout/volumePollInterval) + 1,
+	}
+	status := ""
+	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		volume, err := cinder.Get(c.BlockStorageClient(), volumeID).Extract()
+		if err != nil {
+			if !isRetryable(err) {
+				return false, fmt.Errorf("error getting volume %s: %w", volumeID, err)
+			}
+			klog.V(2).Infof("error getting volume %s, will retry: %v", volumeID, err)
+			return false, nil
+		}
+		status = volume.Status
+		switch {
+		case status == "in-use" || status == "available":
+			return true, nil
+		case strings.HasPrefix(status, "error"):
+			return false, fmt.Errorf("volume %s has gone into %s state", volumeID, status)
+		}
+		klog.V(2).Infof("Waiting for volume %s to be available (status %q)...", volumeID, status)
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("volume %s was not available within %v (status %q)", volumeID, timeout, status)
+	}
+	return err
+}
+
+func (c *openstackCloud) IsVolumeTypeEncrypted(volumeType string) (bool, error) {
+	return isVolumeTypeEncrypted(c, volumeType)
+}
+
+func isVolumeTypeEncrypted(c OpenstackCloud, volumeType string) (bool, error) {
+	allPages, err := volumetypes.List(c.BlockStorageClient(), volumetypes.ListOpts{}).AllPages()
+	if err != nil {
+		return false, fmt.Errorf("error listing volume types: %v", err)
+	}
+	types, err := volumetypes.ExtractVolumeTypes(allPages)
+	if err != nil {
+		return false, fmt.Errorf("error extracting volume types: %v", err)
+	}
+
+	for _, t := range types {
+		if t.Name != volumeType && t.ID != volumeType {
+			continue
+		}
+		// the encryption is empty for unencrypted volume types
+		encryption, err := volumetypes.GetEncryption(c.BlockStorageClient(), t.ID).Extract()
+		if err != nil {
+			return false, fmt.Errorf("error getting encryption of volume type %q: %v", volumeType, err)
+		}
+		return encryption.EncryptionID != "", nil
+	}
+	return false, fmt.Errorf("volume type %q not found", volumeType)
+}
+
 func (c *openstackCloud) DeleteVolume(volumeID string) error {
 	return deleteVolume(c, volumeID)
 }
@@ -0,0 +1,294 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/dns/v2/recordsets"
+	"github.com/gophercloud/gophercloud/openstack/dns/v2/zones"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/dns-controller/pkg/dns"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+// +kops:fitask
+type DNSRecordSet struct {
+	ID *string
+	// Name is the fully qualified name of the record
+	Name *string
+	// Zone is the name or ID of the Designate zone, e.g. example.com., defaults to the longest zone matching the record name
+	Zone *string
+	// Type is the record type, A or AAAA
+	Type *string
+	TTL  *int
+	// ClusterName is recorded in the description of the recordset, so it can be deleted with the cluster
+	ClusterName *string
+
+	// LB is the loadbalancer whose VIP address is the value of the record
+	LB *LB
+	// FloatingIP is the floating IP whose address is the value of the record
+	FloatingIP *FloatingIP
+
+	// Records are the addresses of the record, they are taken from LB or FloatingIP
+	Records []string
+
+	Lifecycle fi.Lifecycle
+}
+
+var dnsRecordSetTypes = []string{"A", "AAAA"}
+
+// DNSRecordSetDescription returns the description of the recordsets managed for the cluster
+func DNSRecordSetDescription(clusterName string) string {
+	return fmt.Sprintf("%s=%s", openstack.TagClusterName, clusterName)
+}
+
+// GetDependencies returns the dependencies of the DNSRecordSet task, the record is written once the address of its
+// loadbalancer or floating IP exists
+func (e *DNSRecordSet) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
+	var deps []fi.CloudupTask
+	if e.LB != nil {
+		deps = append(deps, e.LB)
+	}
+	if e.FloatingIP != nil {
+		deps = append(deps, e.FloatingIP)
+	}
+	return deps
+}
+
+var _ fi.CompareWithID = &DNSRecordSet{}
+
+func (e *DNSRecordSet) CompareWithID() *string {
+	return e.ID
+}
+
+// findDNSZone returns the zone of the recordset, the zone with the longest name matching the record name is used if
+// the zone is not set.
+func findDNSZone(cloud openstack.OpenstackCloud, e *DNSRecordSet) (*zones.Zone, error) {
+	name := dns.EnsureDotSuffix(fi.ValueOf(e.Name))
+	inZone := func(z *zones.Zone) bool {
+		zoneName := dns.EnsureDotSuffix(z.Name)
+		return name == zoneName || strings.HasSuffix(name, "."+zoneName)
+	}
+
+	if e.Zone != nil {
+		zone, err := cloud.FindDNSZone(fi.ValueOf(e.Zone))
+		if err != nil {
+			return nil, err
+		}
+		if !inZone(zone) {
+			return nil, fmt.Errorf("DNS record %q is not part of DNS zone %q", fi.ValueOf(e.Name), zone.Name)
+		}
+		return zone, nil
+	}
+
+	zs, err := cloud.ListDNSZones(zones.ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	var zone *zones.Zone
+	for i := range zs {
+		z := &zs[i]
+		if inZone(z) && (zone == nil || len(z.Name) > len(zone.Name)) {
+			zone = z
+		}
+	}
+	if zone == nil {
+		return nil, fmt.Errorf("DNS zone for record %q not found", fi.ValueOf(e.Name))
+	}
+	return zone, nil
+}
+
+// findDNSRecordSetAddress returns the address of the loadbalancer or floating IP, it returns an empty string if the
+// address is not known yet.
+func findDNSRecordSetAddress(cloud openstack.OpenstackCloud, e *DNSRecordSet) (string, error) {
+	if e.FloatingIP != nil {
+		if e.FloatingIP.IP != nil {
+			return fi.ValueOf(e.FloatingIP.IP), nil
+		}
+		if e.FloatingIP.ID != nil {
+			fip, err := cloud.GetL3FloatingIP(fi.ValueOf(e.FloatingIP.ID))
+			if err != nil {
+				return "", fmt.Errorf("failed to get floating ip %s: %v", fi.ValueOf(e.FloatingIP.ID), err)
+			}
+			return fip.FloatingIP, nil
+		}
+		return "", nil
+	}
+	if e.LB != nil && e.LB.ID != nil {
+		lb, err := cloud.GetLB(fi.ValueOf(e.LB.ID))
+		if err != nil {
+			return "", fmt.Errorf("failed to get loadbalancer %s: %v", fi.ValueOf(e.LB.ID), err)
+		}
+		return lb.VipAddress, nil
+	}
+	return "", nil
+}
+
+func (e *DNSRecordSet) Find(c *fi.CloudupContext) (*DNSRecordSet, error) {
+	if e == nil || e.Name == nil {
+		return nil, nil
+	}
+	cloud := c.T.Cloud.(openstack.OpenstackCloud)
+
+	address, err := findDNSRecordSetAddress(cloud, e)
+	if err != nil {
+		return nil, err
+	}
+	if address != "" {
+		e.Records = []string{address}
+	}
+
+	zone, err := findDNSZone(cloud, e)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs, err := cloud.ListDNSRecordsets(zone.ID, recordsets.ListOpts{
+		Name: dns.EnsureDotSuffix(fi.ValueOf(e.Name)),
+		Type: fi.ValueOf(e.Type),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rrs) == 0 {
+		return nil, nil
+	}
+	if len(rrs) > 1 {
+		return nil, fmt.Errorf("found multiple DNS recordsets with name %s and type %s", fi.ValueOf(e.Name), fi.ValueOf(e.Type))
+	}
+	rr := rrs[0]
+	// a record managed by others, e.g. by the operator, is never repointed to the cluster
+	if e.ClusterName == nil || rr.Description != DNSRecordSetDescription(fi.ValueOf(e.ClusterName)) {
+		return nil, fmt.Errorf("DNS recordset %s of type %s already exists and is not managed by cluster %s (description %q), delete it or remove spec.api.publicName",
+			fi.ValueOf(e.Name), fi.ValueOf(e.Type), fi.ValueOf(e.ClusterName), rr.Description)
+	}
+
+	actual := &DNSRecordSet{
+		ID:          fi.PtrTo(rr.ID),
+		Name:        e.Name,
+		Zone:        e.Zone,
+		Type:        fi.PtrTo(rr.Type),
+		TTL:         fi.PtrTo(rr.TTL),
+		ClusterName: e.ClusterName,
+		LB:          e.LB,
+		FloatingIP:  e.FloatingIP,
+		Records:     rr.Records,
+		Lifecycle:   e.Lifecycle,
+	}
+	// the order of the records is not relevant, unknown addresses are not compared
+	if e.Records == nil || sameElements(rr.Records, e.Records) {
+		actual.Records = e.Records
+	}
+	e.ID = actual.ID
+
+	return actual, nil
+}
+
+func (e *DNSRecordSet) Run(c *fi.CloudupContext) error {
+	return fi.CloudupDefaultDeltaRunMethod(e, c)
+}
+
+func (_ *DNSRecordSet) CheckChanges(a, e, changes *DNSRecordSet) error {
+	if a == nil {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.Type == nil {
+			return fi.RequiredField("Type")
+		}
+	} else {
+		if changes.ID != nil {
+			return fi.CannotChangeField("ID")
+		}
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+		if changes.Type != nil {
+			return fi.CannotChangeField("Type")
+		}
+	}
+	if e.Type != nil && !slices.Contains(dnsRecordSetTypes, fi.ValueOf(e.Type)) {
+		return fmt.Errorf("DNSRecordSet %s has unsupported type %q, must be one of %v", fi.ValueOf(e.Name), fi.ValueOf(e.Type), dnsRecordSetTypes)
+	}
+	if e.TTL != nil && fi.ValueOf(e.TTL) <= 0 {
+		return fmt.Errorf("DNSRecordSet %s has invalid TTL %d, must be greater than 0", fi.ValueOf(e.Name), fi.ValueOf(e.TTL))
+	}
+	if (e.LB == nil) == (e.FloatingIP == nil) {
+		return fmt.Errorf("DNSRecordSet %s must reference either a loadbalancer or a floating IP", fi.ValueOf(e.Name))
+	}
+	return nil
+}
+
+func (_ *DNSRecordSet) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *DNSRecordSet) error {
+	defer openstack.RecordRender("DNSRecordSet", fi.ValueOf(e.Name))()
+
+	if e.Records == nil {
+		address, err := findDNSRecordSetAddress(t.Cloud, e)
+		if err != nil {
+			return err
+		}
+		if address == "" {
+			return fmt.Errorf("address of DNSRecordSet %s is not known", fi.ValueOf(e.Name))
+		}
+		e.Records = []string{address}
+	}
+
+	zone, err := findDNSZone(t.Cloud, e)
+	if err != nil {
+		return err
+	}
+
+	if a == nil {
+		klog.V(2).Infof("Creating DNSRecordSet with Name: %q", fi.ValueOf(e.Name))
+
+		opts := recordsets.CreateOpts{
+			Name:    dns.EnsureDotSuffix(fi.ValueOf(e.Name)),
+			Type:    fi.ValueOf(e.Type),
+			TTL:     fi.ValueOf(e.TTL),
+			Records: e.Records,
+		}
+		if e.ClusterName != nil {
+			opts.Description = DNSRecordSetDescription(fi.ValueOf(e.ClusterName))
+		}
+		rr, err := t.Cloud.CreateDNSRecordset(zone.ID, opts)
+		if err != nil {
+			return fmt.Errorf("error creating DNSRecordSet: %v", err)
+		}
+		e.ID = fi.PtrTo(rr.ID)
+
+		return nil
+	}
+
+	if changes.Records != nil || changes.TTL != nil {
+		klog.V(2).Infof("Updating DNSRecordSet with Name: %q", fi.ValueOf(e.Name))
+
+		opts := recordsets.UpdateOpts{
+			Records: e.Records,
+			TTL:     e.TTL,
+		}
+		_, err := t.Cloud.UpdateDNSRecordset(zone.ID, fi.ValueOf(a.ID), opts)
+		if err != nil {
+			return fmt.Errorf("error updating DNSRecordSet: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/dns/v2/recordsets"
+	"github.com/gophercloud/gophercloud/openstack/dns/v2/zones"
+	"k8s.io/kops/cloudmock/openstack/mockdns"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_DNSRecordSet_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *DNSRecordSet
+		expected      *DNSRecordSet
+		changes       *DNSRecordSet
+		expectedError error
+	}{
+		{
+			desc:   "actual nil all required fields set",
+			actual: nil,
+			expected: &DNSRecordSet{
+				Name:       fi.PtrTo("api.cluster.example.com"),
+				Type:       fi.PtrTo("A"),
+				TTL:        fi.PtrTo(60),
+				FloatingIP: &FloatingIP{Name: fi.PtrTo("fip-api")},
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil required field Type nil",
+			actual: nil,
+			expected: &DNSRecordSet{
+				Name:       fi.PtrTo("api.cluster.example.com"),
+				FloatingIP: &FloatingIP{Name: fi.PtrTo("fip-api")},
+			},
+			expectedError: fi.RequiredField("Type"),
+		},
+		{
+			desc:   "actual nil unsupported type",
+			actual: nil,
+			expected: &DNSRecordSet{
+				Name:       fi.PtrTo("api.cluster.example.com"),
+				Type:       fi.PtrTo("CNAME"),
+				FloatingIP: &FloatingIP{Name: fi.PtrTo("fip-api")},
+			},
+			expectedError: fmt.Errorf("DNSRecordSet api.cluster.example.com has unsupported type \"CNAME\", must be one of [A AAAA]"),
+		},
+		{
+			desc:   "actual nil invalid TTL",
+			actual: nil,
+			expected: &DNSRecordSet{
+				Name: fi.PtrTo("api.cluster.example.com"),
+				Type: fi.PtrTo("A"),
+				TTL:  fi.PtrTo(0),
+				LB:   &LB{Name: fi.PtrTo("api")},
+			},
+			expectedError: fmt.Errorf("DNSRecordSet api.cluster.example.com has invalid TTL 0, must be greater than 0"),
+		},
+		{
+			desc:   "actual nil both loadbalancer and floating IP",
+			actual: nil,
+			expected: &DNSRecordSet{
+				Name:       fi.PtrTo("api.cluster.example.com"),
+				Type:       fi.PtrTo("A"),
+				LB:         &LB{Name: fi.PtrTo("api")},
+				FloatingIP: &FloatingIP{Name: fi.PtrTo("fip-api")},
+			},
+			expectedError: fmt.Errorf("DNSRecordSet api.cluster.example.com must reference either a loadbalancer or a floating IP"),
+		},
+		{
+			desc: "actual not nil records and TTL changed",
+			actual: &DNSRecordSet{
+				Name:    fi.PtrTo("api.cluster.example.com"),
+				Type:    fi.PtrTo("A"),
+				TTL:     fi.PtrTo(300),
+				Records: []string{"192.0.2.1"},
+				LB:      &LB{Name: fi.PtrTo("api")},
+			},
+			expected: &DNSRecordSet{
+				Name:    fi.PtrTo("api.cluster.example.com"),
+				Type:    fi.PtrTo("A"),
+				TTL:     fi.PtrTo(60),
+				Records: []string{"192.0.2.2"},
+				LB:      &LB{Name: fi.PtrTo("api")},
+			},
+			changes: &DNSRecordSet{
+				TTL:     fi.PtrTo(60),
+				Records: []string{"192.0.2.2"},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil unchangeable field Type set",
+			actual: &DNSRecordSet{
+				Name: fi.PtrTo("api.cluster.example.com"),
+				Type: fi.PtrTo("A"),
+				LB:   &LB{Name: fi.PtrTo("api")},
+			},
+			expected: &DNSRecordSet{
+				Name: fi.PtrTo("api.cluster.example.com"),
+				Type: fi.PtrTo("AAAA"),
+				LB:   &LB{Name: fi.PtrTo("api")},
+			},
+			changes: &DNSRecordSet{
+				Type: fi.PtrTo("AAAA"),
+			},
+			expectedError: fi.CannotChangeField("Type"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var recordSet DNSRecordSet
+			err := (&recordSet).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_DNSRecordSet_Find(t *testing.T) {
+	tests := []struct {
+		desc          string
+		description   string
+		expectedError string
+	}{
+		{
+			desc:        "adopts record of the cluster",
+			description: DNSRecordSetDescription("cluster.example.com"),
+		},
+		{
+			desc:          "refuses record of another cluster",
+			description:   DNSRecordSetDescription("other.example.com"),
+			expectedError: "is not managed by cluster cluster.example.com",
+		},
+		{
+			desc:          "refuses record without description",
+			expectedError: "is not managed by cluster cluster.example.com",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockDNSClient: mockdns.CreateClient(),
+			}
+			if err := zones.Create(cloud.DNSClient(), zones.CreateOpts{Name: "example.com."}).Err; err != nil {
+				t.Fatalf("error creating zone: %v", err)
+			}
+			zone, err := cloud.FindDNSZone("example.com.")
+			if err != nil {
+				t.Fatalf("error finding zone: %v", err)
+			}
+			rr, err := cloud.CreateDNSRecordset(zone.ID, recordsets.CreateOpts{
+				Name:        "api.cluster.example.com.",
+				Description: testCase.description,
+				Type:        "A",
+				TTL:         60,
+				Records:     []string{"192.0.2.10"},
+			})
+			if err != nil {
+				t.Fatalf("error creating recordset: %v", err)
+			}
+
+			e := &DNSRecordSet{
+				Name:        fi.PtrTo("api.cluster.example.com"),
+				Type:        fi.PtrTo("A"),
+				TTL:         fi.PtrTo(60),
+				ClusterName: fi.PtrTo("cluster.example.com"),
+				FloatingIP:  &FloatingIP{Name: fi.PtrTo("fip-api"), IP: fi.PtrTo("192.0.2.10")},
+			}
+			actual, err := e.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+			if testCase.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", testCase.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual == nil || fi.ValueOf(actual.ID) != rr.ID || fi.ValueOf(e.ID) != rr.ID {
+				t.Fatalf("expected recordset %s to be adopted, got %+v", rr.ID, actual)
+			}
+		})
+	}
+}
+
+func Test_DNSRecordSet_GetDependencies(t *testing.T) {
+	lb := &LB{Name: fi.PtrTo("api")}
+	fip := &FloatingIP{Name: fi.PtrTo("fip-api")}
+	tasks := map[string]fi.CloudupTask{
+		"LB/api":             lb,
+		"LB/other":           &LB{Name: fi.PtrTo("other")},
+		"FloatingIP/fip-api": fip,
+		"FloatingIP/other":   &FloatingIP{Name: fi.PtrTo("other")},
+	}
+
+	deps := (&DNSRecordSet{LB: lb}).GetDependencies(tasks)
+	if len(deps) != 1 || deps[0] != lb {
+		t.Errorf("expected only the loadbalancer of the record, got %v", deps)
+	}
+	deps = (&DNSRecordSet{FloatingIP: fip}).GetDependencies(tasks)
+	if len(deps) != 1 || deps[0] != fip {
+		t.Errorf("expected only the floating IP of the record, got %v", deps)
+	}
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 
 	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
@@ -39,6 +43,9 @@ type FloatingIP struct {
 	LB        *LB
 	IP        *string
 	Lifecycle fi.Lifecycle
+	// FloatingNetwork is the name of the external network the floating IP is allocated from,
+	// defaults to the external network of the router. It is only used when the floating IP is created.
+	FloatingNetwork *string
 
 	// WellKnownServices indicates which services are supported by this resource.
 	// This field is internal and is not rendered to the cloud.
@@ -152,29 +159,49 @@ func (e *FloatingIP) Find(c *fi.CloudupContext) (*FloatingIP, error) {
 			ID:        fi.PtrTo(fip.ID),
 			LB:        e.LB,
 			Lifecycle: e.Lifecycle,
+			// the floating IP is not moved to another network
+			FloatingNetwork: e.FloatingNetwork,
 		}
 		e.ID = actual.ID
 		return actual, nil
 	}
-	fipname := fi.ValueOf(e.Name)
+	fip, byPort, err := findNamedFip(cloud, fi.ValueOf(e.Name))
+	if err != nil || fip == nil {
+		return nil, err
+	}
+	if byPort {
+		actual := &FloatingIP{
+			Name:      fi.PtrTo(fip.Description),
+			ID:        fi.PtrTo(fip.ID),
+			Lifecycle: e.Lifecycle,
+		}
+		e.ID = actual.ID
+		return actual, nil
+	}
+	actual := &FloatingIP{
+		ID:        fi.PtrTo(fip.ID),
+		Name:      e.Name,
+		IP:        fi.PtrTo(fip.FloatingIP),
+		Lifecycle: e.Lifecycle,
+	}
+	e.ID = actual.ID
+	e.IP = actual.IP
+	return actual, nil
+}
+
+// findNamedFip returns the floating IP with the description fipname, or the floating IP of the port named like it
+// if no floating IP has the description. byPort is true in the latter case. Nothing is modified.
+func findNamedFip(cloud openstack.OpenstackCloud, fipname string) (fip *l3floatingip.FloatingIP, byPort bool, err error) {
 	fips, err := cloud.ListL3FloatingIPs(l3floatingip.ListOpts{
 		Description: fipname,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list layer 3 floating ips: %v", err)
+		return nil, false, fmt.Errorf("failed to list layer 3 floating ips: %v", err)
 	}
 
-	for _, fip := range fips {
-		if fip.Description == fi.ValueOf(e.Name) {
-			actual := &FloatingIP{
-				ID:        fi.PtrTo(fips[0].ID),
-				Name:      e.Name,
-				IP:        fi.PtrTo(fip.FloatingIP),
-				Lifecycle: e.Lifecycle,
-			}
-			e.ID = actual.ID
-			e.IP = actual.IP
-			return actual, nil
+	for i := range fips {
+		if fips[i].Description == fipname {
+			return &fips[i], false, nil
 		}
 	}
 
@@ -187,29 +214,18 @@ func (e *FloatingIP) Find(c *fi.CloudupContext) (*FloatingIP, error) {
 			Name: portname,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to list ports: %v", err)
+			return nil, false, fmt.Errorf("failed to list ports: %v", err)
 		}
 
 		if len(ports) == 1 {
-
 			fip, err := findFipByPortID(cloud, ports[0].ID)
 			if err != nil {
-				return nil, fmt.Errorf("failed to find floating ip: %v", err)
-			}
-			if fip == nil {
-				return nil, nil
-			}
-			actual := &FloatingIP{
-				Name:      fi.PtrTo(fip.Description),
-				ID:        fi.PtrTo(fip.ID),
-				Lifecycle: e.Lifecycle,
+				return nil, false, fmt.Errorf("failed to find floating ip: %v", err)
 			}
-			e.ID = actual.ID
-			return actual, nil
+			return fip, true, nil
 		}
-
 	}
-	return nil, nil
+	return nil, false, nil
 }
 
 func findFipByPortID(cloud openstack.OpenstackCloud, id string) (fip *l3floatingip.FloatingIP, err error) {
@@ -262,12 +278,14 @@ func (_ *FloatingIP) ShouldCreate(a, e, changes *FloatingIP) (bool, error) {
 }
 
 func (f *FloatingIP) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *FloatingIP) error {
+	defer openstack.RecordRender("FloatingIP", fi.ValueOf(e.Name))()
+
 	cloud := t.Cloud
 
 	if a == nil {
-		external, err := cloud.GetExternalNetwork()
+		external, err := floatingNetwork(cloud, e)
 		if err != nil {
-			return fmt.Errorf("Failed to find external network: %v", err)
+			return err
 		}
 
 		opts := l3floatingip.CreateOpts{
@@ -285,10 +303,16 @@ func (f *FloatingIP) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, chan
 			return fmt.Errorf("Failed to find floatingip subnet: %v", err)
 		}
 		if lbSubnet != nil {
+			if e.FloatingNetwork != nil && lbSubnet.NetworkID != external.ID {
+				return fmt.Errorf("floating subnet %s is not part of floating network %s", lbSubnet.Name, fi.ValueOf(e.FloatingNetwork))
+			}
 			opts.SubnetID = lbSubnet.ID
 		}
 		fip, err := cloud.CreateL3FloatingIP(opts)
 		if err != nil {
+			if isFloatingNetworkExhausted(err) {
+				return fmt.Errorf("Failed to create floating IP: no more addresses available on floating network %s: %v", external.Name, err)
+			}
 			return fmt.Errorf("Failed to create floating IP: %v", err)
 		}
 
@@ -310,3 +334,31 @@ func (f *FloatingIP) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, chan
 	klog.V(2).Infof("Openstack task Instance::RenderOpenstack did nothing")
 	return nil
 }
+
+// floatingNetwork returns the external network the floating IP is allocated from
+func floatingNetwork(cloud openstack.OpenstackCloud, e *FloatingIP) (*networks.Network, error) {
+	if e.FloatingNetwork == nil {
+		external, err := cloud.GetExternalNetwork()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to find external network: %v", err)
+		}
+		return external, nil
+	}
+	external, err := cloud.FindExternalNetwork(fi.ValueOf(e.FloatingNetwork))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find floating network %s: %v", fi.ValueOf(e.FloatingNetwork), err)
+	}
+	if external == nil {
+		return nil, fmt.Errorf("floating network %s does not exist or is not an external network", fi.ValueOf(e.FloatingNetwork))
+	}
+	return external, nil
+}
+
+// isFloatingNetworkExhausted returns true if Neutron failed to create a floating IP because the external network has no free addresses
+func isFloatingNetworkExhausted(err error) bool {
+	var conflict gophercloud.ErrDefault409
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	return bytes.Contains(conflict.Body, []byte("IpAddressGenerationFailure"))
+}
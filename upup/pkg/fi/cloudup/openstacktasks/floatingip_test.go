@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/external"
+	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_FloatingIP_RenderOpenstack_FloatingNetwork(t *testing.T) {
+	tests := []struct {
+		desc            string
+		floatingNetwork *string
+		// expectedNetwork is the name of the network the floating IP is allocated from
+		expectedNetwork string
+		expectedError   error
+	}{
+		{
+			desc:            "defaults to the external network of the router",
+			expectedNetwork: "public",
+		},
+		{
+			desc:            "allocates from the named network",
+			floatingNetwork: fi.PtrTo("public-b"),
+			expectedNetwork: "public-b",
+		},
+		{
+			desc:            "missing network",
+			floatingNetwork: fi.PtrTo("public-c"),
+			expectedError:   fmt.Errorf("floating network public-c does not exist or is not an external network"),
+		},
+		{
+			desc:            "internal network",
+			floatingNetwork: fi.PtrTo("cluster"),
+			expectedError:   fmt.Errorf("floating network cluster does not exist or is not an external network"),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockNeutronClient: mocknetworking.CreateClient(),
+			}
+			cloud.SetExternalNetwork(fi.PtrTo("public"))
+			ids := make(map[string]string)
+			for _, name := range []string{"public", "public-b"} {
+				network, err := cloud.CreateNetwork(external.CreateOptsExt{
+					CreateOptsBuilder: networks.CreateOpts{Name: name},
+					External:          fi.PtrTo(true),
+				})
+				if err != nil {
+					t.Fatalf("error creating network: %v", err)
+				}
+				ids[network.ID] = name
+			}
+			if _, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"}); err != nil {
+				t.Fatalf("error creating network: %v", err)
+			}
+
+			e := &FloatingIP{
+				Name:            fi.PtrTo("fip-api.cluster"),
+				Lifecycle:       fi.LifecycleSync,
+				FloatingNetwork: testCase.floatingNetwork,
+			}
+			err := (&FloatingIP{}).RenderOpenstack(&openstack.OpenstackAPITarget{Cloud: cloud}, nil, e, nil)
+			compareErrors(t, err, testCase.expectedError)
+			if testCase.expectedError != nil {
+				return
+			}
+			fips, err := cloud.ListL3FloatingIPs(l3floatingip.ListOpts{})
+			if err != nil {
+				t.Fatalf("error listing floating IPs: %v", err)
+			}
+			if len(fips) != 1 {
+				t.Fatalf("expected one floating IP, got %d", len(fips))
+			}
+			if fip := fips[0]; ids[fip.FloatingNetworkID] != testCase.expectedNetwork {
+				t.Errorf("expected the floating IP to be allocated from %s, got %s", testCase.expectedNetwork, ids[fip.FloatingNetworkID])
+			}
+		})
+	}
+}
+
+func Test_IsFloatingNetworkExhausted(t *testing.T) {
+	tests := []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{
+			desc: "no more addresses",
+			err: fmt.Errorf("CreateL3FloatingIP: create L3 floating IP failed: %w", gophercloud.ErrDefault409{
+				ErrUnexpectedResponseCode: gophercloud.ErrUnexpectedResponseCode{
+					Body: []byte(`{"NeutronError": {"type": "IpAddressGenerationFailure", "message": "No more IP addresses available on network public."}}`),
+				},
+			}),
+			expected: true,
+		},
+		{
+			desc: "other conflict",
+			err: gophercloud.ErrDefault409{
+				ErrUnexpectedResponseCode: gophercloud.ErrUnexpectedResponseCode{
+					Body: []byte(`{"NeutronError": {"type": "FloatingIPPortAlreadyAssociated"}}`),
+				},
+			},
+		},
+		{
+			desc: "other error",
+			err:  fmt.Errorf("connection refused"),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			if actual := isFloatingNetworkExhausted(testCase.err); actual != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"encoding/json"
 	"fmt"
-	"strconv"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	cinder "github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumes"
 	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 
@@ -28,6 +32,7 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/bootfromvolume"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/schedulerhints"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/volumeattach"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/truncate"
@@ -56,6 +61,23 @@ type Instance struct {
 	ConfigDrive      *bool
 	Status           *string
 
+	// BootVolumeSizeGB is the size of the root volume when booting from volume, defaults to the minimum disk size of the image.
+	BootVolumeSizeGB *int64
+	// BootVolumeType is the Cinder volume type of the root volume when booting from volume.
+	BootVolumeType *string
+	// BootVolumeDeleteOnTermination deletes the root volume together with the server, defaults to true.
+	BootVolumeDeleteOnTermination *bool
+
+	// DataVolumes are additional volumes attached to the instance.
+	DataVolumes []*InstanceVolume
+
+	// SchedulerHints are additional scheduler hints, e.g. for placing the server on a host aggregate. They are only
+	// applied when the server is created. The server group and availability zone are set with ServerGroup and AvailabilityZone.
+	SchedulerHints map[string]string
+
+	// ServerTags are the Nova tags of the server, tags not in the list are removed from the server.
+	ServerTags []string
+
 	Lifecycle fi.Lifecycle
 
 	// WellKnownServices indicates which services are supported by this resource.
@@ -63,6 +85,15 @@ type Instance struct {
 	WellKnownServices []wellknownservices.WellKnownService
 }
 
+// InstanceVolume is an additional volume attached to an instance
+type InstanceVolume struct {
+	Volume *Volume
+	// Device is the requested device name, the hypervisor might not honor it.
+	Device string
+	// DeleteOnTermination deletes the volume together with the server.
+	DeleteOnTermination bool
+}
+
 var (
 	_ fi.CloudupTask            = &Instance{}
 	_ fi.HasAddress             = &Instance{}
@@ -78,6 +109,30 @@ var TRUNCATE_OPT = truncate.TruncateStringOptions{
 	HashLength:    6,
 }
 
+// kopsOwnedMetadata are the metadata keys kOps uses to identify and track its servers.
+// They are set when the server is created and never changed in place, a new server is
+// created by the rolling update instead.
+var kopsOwnedMetadata = []string{
+	"k8s",
+	openstack.TagClusterName,
+	openstack.TagKopsInstanceGroup,
+	openstack.TagKopsName,
+	openstack.TagKopsRole,
+	openstack.TagKopsNetwork,
+	openstack.INSTANCE_GROUP_GENERATION,
+	openstack.CLUSTER_GENERATION,
+	openstack.BOOT_FROM_VOLUME,
+	openstack.BOOT_VOLUME_SIZE,
+	openstack.MANAGED_METADATA,
+	openstack.SCHEDULER_HINTS,
+}
+
+// maxMetadataValueLength is the maximum length of a Nova metadata value
+const maxMetadataValueLength = 255
+
+// volumeTimeout is the time to wait for volumes to become available, creating the root volume from the image can take a while
+const volumeTimeout = 10 * time.Minute
+
 // GetDependencies returns the dependencies of the Instance task
 func (e *Instance) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 	var deps []fi.CloudupTask
@@ -91,6 +146,9 @@ func (e *Instance) GetDependencies(tasks map[string]fi.CloudupTask) []fi.Cloudup
 		if _, ok := task.(*FloatingIP); ok {
 			deps = append(deps, task)
 		}
+		if _, ok := task.(*Volume); ok {
+			deps = append(deps, task)
+		}
 	}
 
 	if e.UserData != nil {
@@ -133,7 +191,7 @@ func (e *Instance) FindAddresses(context *fi.CloudupContext) ([]string, error) {
 // filterInstancePorts tries to get all ports of an instance tagged with the cluster name.
 // If no tagged ports are found it will return all ports of the instance, to not change the legacy behavior when there weren't tagged ports
 func filterInstancePorts(allPorts []ports.Port, clusterName string) []ports.Port {
-	clusterNameTag := truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagClusterName, clusterName), TRUNCATE_OPT)
+	clusterNameTag := ClusterTag(clusterName)
 
 	var taggedPorts []ports.Port
 
@@ -153,12 +211,8 @@ func filterInstancePorts(allPorts []ports.Port, clusterName string) []ports.Port
 	return taggedPorts
 }
 
-func (e *Instance) Find(c *fi.CloudupContext) (*Instance, error) {
-	if e == nil || e.Name == nil {
-		return nil, nil
-	}
-	cloud := c.T.Cloud.(openstack.OpenstackCloud)
-
+// findServer returns the server of the instance, nil if it does not exist
+func findServer(cloud openstack.OpenstackCloud, e *Instance) (*servers.Server, error) {
 	serverList, err := cloud.ListInstances(servers.ListOpts{
 		Name: fmt.Sprintf("^%s", fi.ValueOf(e.GroupName)),
 	})
@@ -190,14 +244,26 @@ func (e *Instance) Find(c *fi.CloudupContext) (*Instance, error) {
 	if len(filteredList) > 1 {
 		return nil, fmt.Errorf("Multiple servers found with name %s", fi.ValueOf(e.Name))
 	}
+	return &filteredList[0], nil
+}
+
+func (e *Instance) Find(c *fi.CloudupContext) (*Instance, error) {
+	if e == nil || e.Name == nil {
+		return nil, nil
+	}
+	cloud := c.T.Cloud.(openstack.OpenstackCloud)
+
+	server, err := findServer(cloud, e)
+	if err != nil || server == nil {
+		return nil, err
+	}
 
-	server := filteredList[0]
 	actual := &Instance{
 		ID:               fi.PtrTo(server.ID),
 		Name:             e.Name,
 		SSHKey:           fi.PtrTo(server.KeyName),
 		Lifecycle:        e.Lifecycle,
-		Metadata:         server.Metadata,
+		Metadata:         managedInstanceMetadata(server.Metadata, e.Metadata),
 		Role:             fi.PtrTo(server.Metadata["KopsRole"]),
 		AvailabilityZone: e.AvailabilityZone,
 		GroupName:        e.GroupName,
@@ -247,6 +313,27 @@ func (e *Instance) Find(c *fi.CloudupContext) (*Instance, error) {
 		}
 	}
 
+	if e.DataVolumes != nil {
+		attached := make(map[string]bool)
+		for _, volume := range server.AttachedVolumes {
+			attached[volume.ID] = true
+		}
+		// only the expected volumes are compared, volumes attached out-of-band are kept
+		for _, volume := range e.DataVolumes {
+			if attached[fi.ValueOf(volume.Volume.ID)] {
+				actual.DataVolumes = append(actual.DataVolumes, volume)
+			}
+		}
+	}
+
+	if e.ServerTags != nil && server.Tags != nil {
+		actual.ServerTags = *server.Tags
+		// the order of the tags is not relevant
+		if sameElements(actual.ServerTags, e.ServerTags) {
+			actual.ServerTags = e.ServerTags
+		}
+	}
+
 	// Avoid flapping
 	e.ID = actual.ID
 	e.Status = fi.PtrTo(activeStatus)
@@ -259,10 +346,107 @@ func (e *Instance) Find(c *fi.CloudupContext) (*Instance, error) {
 	actual.Region = e.Region
 	actual.SSHKey = e.SSHKey
 	actual.ServerGroup = e.ServerGroup
+	actual.BootVolumeSizeGB = e.BootVolumeSizeGB
+	actual.BootVolumeType = e.BootVolumeType
+	actual.BootVolumeDeleteOnTermination = e.BootVolumeDeleteOnTermination
+
+	// the scheduler hints are not returned by Nova, they are recorded in the metadata when the server is created
+	actual.SchedulerHints = e.SchedulerHints
+	if v, ok := server.Metadata[openstack.SCHEDULER_HINTS]; ok {
+		var hints map[string]string
+		if err := json.Unmarshal([]byte(v), &hints); err != nil {
+			klog.Warningf("Ignoring invalid scheduler hints %q of server %s: %v", v, server.ID, err)
+		} else if len(hints) > 0 || len(e.SchedulerHints) > 0 {
+			actual.SchedulerHints = hints
+		}
+	}
 
 	return actual, nil
 }
 
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, tag := range a {
+		if !slices.Contains(b, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// managedInstanceMetadata returns the server metadata kOps is responsible for: the keys in
+// the expected metadata and the keys recorded as managed when the server was created or
+// last updated. Keys set by others are ignored so they are not removed.
+func managedInstanceMetadata(actual, expected map[string]string) map[string]string {
+	if actual == nil {
+		return nil
+	}
+	managed := make(map[string]bool)
+	for _, k := range strings.Split(actual[openstack.MANAGED_METADATA], ",") {
+		if k != "" {
+			managed[k] = true
+		}
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range actual {
+		if k == openstack.MANAGED_METADATA {
+			continue
+		}
+		if _, ok := expected[k]; ok || managed[k] {
+			metadata[k] = v
+		}
+	}
+	if len(metadata) == 0 && expected == nil {
+		return nil
+	}
+	return metadata
+}
+
+// withManagedMetadataKeys returns a copy of the metadata with the list of keys which are
+// managed by kOps, so removed keys can be deleted from the server later on.
+func withManagedMetadataKeys(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	var keys []string
+	result := make(map[string]string)
+	for k, v := range metadata {
+		result[k] = v
+		if !slices.Contains(kopsOwnedMetadata, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	managed := strings.Join(keys, ",")
+	if len(managed) > maxMetadataValueLength {
+		klog.Warningf("Too many metadata keys to track, keys removed later on will not be deleted from the server")
+	} else if managed != "" {
+		result[openstack.MANAGED_METADATA] = managed
+	}
+	return result
+}
+
+// withSchedulerHints records the scheduler hints in the metadata, so changed hints can be detected later on
+func withSchedulerHints(metadata map[string]string, hints map[string]string) map[string]string {
+	if len(hints) == 0 {
+		return metadata
+	}
+	b, err := json.Marshal(hints)
+	if err != nil || len(b) > maxMetadataValueLength {
+		klog.Warningf("Cannot record scheduler hints in the server metadata, changed hints will not be detected")
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata[openstack.SCHEDULER_HINTS] = string(b)
+	return metadata
+}
+
 func (e *Instance) Run(c *fi.CloudupContext) error {
 	return fi.CloudupDefaultDeltaRunMethod(e, c)
 }
@@ -279,10 +463,23 @@ func (_ *Instance) CheckChanges(a, e, changes *Instance) error {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
 		}
+		if changes.SchedulerHints != nil && !pendingReplacement(a, e) {
+			return fmt.Errorf("cannot change scheduler hints of Instance %s from %v to %v: scheduler hints are only applied when the server is created, replace the server with a forced rolling update to apply them", fi.ValueOf(e.Name), a.SchedulerHints, e.SchedulerHints)
+		}
+	}
+	if _, ok := e.SchedulerHints["group"]; ok {
+		return fmt.Errorf("scheduler hint \"group\" of Instance %s is set by the server group and cannot be overridden", fi.ValueOf(e.Name))
 	}
 	return nil
 }
 
+// pendingReplacement returns true if the instance group changed after the server was created, the server will be
+// replaced by the rolling update.
+func pendingReplacement(a, e *Instance) bool {
+	generation, ok := e.Metadata[openstack.INSTANCE_GROUP_GENERATION]
+	return ok && a.Metadata[openstack.INSTANCE_GROUP_GENERATION] != generation
+}
+
 func (_ *Instance) ShouldCreate(a, e, changes *Instance) (bool, error) {
 	if a == nil {
 		return true, nil
@@ -317,6 +514,8 @@ func generateInstanceName(e *Instance) (string, error) {
 }
 
 func (_ *Instance) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Instance) error {
+	defer openstack.RecordRender("Instance", fi.ValueOf(e.Name))()
+
 	cloud := t.Cloud
 
 	if a != nil && fi.ValueOf(a.Status) == errorStatus {
@@ -352,7 +551,7 @@ func (_ *Instance) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, change
 					Port: fi.ValueOf(e.Port.ID),
 				},
 			},
-			Metadata:       e.Metadata,
+			Metadata:       withSchedulerHints(withManagedMetadataKeys(e.Metadata), e.SchedulerHints),
 			SecurityGroups: e.SecurityGroups,
 			ConfigDrive:    e.ConfigDrive,
 		}
@@ -366,29 +565,52 @@ func (_ *Instance) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, change
 		if e.AvailabilityZone != nil {
 			opt.AvailabilityZone = fi.ValueOf(e.AvailabilityZone)
 		}
+		if bootFromVolume(e.Metadata) {
+			// the image is taken from the root volume
+			opt.ImageRef = ""
+		}
 		keyext := keypairs.CreateOptsExt{
 			CreateOptsBuilder: opt,
 			KeyName:           openstackKeyPairName(fi.ValueOf(e.SSHKey)),
 		}
 
+		hints := &schedulerhints.SchedulerHints{
+			Group: *e.ServerGroup.ID,
+		}
+		if len(e.SchedulerHints) > 0 {
+			hints.AdditionalProperties = make(map[string]interface{})
+			for k, v := range e.SchedulerHints {
+				hints.AdditionalProperties[k] = v
+			}
+		}
 		sgext := schedulerhints.CreateOptsExt{
 			CreateOptsBuilder: keyext,
-			SchedulerHints: &schedulerhints.SchedulerHints{
-				Group: *e.ServerGroup.ID,
-			},
+			SchedulerHints:    hints,
 		}
 
-		opts, err := includeBootVolumeOptions(t, e, sgext)
+		opts, bootVolumeID, err := includeBlockDeviceOptions(t, e, serverName, sgext)
 		if err != nil {
 			return err
 		}
 
 		v, err := t.Cloud.CreateInstance(opts, fi.ValueOf(e.Port.ID))
 		if err != nil {
+			if bootVolumeID != "" {
+				if err := t.Cloud.DeleteVolume(bootVolumeID); err != nil {
+					klog.Warningf("Failed to delete root volume %s of failed server %s: %v", bootVolumeID, serverName, err)
+				}
+			}
 			return fmt.Errorf("Error creating instance: %v", err)
 		}
 		e.ID = fi.PtrTo(v.ID)
 
+		// the compute API version in use does not support tags in the create request, they are added afterwards
+		for _, tag := range e.ServerTags {
+			if err := t.Cloud.AddInstanceTag(v.ID, tag); err != nil {
+				return err
+			}
+		}
+
 		if e.FloatingIP != nil {
 			err = associateFloatingIP(t, e)
 			if err != nil {
@@ -414,9 +636,112 @@ func (_ *Instance) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, change
 			return err
 		}
 	}
+	if changes.DataVolumes != nil {
+		err := attachMissingVolumes(t, a, e)
+		if err != nil {
+			return err
+		}
+	}
+	if changes.Metadata != nil {
+		err := reconcileInstanceMetadata(t, a, e)
+		if err != nil {
+			return err
+		}
+	}
+	if changes.SchedulerHints != nil {
+		klog.Infof("Scheduler hints of Instance %q changed, they will be applied when the server is replaced", fi.ValueOf(e.Name))
+	}
+	if changes.ServerTags != nil {
+		err := reconcileServerTags(t, a, e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileServerTags adds the missing tags to an existing server and removes the tags which are not expected
+func reconcileServerTags(t *openstack.OpenstackAPITarget, a, e *Instance) error {
+	id := fi.ValueOf(a.ID)
+	for _, tag := range a.ServerTags {
+		if slices.Contains(e.ServerTags, tag) {
+			continue
+		}
+		klog.V(2).Infof("Deleting tag %q from Instance %q", tag, fi.ValueOf(e.Name))
+		if err := t.Cloud.DeleteInstanceTag(id, tag); err != nil {
+			return err
+		}
+	}
+	for _, tag := range e.ServerTags {
+		if slices.Contains(a.ServerTags, tag) {
+			continue
+		}
+		klog.V(2).Infof("Adding tag %q to Instance %q", tag, fi.ValueOf(e.Name))
+		if err := t.Cloud.AddInstanceTag(id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachMissingVolumes attaches the expected data volumes which are not attached to the server yet
+func attachMissingVolumes(t *openstack.OpenstackAPITarget, a, e *Instance) error {
+	attached := make(map[*InstanceVolume]bool)
+	for _, volume := range a.DataVolumes {
+		attached[volume] = true
+	}
+	for _, volume := range e.DataVolumes {
+		if attached[volume] {
+			continue
+		}
+		klog.V(2).Infof("Attaching Volume %q to Instance %q", fi.ValueOf(volume.Volume.Name), fi.ValueOf(e.Name))
+		_, err := t.Cloud.AttachVolume(fi.ValueOf(a.ID), volumeattach.CreateOpts{
+			VolumeID: fi.ValueOf(volume.Volume.ID),
+			Device:   volume.Device,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// reconcileInstanceMetadata updates the metadata managed by kOps on an existing server,
+// the metadata owned by kOps is left untouched.
+func reconcileInstanceMetadata(t *openstack.OpenstackAPITarget, a, e *Instance) error {
+	id := fi.ValueOf(a.ID)
+	changed := false
+
+	for k := range a.Metadata {
+		if _, ok := e.Metadata[k]; ok || slices.Contains(kopsOwnedMetadata, k) {
+			continue
+		}
+		klog.V(2).Infof("Deleting metadata %q from Instance %q", k, fi.ValueOf(e.Name))
+		if err := t.Cloud.DeleteInstanceMetadata(id, k); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	updated := make(map[string]string)
+	for k, v := range e.Metadata {
+		if slices.Contains(kopsOwnedMetadata, k) {
+			continue
+		}
+		if actual, ok := a.Metadata[k]; !ok || actual != v {
+			updated[k] = v
+		}
+	}
+	if len(updated) == 0 && !changed {
+		return nil
+	}
+	if managed, ok := withManagedMetadataKeys(e.Metadata)[openstack.MANAGED_METADATA]; ok {
+		updated[openstack.MANAGED_METADATA] = managed
+	}
+	klog.V(2).Infof("Updating metadata of Instance %q", fi.ValueOf(e.Name))
+	return t.Cloud.UpdateInstanceMetadata(id, updated)
+}
+
 func associateFloatingIP(t *openstack.OpenstackAPITarget, e *Instance) error {
 	client := t.Cloud.NetworkingClient()
 
@@ -429,38 +754,118 @@ func associateFloatingIP(t *openstack.OpenstackAPITarget, e *Instance) error {
 	return nil
 }
 
-func includeBootVolumeOptions(t *openstack.OpenstackAPITarget, e *Instance, opts servers.CreateOptsBuilder) (servers.CreateOptsBuilder, error) {
-	if !bootFromVolume(e.Metadata) {
-		return opts, nil
+// includeBlockDeviceOptions adds the root volume when booting from volume and the data volumes to the server create options.
+// It returns the ID of the root volume if it was created.
+func includeBlockDeviceOptions(t *openstack.OpenstackAPITarget, e *Instance, serverName string, opts servers.CreateOptsBuilder) (servers.CreateOptsBuilder, string, error) {
+	var blockDevices []bootfromvolume.BlockDevice
+	var bootVolumeID string
+	if bootFromVolume(e.Metadata) {
+		volume, err := createBootVolume(t, e, serverName)
+		if err != nil {
+			return nil, "", err
+		}
+		bootVolumeID = volume.ID
+		blockDevices = append(blockDevices, bootfromvolume.BlockDevice{
+			BootIndex:           0,
+			DeleteOnTermination: e.BootVolumeDeleteOnTermination == nil || fi.ValueOf(e.BootVolumeDeleteOnTermination),
+			DestinationType:     bootfromvolume.DestinationVolume,
+			SourceType:          bootfromvolume.SourceVolume,
+			UUID:                volume.ID,
+		})
 	}
 
+	deviceNames := make(map[string]string)
+	for _, volume := range e.DataVolumes {
+		id := fi.ValueOf(volume.Volume.ID)
+		// the volumes are created by the Volume tasks, but might not be available yet
+		if err := cinder.WaitForStatus(t.Cloud.BlockStorageClient(), id, "available", int(volumeTimeout.Seconds())); err != nil {
+			return nil, bootVolumeID, fmt.Errorf("Error waiting for volume %s to become available: %v", id, err)
+		}
+		blockDevices = append(blockDevices, bootfromvolume.BlockDevice{
+			BootIndex:           -1,
+			DeleteOnTermination: volume.DeleteOnTermination,
+			DestinationType:     bootfromvolume.DestinationVolume,
+			SourceType:          bootfromvolume.SourceVolume,
+			UUID:                id,
+		})
+		if volume.Device != "" {
+			deviceNames[id] = volume.Device
+		}
+	}
+
+	if len(blockDevices) == 0 {
+		return opts, "", nil
+	}
+	return blockDeviceNamesExt{
+		CreateOptsBuilder: bootfromvolume.CreateOptsExt{
+			CreateOptsBuilder: opts,
+			BlockDevice:       blockDevices,
+		},
+		DeviceNames: deviceNames,
+	}, bootVolumeID, nil
+}
+
+// createBootVolume creates the root volume from the image. The volume is created in Cinder, as the compute API version
+// in use does not support setting the volume type, and tagged with the cluster so it can be cleaned up if it is kept
+// after the server is deleted.
+func createBootVolume(t *openstack.OpenstackAPITarget, e *Instance, serverName string) (*cinder.Volume, error) {
 	i, err := t.Cloud.GetImage(fi.ValueOf(e.Image))
 	if err != nil {
 		return nil, fmt.Errorf("Error getting image information: %v", err)
 	}
 
-	bfv := bootfromvolume.CreateOptsExt{
-		CreateOptsBuilder: opts,
-		BlockDevice: []bootfromvolume.BlockDevice{{
-			BootIndex:           0,
-			DeleteOnTermination: true,
-			DestinationType:     "volume",
-			SourceType:          "image",
-			UUID:                i.ID,
-			VolumeSize:          i.MinDiskGigabytes,
-		}},
+	size := int64(i.MinDiskGigabytes)
+	if i.SizeBytes > size<<30 {
+		size = (i.SizeBytes + 1<<30 - 1) >> 30
+	}
+	if e.BootVolumeSizeGB != nil {
+		size = fi.ValueOf(e.BootVolumeSizeGB)
 	}
 
-	if s, ok := e.Metadata[openstack.BOOT_VOLUME_SIZE]; ok {
-		i, err := strconv.ParseInt(s, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("Invalid value for %v: %v", openstack.BOOT_VOLUME_SIZE, err)
-		}
+	volume, err := t.Cloud.CreateVolume(cinder.CreateOpts{
+		Name:       serverName,
+		Size:       int(size),
+		VolumeType: fi.ValueOf(e.BootVolumeType),
+		ImageID:    i.ID,
+		Metadata: map[string]string{
+			openstack.TagClusterName:       fi.ValueOf(e.ServerGroup.ClusterName),
+			openstack.TagKopsInstanceGroup: fi.ValueOf(e.GroupName),
+			openstack.TagKopsName:          fi.ValueOf(e.Name),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error creating root volume for instance %s: %v", serverName, err)
+	}
 
-		bfv.BlockDevice[0].VolumeSize = int(i)
+	if err := cinder.WaitForStatus(t.Cloud.BlockStorageClient(), volume.ID, "available", int(volumeTimeout.Seconds())); err != nil {
+		if err := t.Cloud.DeleteVolume(volume.ID); err != nil {
+			klog.Warningf("Failed to delete root volume %s: %v", volume.ID, err)
+		}
+		return nil, fmt.Errorf("Error waiting for root volume %s to become available: %v", volume.ID, err)
 	}
+	return volume, nil
+}
+
+// blockDeviceNamesExt sets the device names of the block device mappings, which are not supported by bootfromvolume.BlockDevice
+type blockDeviceNamesExt struct {
+	servers.CreateOptsBuilder
+	// DeviceNames maps volume IDs to device names
+	DeviceNames map[string]string
+}
 
-	return bfv, nil
+func (opts blockDeviceNamesExt) ToServerCreateMap() (map[string]interface{}, error) {
+	base, err := opts.CreateOptsBuilder.ToServerCreateMap()
+	if err != nil {
+		return nil, err
+	}
+	serverMap := base["server"].(map[string]interface{})
+	blockDevices, _ := serverMap["block_device_mapping_v2"].([]map[string]interface{})
+	for _, blockDevice := range blockDevices {
+		if device, ok := opts.DeviceNames[fmt.Sprintf("%v", blockDevice["uuid"])]; ok {
+			blockDevice["device_name"] = device
+		}
+	}
+	return base, nil
 }
 
 func bootFromVolume(m map[string]string) bool {
@@ -23,6 +23,7 @@ import (
 
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 	"k8s.io/kops/pkg/truncate"
+	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 )
 
@@ -136,3 +137,131 @@ func TestFilterPortsReturnsOnlyTaggedPortsWithLongClustername(t *testing.T) {
 		t.Fatalf("expected '%+v', but got '%+v", expectedPorts, actualPorts)
 	}
 }
+
+func TestManagedInstanceMetadataIgnoresExternalKeys(t *testing.T) {
+	actual := map[string]string{
+		openstack.MANAGED_METADATA: "removed,team",
+		openstack.TagKopsName:      "node-1",
+		"team":                     "a",
+		"removed":                  "b",
+		"set-by-someone-else":      "c",
+	}
+	expected := map[string]string{
+		openstack.TagKopsName: "node-1",
+		"team":                "a",
+	}
+
+	metadata := managedInstanceMetadata(actual, expected)
+
+	wanted := map[string]string{
+		openstack.TagKopsName: "node-1",
+		"team":                "a",
+		"removed":             "b",
+	}
+	if !reflect.DeepEqual(wanted, metadata) {
+		t.Fatalf("expected '%+v', but got '%+v", wanted, metadata)
+	}
+}
+
+func TestWithManagedMetadataKeysSkipsKopsOwnedKeys(t *testing.T) {
+	metadata := map[string]string{
+		openstack.TagKopsName:               "node-1",
+		openstack.INSTANCE_GROUP_GENERATION: "1",
+		"team":                              "a",
+		"cost-center":                       "b",
+	}
+
+	result := withManagedMetadataKeys(metadata)
+
+	if result[openstack.MANAGED_METADATA] != "cost-center,team" {
+		t.Fatalf("expected managed keys 'cost-center,team', but got '%s'", result[openstack.MANAGED_METADATA])
+	}
+	if _, ok := metadata[openstack.MANAGED_METADATA]; ok {
+		t.Fatalf("expected metadata not to be modified")
+	}
+}
+
+func TestSameElementsIgnoresOrder(t *testing.T) {
+	if !sameElements([]string{"a", "b"}, []string{"b", "a"}) {
+		t.Fatalf("expected tags in different order to be the same")
+	}
+	if sameElements([]string{"a", "b"}, []string{"a"}) {
+		t.Fatalf("expected removed tag to be detected")
+	}
+	if sameElements([]string{"a", "b"}, []string{"a", "c"}) {
+		t.Fatalf("expected changed tag to be detected")
+	}
+}
+
+func Test_Instance_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *Instance
+		expected      *Instance
+		changes       *Instance
+		expectedError error
+	}{
+		{
+			desc:   "actual nil with scheduler hints",
+			actual: nil,
+			expected: &Instance{
+				Name:           fi.PtrTo("node-1"),
+				SchedulerHints: map[string]string{"aggregate": "gpu"},
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil with group scheduler hint",
+			actual: nil,
+			expected: &Instance{
+				Name:           fi.PtrTo("node-1"),
+				SchedulerHints: map[string]string{"group": "id"},
+			},
+			expectedError: fmt.Errorf("scheduler hint \"group\" of Instance node-1 is set by the server group and cannot be overridden"),
+		},
+		{
+			desc: "actual not nil scheduler hints changed",
+			actual: &Instance{
+				Name:           fi.PtrTo("node-1"),
+				Metadata:       map[string]string{openstack.INSTANCE_GROUP_GENERATION: "1"},
+				SchedulerHints: map[string]string{"aggregate": "gpu"},
+			},
+			expected: &Instance{
+				Name:           fi.PtrTo("node-1"),
+				Metadata:       map[string]string{openstack.INSTANCE_GROUP_GENERATION: "1"},
+				SchedulerHints: map[string]string{"aggregate": "cpu"},
+			},
+			changes: &Instance{
+				SchedulerHints: map[string]string{"aggregate": "cpu"},
+			},
+			expectedError: fmt.Errorf("cannot change scheduler hints of Instance node-1 from map[aggregate:gpu] to map[aggregate:cpu]: scheduler hints are only applied when the server is created, replace the server with a forced rolling update to apply them"),
+		},
+		{
+			desc: "actual not nil scheduler hints changed with instance group",
+			actual: &Instance{
+				Name:           fi.PtrTo("node-1"),
+				Metadata:       map[string]string{openstack.INSTANCE_GROUP_GENERATION: "1"},
+				SchedulerHints: map[string]string{"aggregate": "gpu"},
+			},
+			expected: &Instance{
+				Name:           fi.PtrTo("node-1"),
+				Metadata:       map[string]string{openstack.INSTANCE_GROUP_GENERATION: "2"},
+				SchedulerHints: map[string]string{"aggregate": "cpu"},
+			},
+			changes: &Instance{
+				Metadata:       map[string]string{openstack.INSTANCE_GROUP_GENERATION: "2"},
+				SchedulerHints: map[string]string{"aggregate": "cpu"},
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var instance Instance
+			err := (&instance).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
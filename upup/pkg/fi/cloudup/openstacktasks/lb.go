@@ -17,18 +17,23 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 
-	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/util/pkg/vfs"
 )
 
 // +kops:fitask
@@ -42,6 +47,43 @@ type LB struct {
 	SecurityGroup *SecurityGroup
 	Provider      *string
 	FlavorID      *string
+	// PreviousName opts in to renaming an existing loadbalancer with this name to Name, instead of creating a new
+	// loadbalancer and VIP. Listeners and pools are still matched by their own names.
+	PreviousName *string
+	// PortSecurityGroups are the names of the security groups of the VIP port, they are derived from SecurityGroup
+	// so that changes of the port security groups are shown in the plan
+	PortSecurityGroups []string
+	// VipQosPolicy is the name or ID of the Neutron QoS policy of the VIP port
+	VipQosPolicy *string
+	// SkipActiveWait does not block while the loadbalancer is provisioned, tasks that need an ACTIVE loadbalancer
+	// try again later instead
+	SkipActiveWait *bool
+	// ManagePortSecurityGroups removes all security groups from the VIP port if SecurityGroup is not set, by default
+	// only the security group of the loadbalancer is removed and groups attached by others are kept
+	ManagePortSecurityGroups *bool
+	// Tags are the tags of the loadbalancer, tags added by others are kept
+	Tags []string
+	// Network is the network of the cluster, the VIP subnet must belong to it so that the members can be reached
+	Network *Network
+	// AdminStateUp is the administrative state of the loadbalancer, the VIP does not accept connections if it is false
+	AdminStateUp *bool
+	// RecreateOnImmutableChange deletes and creates the loadbalancer again if its VIP subnet, provider or flavor
+	// change, by default these changes are rejected. The floating IP of the VIP is kept.
+	RecreateOnImmutableChange *bool
+	// VipPortID is the ID of an existing Neutron port that is used as the VIP port instead of letting Octavia create
+	// one. The port is owned by the user, its security groups are only changed if ManagePortSecurityGroups is set.
+	VipPortID *string
+	// VipPortTags are the tags of the VIP port created by Octavia, e.g. for tooling that selects ports by tag. Tags
+	// added by others are kept. The port is still used if it cannot be tagged.
+	VipPortTags []string
+	// CreatedAt and UpdatedAt are the times the loadbalancer was created and last changed, they are only read from
+	// the cloud and never reported as changes
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+
+	// flavorError is the result of checking FlavorID against Provider in Find, CheckChanges returns it so that a
+	// flavor of another provider fails the plan instead of the create
+	flavorError error
 }
 
 const (
@@ -52,21 +94,31 @@ const (
 	loadbalancerActiveInitDelay = 1 * time.Second
 	loadbalancerActiveFactor    = 1.2
 	loadbalancerActiveSteps     = 22
+	// loadbalancerActiveJitter adds up to 10% to each delay, so that loadbalancers created at the same time do not
+	// poll Octavia at the same time. The timeout is at most 10% longer.
+	loadbalancerActiveJitter = 0.1
 
 	activeStatus = "ACTIVE"
 	errorStatus  = "ERROR"
 )
 
-func waitLoadbalancerActiveProvisioningStatus(client *gophercloud.ServiceClient, loadbalancerID string) (string, error) {
-	backoff := wait.Backoff{
-		Duration: loadbalancerActiveInitDelay,
-		Factor:   loadbalancerActiveFactor,
-		Steps:    loadbalancerActiveSteps,
-	}
+// loadbalancerActiveBackoff is the backoff for waiting for the ACTIVE loadbalancer provisioning status
+var loadbalancerActiveBackoff = wait.Backoff{
+	Duration: loadbalancerActiveInitDelay,
+	Factor:   loadbalancerActiveFactor,
+	Steps:    loadbalancerActiveSteps,
+	Jitter:   loadbalancerActiveJitter,
+}
 
+// loadbalancerGetter fetches a loadbalancer by ID, e.g. OpenstackCloud.GetLB
+type loadbalancerGetter func(loadbalancerID string) (*loadbalancers.LoadBalancer, error)
+
+// waitLoadbalancerActiveProvisioningStatus polls the loadbalancer until it is ACTIVE. Errors fetching the loadbalancer
+// are returned immediately, transient errors are expected to be retried by the getter.
+func waitLoadbalancerActiveProvisioningStatus(getLB loadbalancerGetter, loadbalancerID string, backoff wait.Backoff) (string, error) {
 	var provisioningStatus string
 	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
-		loadbalancer, err := loadbalancers.Get(client, loadbalancerID).Extract()
+		loadbalancer, err := getLB(loadbalancerID)
 		if err != nil {
 			return false, err
 		}
@@ -76,7 +128,7 @@ func waitLoadbalancerActiveProvisioningStatus(client *gophercloud.ServiceClient,
 		} else if loadbalancer.ProvisioningStatus == errorStatus {
 			return true, fmt.Errorf("loadbalancer has gone into ERROR state")
 		} else {
-			klog.Infof("Waiting for Loadbalancer to be ACTIVE...")
+			klog.InfoS("Waiting for loadbalancer to be ACTIVE", "lbID", loadbalancerID, "provisioningStatus", loadbalancer.ProvisioningStatus)
 			return false, nil
 		}
 	})
@@ -87,6 +139,119 @@ func waitLoadbalancerActiveProvisioningStatus(client *gophercloud.ServiceClient,
 	return provisioningStatus, err
 }
 
+// waitLoadbalancerActive waits for the ACTIVE provisioning status of the loadbalancer. If the loadbalancer skips the
+// wait, the status is only checked once and a TryAgainLaterError is returned while it is provisioned.
+func waitLoadbalancerActive(getLB loadbalancerGetter, lb *LB) error {
+	loadbalancerID := fi.ValueOf(lb.ID)
+	if fi.ValueOf(lb.SkipActiveWait) {
+		loadbalancer, err := getLB(loadbalancerID)
+		if err != nil {
+			return fmt.Errorf("failed to get loadbalancer %s: %v", loadbalancerID, err)
+		}
+		switch loadbalancer.ProvisioningStatus {
+		case activeStatus:
+			return nil
+		case errorStatus:
+			return fmt.Errorf("loadbalancer has gone into ERROR state")
+		default:
+			return fi.NewTryAgainLaterError(fmt.Sprintf("waiting for loadbalancer %s to be ACTIVE, provisioning status is %s", loadbalancerID, loadbalancer.ProvisioningStatus))
+		}
+	}
+
+	provisioningStatus, err := waitLoadbalancerActiveProvisioningStatus(getLB, loadbalancerID, loadbalancerActiveBackoff)
+	if err != nil {
+		return fmt.Errorf("failed to loadbalancer ACTIVE provisioning status %v: %v", provisioningStatus, err)
+	}
+	return nil
+}
+
+// vipPortBackoff is the backoff for waiting for the VIP port of a new loadbalancer to be gettable
+var vipPortBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   1.5,
+	Steps:    6,
+}
+
+// portGetter fetches a port by ID, e.g. ports.Get
+type portGetter func(portID string) (*ports.Port, error)
+
+// waitForPort polls until the port can be fetched, the VIP port of a new loadbalancer may not be found right away.
+// Errors other than not found are returned immediately.
+func waitForPort(getPort portGetter, portID string, backoff wait.Backoff) error {
+	done, err := vfs.RetryWithBackoff(backoff, func() (bool, error) {
+		_, err := getPort(portID)
+		if err == nil {
+			return true, nil
+		}
+		if openstack.IsNotFound(err) {
+			klog.V(lbLogLevel).InfoS("Waiting for port to exist", "portID", portID)
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get port %s: %w", portID, err)
+	} else if !done {
+		return fmt.Errorf("port %s not found within allotted time", portID)
+	}
+	return nil
+}
+
+// secureVIPPort waits for the VIP port of a new loadbalancer and sets the security group as its only security group
+func secureVIPPort(cloud openstack.OpenstackCloud, portID string, securityGroup *SecurityGroup) error {
+	getPort := func(portID string) (*ports.Port, error) {
+		return ports.Get(cloud.NetworkingClient(), portID).Extract()
+	}
+	if err := waitForPort(getPort, portID, vipPortBackoff); err != nil {
+		return err
+	}
+
+	opts := ports.UpdateOpts{
+		SecurityGroups: &[]string{fi.ValueOf(securityGroup.ID)},
+	}
+	if _, err := ports.Update(cloud.NetworkingClient(), portID, opts).Extract(); err != nil {
+		return fmt.Errorf("Failed to update security group for port %s: %v", portID, err)
+	}
+	return nil
+}
+
+// managesPortSecurityGroups returns true if kOps sets the security groups of the VIP port, the security groups of a
+// VIP port created by the user are only managed on request
+func (e *LB) managesPortSecurityGroups() bool {
+	return e.VipPortID == nil || fi.ValueOf(e.ManagePortSecurityGroups)
+}
+
+// isSharedLifecycle returns true if the loadbalancer is managed outside of kOps and is only referenced by the task
+func isSharedLifecycle(lifecycle fi.Lifecycle) bool {
+	return lifecycle == fi.LifecycleExistsAndWarnIfChanges || lifecycle == fi.LifecycleExistsAndValidates
+}
+
+// isLoadbalancer returns true if the task is the given loadbalancer. Loadbalancer, pool and listener tasks only depend
+// on the tasks of their own loadbalancer, so independent loadbalancers are created and waited for concurrently.
+func isLoadbalancer(task fi.CloudupTask, lb *LB) bool {
+	t, ok := task.(*LB)
+	return ok && lb != nil && fi.ValueOf(t.Name) == fi.ValueOf(lb.Name)
+}
+
+// isPool returns true if the task is the given pool
+func isPool(task fi.CloudupTask, pool *LBPool) bool {
+	t, ok := task.(*LBPool)
+	return ok && pool != nil && fi.ValueOf(t.Name) == fi.ValueOf(pool.Name)
+}
+
+// planParent returns the task matching the predicate with the lowest key, it is the parent of a task in the dry-run
+// report
+func planParent(tasks map[string]fi.CloudupTask, match func(task fi.CloudupTask) bool) fi.CloudupTask {
+	var parentKey string
+	var parent fi.CloudupTask
+	for key, task := range tasks {
+		if match(task) && (parent == nil || key < parentKey) {
+			parentKey, parent = key, task
+		}
+	}
+	return parent
+}
+
 // GetDependencies returns the dependencies of the Instance task
 func (e *LB) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 	var deps []fi.CloudupTask
@@ -97,21 +262,102 @@ func (e *LB) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 		if _, ok := task.(*SecurityGroup); ok {
 			deps = append(deps, task)
 		}
+		if _, ok := task.(*Network); ok {
+			deps = append(deps, task)
+		}
 	}
 	return deps
 }
 
+// checkVIPSubnetNetwork returns an error if the VIP subnet does not belong to the network of the cluster
+func checkVIPSubnetNetwork(subnet *subnets.Subnet, network *Network) error {
+	if network == nil || network.ID == nil || subnet.NetworkID == fi.ValueOf(network.ID) {
+		return nil
+	}
+	return fmt.Errorf("VIP subnet %q (%s) belongs to network %s, not to the cluster network %q (%s), the loadbalancer could not reach its members",
+		subnet.Name, subnet.ID, subnet.NetworkID, fi.ValueOf(network.Name), fi.ValueOf(network.ID))
+}
+
+// checkVIPPortNetwork returns an error if the VIP port created by the user is not part of the network of the cluster
+func checkVIPPortNetwork(port *ports.Port, network *Network) error {
+	if network == nil || network.ID == nil || port.NetworkID == fi.ValueOf(network.ID) {
+		return nil
+	}
+	return fmt.Errorf("VIP port %q (%s) belongs to network %s, not to the cluster network %q (%s), the loadbalancer could not reach its members",
+		port.Name, port.ID, port.NetworkID, fi.ValueOf(network.Name), fi.ValueOf(network.ID))
+}
+
 var _ fi.CompareWithID = &LB{}
 
+// lbLogLevel is the verbosity of the informational logs of the LB task. The logs are structured, the loadbalancer is
+// identified by the lb, lbID and cluster keys so that its events can be filtered; -vmodule=lb=2 enables them on their own.
+const lbLogLevel = 2
+
+// logValues returns the key/value pairs identifying the loadbalancer of the task in structured logs, followed by the
+// given pairs
+func (e *LB) logValues(keysAndValues ...interface{}) []interface{} {
+	kv := []interface{}{"task", "LB/" + fi.ValueOf(e.Name), "lb", fi.ValueOf(e.Name)}
+	if e.ID != nil {
+		kv = append(kv, "lbID", fi.ValueOf(e.ID))
+	}
+	if cluster := clusterNameFromTags(e.Tags); cluster != "" {
+		kv = append(kv, "cluster", cluster)
+	}
+	return append(kv, keysAndValues...)
+}
+
+// loadbalancerLogValues returns the key/value pairs identifying a loadbalancer of the cloud in structured logs,
+// followed by the given pairs
+func loadbalancerLogValues(lb *loadbalancers.LoadBalancer, keysAndValues ...interface{}) []interface{} {
+	kv := []interface{}{"lb", lb.Name, "lbID", lb.ID, "provider", lb.Provider, "provisioningStatus", lb.ProvisioningStatus}
+	if cluster := clusterNameFromTags(lb.Tags); cluster != "" {
+		kv = append(kv, "cluster", cluster)
+	}
+	return append(kv, keysAndValues...)
+}
+
 func (s *LB) CompareWithID() *string {
 	return s.ID
 }
 
-func NewLBTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, lb *loadbalancers.LoadBalancer, find *LB) (*LB, error) {
+// lbLookupTimeout bounds the lookups of the subnet, the VIP port, the security groups and the QoS policy of a
+// loadbalancer, so that a slow Neutron cannot stall the apply
+var lbLookupTimeout = 2 * time.Minute
+
+// taskContext returns the context of the apply, the context is not set if the cloudup context is built by a test
+func taskContext(c *fi.CloudupContext) context.Context {
+	if ctx := c.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// lbTime returns the timestamp of the loadbalancer, nil if it is not reported
+func lbTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func NewLBTaskFromCloud(ctx context.Context, cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, lb *loadbalancers.LoadBalancer, find *LB) (*LB, error) {
 	osCloud := cloud
-	sub, err := subnets.Get(osCloud.NetworkingClient(), lb.VipSubnetID).Extract()
-	if err != nil {
-		return nil, err
+	ctx, cancel := context.WithTimeout(ctx, lbLookupTimeout)
+	defer cancel()
+
+	// the VIP subnet may have been deleted, the loadbalancer is still reported so that it can be reconciled
+	var subnetName *string
+	if lb.VipSubnetID == "" {
+		klog.InfoS("Loadbalancer does not report its VIP subnet", loadbalancerLogValues(lb)...)
+	} else if sub, err := openstack.RunWithContext(ctx, func() (*subnets.Subnet, error) {
+		return subnets.Get(osCloud.NetworkingClient(), lb.VipSubnetID).Extract()
+	}); err != nil {
+		if !openstack.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get subnet %s of loadbalancer %s: %w", lb.VipSubnetID, lb.Name, err)
+		}
+		klog.InfoS("VIP subnet of loadbalancer not found", loadbalancerLogValues(lb, "subnetID", lb.VipSubnetID)...)
+	} else {
+		subnetName = fi.PtrTo(sub.Name)
 	}
 
 	secGroup := true
@@ -120,29 +366,116 @@ func NewLBTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle,
 	}
 
 	actual := &LB{
-		ID:        fi.PtrTo(lb.ID),
-		Name:      fi.PtrTo(lb.Name),
-		Lifecycle: lifecycle,
-		PortID:    fi.PtrTo(lb.VipPortID),
-		Subnet:    fi.PtrTo(sub.Name),
-		VipSubnet: fi.PtrTo(lb.VipSubnetID),
-		Provider:  fi.PtrTo(lb.Provider),
-		FlavorID:  fi.PtrTo(lb.FlavorID),
+		ID:           fi.PtrTo(lb.ID),
+		Name:         fi.PtrTo(lb.Name),
+		Lifecycle:    lifecycle,
+		PortID:       fi.PtrTo(lb.VipPortID),
+		Subnet:       subnetName,
+		VipSubnet:    fi.PtrTo(lb.VipSubnetID),
+		Provider:     fi.PtrTo(lb.Provider),
+		FlavorID:     fi.PtrTo(lb.FlavorID),
+		Tags:         lb.Tags,
+		AdminStateUp: fi.PtrTo(lb.AdminStateUp),
+		CreatedAt:    lbTime(lb.CreatedAt),
+		UpdatedAt:    lbTime(lb.UpdatedAt),
 	}
 
 	if secGroup {
-		sg, err := getSecurityGroupByName(&SecurityGroup{Name: fi.PtrTo(lb.Name)}, osCloud)
+		// an adopted loadbalancer may be named differently than its security group
+		secGroupName := lb.Name
+		if find != nil && find.SecurityGroup.Name != nil {
+			secGroupName = fi.ValueOf(find.SecurityGroup.Name)
+		}
+		sg, err := getSecurityGroupByName(ctx, &SecurityGroup{Name: fi.PtrTo(secGroupName)}, osCloud)
 		if err != nil {
 			return nil, err
 		}
 		actual.SecurityGroup = sg
 	}
+	if find != nil && find.SecurityGroup != nil && find.managesPortSecurityGroups() {
+		portSecurityGroups, err := getPortSecurityGroupNames(ctx, osCloud, lb.VipPortID)
+		if err != nil {
+			return nil, err
+		}
+		actual.PortSecurityGroups = portSecurityGroups
+		find.PortSecurityGroups = []string{fi.ValueOf(find.SecurityGroup.Name)}
+	}
+	// the VIP port is read for its tags and to remove the security group of the loadbalancer if it is no longer used
+	readPortSecurityGroups := find != nil && find.SecurityGroup == nil && find.managesPortSecurityGroups()
+	readPortTags := find != nil && find.VipPortTags != nil
+	if !isSharedLifecycle(lifecycle) && (readPortSecurityGroups || readPortTags) {
+		port, err := openstack.RunWithContext(ctx, func() (*ports.Port, error) {
+			return ports.Get(osCloud.NetworkingClient(), lb.VipPortID).Extract()
+		})
+		if err != nil {
+			if !openstack.IsNotFound(err) {
+				return nil, fmt.Errorf("Failed to get port with id %s: %w", lb.VipPortID, err)
+			}
+			klog.InfoS("VIP port of loadbalancer not found", loadbalancerLogValues(lb, "portID", lb.VipPortID)...)
+		} else {
+			if readPortTags {
+				actual.VipPortTags = intersectTags(port.Tags, find.VipPortTags)
+			}
+			if readPortSecurityGroups {
+				portSecurityGroups, err := securityGroupNames(ctx, osCloud, port.SecurityGroups)
+				if err != nil {
+					return nil, err
+				}
+				expected := []string{}
+				if !fi.ValueOf(find.ManagePortSecurityGroups) {
+					for _, name := range portSecurityGroups {
+						if name != lb.Name {
+							expected = append(expected, name)
+						}
+					}
+				}
+				actual.PortSecurityGroups = portSecurityGroups
+				if sameElements(portSecurityGroups, expected) {
+					actual.PortSecurityGroups = expected
+				}
+				find.PortSecurityGroups = expected
+			}
+		}
+	}
+	if lb.VipQosPolicyID != "" {
+		actual.VipQosPolicy = fi.PtrTo(lb.VipQosPolicyID)
+		// the policy may be referenced by name
+		if find != nil && find.VipQosPolicy != nil && fi.ValueOf(find.VipQosPolicy) != lb.VipQosPolicyID {
+			policy, err := osCloud.FindQosPolicyWithContext(ctx, fi.ValueOf(find.VipQosPolicy))
+			if err != nil {
+				return nil, err
+			}
+			if policy.ID == lb.VipQosPolicyID {
+				actual.VipQosPolicy = find.VipQosPolicy
+			}
+		}
+	}
 	if find != nil {
 		find.ID = actual.ID
 		find.PortID = actual.PortID
 		find.VipSubnet = actual.VipSubnet
-		find.Provider = actual.Provider
-		find.FlavorID = actual.FlavorID
+		find.CreatedAt = actual.CreatedAt
+		find.UpdatedAt = actual.UpdatedAt
+		if find.VipPortID != nil {
+			actual.VipPortID = actual.PortID
+			// the VIP subnet is the subnet of the port
+			actual.Subnet = find.Subnet
+		}
+		// the provider and flavor are only compared if the loadbalancer is recreated when they change
+		recreate := fi.ValueOf(find.RecreateOnImmutableChange)
+		if !recreate || find.Provider == nil || openstack.SameLBProvider(fi.ValueOf(find.Provider), lb.Provider) {
+			find.Provider = actual.Provider
+		}
+		if !recreate || find.FlavorID == nil {
+			find.FlavorID = actual.FlavorID
+		}
+		// options of the task that are not stored in the cloud are never reported as changes
+		actual.PreviousName = find.PreviousName
+		actual.RecreateOnImmutableChange = find.RecreateOnImmutableChange
+		actual.SkipActiveWait = find.SkipActiveWait
+		actual.ManagePortSecurityGroups = find.ManagePortSecurityGroups
+		actual.Tags = intersectTags(lb.Tags, find.Tags)
+		actual.Network = find.Network
 	}
 	return actual, nil
 }
@@ -153,24 +486,241 @@ func (s *LB) Find(context *fi.CloudupContext) (*LB, error) {
 	}
 
 	cloud := context.T.Cloud.(openstack.OpenstackCloud)
-	lbPage, err := loadbalancers.List(cloud.LoadBalancerClient(), loadbalancers.ListOpts{
-		Name: fi.ValueOf(s.Name),
-	}).AllPages()
+	if err := openstack.CheckLoadBalancerClient(cloud); err != nil {
+		return nil, fmt.Errorf("cannot find LB %s: %w", fi.ValueOf(s.Name), err)
+	}
+
+	s.flavorError = nil
+	lb, err := findCloudLB(cloud, s)
+	if err != nil {
+		return nil, err
+	}
+	if lb == nil {
+		s.validateFlavor(cloud, nil)
+		return nil, nil
+	}
+	actual, err := NewLBTaskFromCloud(taskContext(context), cloud, s.Lifecycle, lb, s)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to retrieve loadbalancers for name %s: %v", fi.ValueOf(s.Name), err)
+		return nil, err
+	}
+	s.validateFlavor(cloud, actual)
+	if isSharedLifecycle(s.Lifecycle) && s.ID != nil {
+		// the shared loadbalancer keeps its own name and subnet, the name of the task is only used for related resources
+		actual.Name = s.Name
+		actual.Subnet = s.Subnet
+	}
+	return actual, nil
+}
+
+// validateFlavor checks that the flavor the loadbalancer is created with belongs to its provider, Octavia only rejects
+// the flavor of another provider when the loadbalancer is created. Existing loadbalancers are only checked if they are
+// recreated with another flavor or provider.
+func (s *LB) validateFlavor(cloud openstack.OpenstackCloud, actual *LB) {
+	if fi.ValueOf(s.FlavorID) == "" {
+		return
+	}
+	if actual != nil && fi.ValueOf(actual.FlavorID) == fi.ValueOf(s.FlavorID) && fi.ValueOf(actual.Provider) == fi.ValueOf(s.Provider) {
+		return
+	}
+	s.flavorError = openstack.ValidateLBFlavor(cloud, fi.ValueOf(s.FlavorID), fi.ValueOf(s.Provider))
+}
+
+// findCloudLB returns the loadbalancer of the task, nil if it does not exist. A shared loadbalancer is looked up by
+// ID, otherwise the loadbalancer is looked up by name or previous name.
+func findCloudLB(cloud openstack.OpenstackCloud, s *LB) (*loadbalancers.LoadBalancer, error) {
+	if isSharedLifecycle(s.Lifecycle) && s.ID != nil {
+		lb, err := cloud.GetLB(fi.ValueOf(s.ID))
+		if openstack.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get loadbalancer %s: %w", fi.ValueOf(s.ID), err)
+		}
+		return lb, nil
 	}
-	lbs, err := loadbalancers.ExtractLoadBalancers(lbPage)
+	lbs, err := findLBsByName(cloud, fi.ValueOf(s.Name))
 	if err != nil {
-		return nil, fmt.Errorf("Failed to extract loadbalancers : %v", err)
+		return nil, err
+	}
+	if len(lbs) == 0 && s.PreviousName != nil {
+		klog.V(lbLogLevel).InfoS("Loadbalancer not found, looking for loadbalancer with previous name", s.logValues("previousName", fi.ValueOf(s.PreviousName))...)
+		lbs, err = findLBsByName(cloud, fi.ValueOf(s.PreviousName))
+		if err != nil {
+			return nil, err
+		}
 	}
 	if len(lbs) == 0 {
 		return nil, nil
 	}
 	if len(lbs) > 1 {
-		return nil, fmt.Errorf("Multiple load balancers for name %s", fi.ValueOf(s.Name))
+		return matchLB(cloud, s, lbs)
+	}
+	return &lbs[0], nil
+}
+
+// matchLB picks the loadbalancer of the task from loadbalancers with the same name, e.g. of other clusters in the same
+// project. The loadbalancers are narrowed to the VIP subnet and then to the tags of the task, nil is returned if none
+// of them matches. It fails if the loadbalancer is still ambiguous.
+func matchLB(cloud openstack.OpenstackCloud, s *LB, lbs []loadbalancers.LoadBalancer) (*loadbalancers.LoadBalancer, error) {
+	subnetIDs := map[string]bool{}
+	if s.VipSubnet != nil {
+		subnetIDs[fi.ValueOf(s.VipSubnet)] = true
+	} else if s.Subnet != nil {
+		subnets, err := cloud.ListSubnets(subnets.ListOpts{Name: fi.ValueOf(s.Subnet)})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to retrieve subnet `%s` of loadbalancer %s: %v", fi.ValueOf(s.Subnet), fi.ValueOf(s.Name), err)
+		}
+		for _, subnet := range subnets {
+			subnetIDs[subnet.ID] = true
+		}
+	}
+
+	candidates := lbs
+	if s.VipSubnet != nil || s.Subnet != nil {
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(lb loadbalancers.LoadBalancer) bool {
+			return !subnetIDs[lb.VipSubnetID]
+		})
+	}
+	if len(candidates) > 1 && len(s.Tags) > 0 {
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(lb loadbalancers.LoadBalancer) bool {
+			return len(intersectTags(lb.Tags, s.Tags)) != len(s.Tags)
+		})
+	}
+
+	switch len(candidates) {
+	case 0:
+		klog.V(lbLogLevel).InfoS("None of the loadbalancers with the name belongs to the subnet", s.logValues("count", len(lbs), "subnet", fi.ValueOf(s.Subnet))...)
+		return nil, nil
+	case 1:
+		klog.V(lbLogLevel).InfoS("Using one of the loadbalancers with the name", loadbalancerLogValues(&candidates[0], "count", len(lbs))...)
+		return &candidates[0], nil
+	default:
+		var descriptions []string
+		for _, lb := range candidates {
+			descriptions = append(descriptions, fmt.Sprintf("%s (subnet %s, VIP %s)", lb.ID, lb.VipSubnetID, lb.VipAddress))
+		}
+		return nil, fmt.Errorf("Multiple load balancers for name %s: %s", candidates[0].Name, strings.Join(descriptions, ", "))
+	}
+}
+
+// LBEndpoint is the address under which a loadbalancer can be reached
+type LBEndpoint struct {
+	// Name is the name of the loadbalancer
+	Name string `json:"name"`
+	// VIP is the address of the loadbalancer in its VIP subnet
+	VIP string `json:"vip"`
+	// FloatingIPs are the floating IPs associated to the VIP port of the loadbalancer
+	FloatingIPs []string `json:"floatingIPs,omitempty"`
+}
+
+// FindLBEndpoint returns the endpoint of the loadbalancer of the task, nil if the loadbalancer does not exist. The
+// loadbalancer is looked up the same way as by Find.
+func FindLBEndpoint(cloud openstack.OpenstackCloud, e *LB) (*LBEndpoint, error) {
+	if err := openstack.CheckLoadBalancerClient(cloud); err != nil {
+		return nil, err
+	}
+	lb, err := findCloudLB(cloud, e)
+	if err != nil || lb == nil {
+		return nil, err
+	}
+	endpoint := &LBEndpoint{
+		Name: lb.Name,
+		VIP:  lb.VipAddress,
+	}
+	fips, err := cloud.ListL3FloatingIPs(l3floatingip.ListOpts{
+		PortID: lb.VipPortID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list floating IPs of loadbalancer %s: %w", lb.Name, err)
+	}
+	for _, fip := range fips {
+		// the port filter is not applied by every deployment
+		if fip.PortID == lb.VipPortID {
+			endpoint.FloatingIPs = append(endpoint.FloatingIPs, fip.FloatingIP)
+		}
+	}
+	return endpoint, nil
+}
+
+// getPortSecurityGroupNames returns the names of the security groups of the port, the ID is used for groups that
+// cannot be found
+func getPortSecurityGroupNames(ctx context.Context, cloud openstack.OpenstackCloud, portID string) ([]string, error) {
+	port, err := openstack.RunWithContext(ctx, func() (*ports.Port, error) {
+		return ports.Get(cloud.NetworkingClient(), portID).Extract()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get port with id %s: %w", portID, err)
+	}
+	return securityGroupNames(ctx, cloud, port.SecurityGroups)
+}
+
+// securityGroupNames returns the names of the security groups, the ID is used for groups that are not found
+func securityGroupNames(ctx context.Context, cloud openstack.OpenstackCloud, securityGroupIDs []string) ([]string, error) {
+	var names []string
+	for _, id := range securityGroupIDs {
+		gs, err := cloud.ListSecurityGroupsWithContext(ctx, sg.ListOpts{ID: id})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get security group with id %s: %w", id, err)
+		}
+		if len(gs) == 1 {
+			names = append(names, gs[0].Name)
+		} else {
+			names = append(names, id)
+		}
 	}
+	return names, nil
+}
 
-	return NewLBTaskFromCloud(cloud, s.Lifecycle, &lbs[0], s)
+// remainingPortSecurityGroups returns the IDs of the security groups that are kept on the VIP port if the loadbalancer
+// has no security group: none if the port security groups are managed, the groups attached by others otherwise
+func remainingPortSecurityGroups(ctx context.Context, cloud openstack.OpenstackCloud, e *LB) ([]string, error) {
+	securityGroupIDs := []string{}
+	if fi.ValueOf(e.ManagePortSecurityGroups) {
+		return securityGroupIDs, nil
+	}
+	port, err := openstack.RunWithContext(ctx, func() (*ports.Port, error) {
+		return ports.Get(cloud.NetworkingClient(), fi.ValueOf(e.PortID)).Extract()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get port with id %s: %w", fi.ValueOf(e.PortID), err)
+	}
+	lbSecurityGroup, err := getSecurityGroupByName(ctx, &SecurityGroup{Name: e.Name}, cloud)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range port.SecurityGroups {
+		if lbSecurityGroup == nil || id != fi.ValueOf(lbSecurityGroup.ID) {
+			securityGroupIDs = append(securityGroupIDs, id)
+		}
+	}
+	return securityGroupIDs, nil
+}
+
+func findLBsByName(cloud openstack.OpenstackCloud, name string) ([]loadbalancers.LoadBalancer, error) {
+	lbs, err := cloud.FindLBsByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve loadbalancers for name %s: %w", name, err)
+	}
+	return lbs, nil
+}
+
+// findExistingLB returns the loadbalancer of the task after a create conflict. Octavia does not reject duplicate names,
+// so a loadbalancer with the name is only adopted if it is in the subnet and has the tags of the task, the create
+// error is returned otherwise.
+func findExistingLB(cloud openstack.OpenstackCloud, e *LB, createErr error) (*loadbalancers.LoadBalancer, error) {
+	lbs, err := findLBsByName(cloud, fi.ValueOf(e.Name))
+	if err != nil {
+		return nil, err
+	}
+	lb, err := matchLB(cloud, e, lbs)
+	if err != nil {
+		return nil, err
+	}
+	// matchLB only compares the tags to tell apart several loadbalancers in the subnet
+	if lb == nil || len(intersectTags(lb.Tags, e.Tags)) != len(e.Tags) {
+		return nil, createErr
+	}
+	return lb, nil
 }
 
 func (s *LB) Run(context *fi.CloudupContext) error {
@@ -186,35 +736,148 @@ func (_ *LB) CheckChanges(a, e, changes *LB) error {
 		if changes.ID != nil {
 			return fi.CannotChangeField("ID")
 		}
-		if changes.Name != nil {
+		// only a loadbalancer with the previous name is renamed
+		if changes.Name != nil && fi.ValueOf(a.Name) != fi.ValueOf(e.PreviousName) {
 			return fi.CannotChangeField("Name")
 		}
 	}
+	if e.Provider != nil {
+		if err := openstack.ValidateLBProviderFlavor(fi.ValueOf(e.Provider), fi.ValueOf(e.FlavorID)); err != nil {
+			return fmt.Errorf("LB %s: %w", fi.ValueOf(e.Name), err)
+		}
+	}
+	if e.flavorError != nil {
+		return fmt.Errorf("LB %s: %w", fi.ValueOf(e.Name), e.flavorError)
+	}
+	// the loadbalancer must be recreated to change these fields, which is opt-in
+	if a != nil && !fi.ValueOf(e.RecreateOnImmutableChange) {
+		if fields := immutableLBChanges(a, changes); len(fields) > 0 {
+			return fi.CannotChangeField(fields[0])
+		}
+	}
 	return nil
 }
 
-func (_ *LB) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LB) error {
-	if a == nil {
-		klog.V(2).Infof("Creating LB with Name: %q", fi.ValueOf(e.Name))
+// immutableLBChanges returns the changed fields of the loadbalancer that can only be changed by recreating it
+func immutableLBChanges(a, changes *LB) []string {
+	var fields []string
+	// a loadbalancer whose VIP subnet was deleted is still reported, its subnet is unknown and not compared
+	if changes.Subnet != nil && a.Subnet != nil {
+		fields = append(fields, "Subnet")
+	}
+	if changes.Provider != nil {
+		fields = append(fields, "Provider")
+	}
+	if changes.FlavorID != nil {
+		fields = append(fields, "FlavorID")
+	}
+	if changes.VipPortID != nil {
+		fields = append(fields, "VipPortID")
+	}
+	return fields
+}
 
-		subnets, err := t.Cloud.ListSubnets(subnets.ListOpts{
-			Name: fi.ValueOf(e.Subnet),
-		})
-		if err != nil {
-			return fmt.Errorf("Failed to retrieve subnet `%s` in loadbalancer creation: %v", fi.ValueOf(e.Subnet), err)
-		}
-		if len(subnets) != 1 {
-			return fmt.Errorf("Unexpected desired subnets for `%s`.  Expected 1, got %d", fi.ValueOf(e.Subnet), len(subnets))
+// tagVIPPort adds the missing tags of the task to the VIP port. The tags are only used outside of kOps, so the apply
+// continues if the port cannot be tagged, e.g. because Neutron does not support tags.
+func (e *LB) tagVIPPort(cloud openstack.OpenstackCloud, portID string, tags []string) {
+	if len(e.VipPortTags) == 0 || e.VipPortID != nil {
+		return
+	}
+	if err := appendMissingTags(cloud, openstack.ResourceTypePort, portID, tags, e.VipPortTags); err != nil {
+		klog.InfoS("Unable to tag the VIP port of LB, continuing without the tags", e.logValues("portID", portID, "err", err)...)
+	}
+}
+
+// deleteLBForRecreate deletes the loadbalancer together with its listeners, pools and members and returns the floating
+// IP of its VIP port, so that it can be associated with the recreated loadbalancer
+func deleteLBForRecreate(cloud openstack.OpenstackCloud, a *LB) (*l3floatingip.FloatingIP, error) {
+	fip, err := findFipByPortID(cloud, fi.ValueOf(a.PortID))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the floating IP of LB %s: %v", fi.ValueOf(a.Name), err)
+	}
+	if err := cloud.DeleteLB(fi.ValueOf(a.ID), loadbalancers.DeleteOpts{Cascade: true}); err != nil {
+		return nil, fmt.Errorf("Failed to delete LB %s to recreate it: %v", fi.ValueOf(a.Name), err)
+	}
+	if err := openstack.WaitLBDeleted(cloud, fi.ValueOf(a.ID), openstack.DefaultLBDeleteTimeout); err != nil {
+		return nil, fmt.Errorf("Failed to delete LB %s to recreate it: %w", fi.ValueOf(a.Name), err)
+	}
+	return fip, nil
+}
+
+// RenderOpenstack creates or updates the loadbalancer. It is never called for a dry-run, the changes are reported by
+// the dry-run target instead, so the loadbalancer and its VIP port are not modified.
+func (_ *LB) RenderOpenstack(c *fi.CloudupContext, t *openstack.OpenstackAPITarget, a, e, changes *LB) error {
+	defer openstack.RecordRender("LB", fi.ValueOf(e.Name))()
+
+	if err := openstack.CheckLoadBalancerClient(t.Cloud); err != nil {
+		return fmt.Errorf("cannot create or update LB %s: %w", fi.ValueOf(e.Name), err)
+	}
+
+	var previousFIP *l3floatingip.FloatingIP
+	if a != nil && fi.ValueOf(e.RecreateOnImmutableChange) {
+		if fields := immutableLBChanges(a, changes); len(fields) > 0 {
+			klog.InfoS("Recreating LB, fields cannot be changed in place", a.logValues("fields", fields)...)
+			fip, err := deleteLBForRecreate(t.Cloud, a)
+			if err != nil {
+				return err
+			}
+			previousFIP = fip
+			a = nil
 		}
+	}
+
+	if a == nil {
+		klog.V(lbLogLevel).InfoS("Creating LB", e.logValues("provider", fi.ValueOf(e.Provider), "flavorID", fi.ValueOf(e.FlavorID))...)
 
 		lbopts := loadbalancers.CreateOpts{
-			Name:        fi.ValueOf(e.Name),
-			VipSubnetID: subnets[0].ID,
+			Name:         fi.ValueOf(e.Name),
+			Tags:         e.Tags,
+			AdminStateUp: e.AdminStateUp,
+		}
+		if e.VipPortID != nil {
+			// the VIP subnet is the subnet of the port
+			port, err := t.Cloud.GetPort(fi.ValueOf(e.VipPortID))
+			if err != nil {
+				return fmt.Errorf("Failed to get VIP port %s of loadbalancer %s: %v", fi.ValueOf(e.VipPortID), fi.ValueOf(e.Name), err)
+			}
+			if err := checkVIPPortNetwork(port, e.Network); err != nil {
+				return fmt.Errorf("Failed to create loadbalancer %s: %v", fi.ValueOf(e.Name), err)
+			}
+			lbopts.VipPortID = port.ID
+		} else {
+			subnets, err := t.Cloud.ListSubnets(subnets.ListOpts{
+				Name: fi.ValueOf(e.Subnet),
+			})
+			if err != nil {
+				return fmt.Errorf("Failed to retrieve subnet `%s` in loadbalancer creation: %v", fi.ValueOf(e.Subnet), err)
+			}
+			if len(subnets) != 1 {
+				return fmt.Errorf("Unexpected desired subnets for `%s`.  Expected 1, got %d", fi.ValueOf(e.Subnet), len(subnets))
+			}
+			if err := checkVIPSubnetNetwork(&subnets[0], e.Network); err != nil {
+				return fmt.Errorf("Failed to create loadbalancer %s: %v", fi.ValueOf(e.Name), err)
+			}
+			lbopts.VipSubnetID = subnets[0].ID
+		}
+		if e.Provider != nil {
+			lbopts.Provider = fi.ValueOf(e.Provider)
 		}
 		if e.FlavorID != nil {
 			lbopts.FlavorID = fi.ValueOf(e.FlavorID)
 		}
+		if e.VipQosPolicy != nil {
+			policy, err := t.Cloud.FindQosPolicy(fi.ValueOf(e.VipQosPolicy))
+			if err != nil {
+				return fmt.Errorf("Failed to find QoS policy for loadbalancer %s: %v", fi.ValueOf(e.Name), err)
+			}
+			lbopts.VipQosPolicyID = policy.ID
+		}
 		lb, err := t.Cloud.CreateLB(lbopts)
+		if openstack.IsConflict(err) {
+			// Find may have missed a loadbalancer that was not listed yet
+			klog.InfoS("LB may already exist, adopting it", e.logValues("err", err)...)
+			lb, err = findExistingLB(t.Cloud, e, err)
+		}
 		if err != nil {
 			return fmt.Errorf("error creating LB: %v", err)
 		}
@@ -224,29 +887,99 @@ func (_ *LB) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LB)
 		e.Provider = fi.PtrTo(lb.Provider)
 		e.FlavorID = fi.PtrTo(lb.FlavorID)
 
-		if e.SecurityGroup != nil {
-			opts := ports.UpdateOpts{
-				SecurityGroups: &[]string{fi.ValueOf(e.SecurityGroup.ID)},
+		e.tagVIPPort(t.Cloud, lb.VipPortID, nil)
+		if e.SecurityGroup != nil && e.managesPortSecurityGroups() {
+			// the loadbalancer is not rolled back, it is found again when the task is retried and the security group
+			// is set on its VIP port by the update
+			if err := secureVIPPort(t.Cloud, lb.VipPortID, e.SecurityGroup); err != nil {
+				return fmt.Errorf("loadbalancer %s (%s) was created but its VIP port %s is not restricted to security group %s yet, it is updated on retry: %w",
+					lb.Name, lb.ID, lb.VipPortID, fi.ValueOf(e.SecurityGroup.Name), err)
 			}
-			_, err = ports.Update(t.Cloud.NetworkingClient(), lb.VipPortID, opts).Extract()
+		}
+		if previousFIP != nil {
+			// the API keeps its address, otherwise the floating IP task allocates a new one
+			_, err := l3floatingip.Update(t.Cloud.NetworkingClient(), previousFIP.ID, l3floatingip.UpdateOpts{PortID: fi.PtrTo(lb.VipPortID)}).Extract()
 			if err != nil {
-				return fmt.Errorf("Failed to update security group for port %s: %v", lb.VipPortID, err)
+				klog.ErrorS(err, "Failed to associate floating IP with the VIP port of the recreated LB, the floating IP is kept",
+					e.logValues("floatingIP", previousFIP.FloatingIP, "portID", lb.VipPortID)...)
 			}
 		}
 		return nil
 	}
-	// We may have failed to update the security groups on the load balancer
-	port, err := t.Cloud.GetPort(fi.ValueOf(a.PortID))
-	if err != nil {
-		return fmt.Errorf("Failed to get port with id %s: %v", fi.ValueOf(a.PortID), err)
+	updateOpts := loadbalancers.UpdateOpts{}
+	update := false
+	if changes.Name != nil {
+		klog.V(lbLogLevel).InfoS("Renaming LB", a.logValues("newName", fi.ValueOf(e.Name))...)
+		updateOpts.Name = e.Name
+		update = true
+	}
+	if changes.VipQosPolicy != nil {
+		klog.V(lbLogLevel).InfoS("Updating QoS policy of LB", a.logValues("from", fi.ValueOf(a.VipQosPolicy), "to", fi.ValueOf(e.VipQosPolicy))...)
+		policy, err := t.Cloud.FindQosPolicy(fi.ValueOf(e.VipQosPolicy))
+		if err != nil {
+			return fmt.Errorf("Failed to find QoS policy for loadbalancer %s: %v", fi.ValueOf(e.Name), err)
+		}
+		updateOpts.VipQosPolicyID = fi.PtrTo(policy.ID)
+		update = true
+	}
+	if changes.AdminStateUp != nil {
+		klog.V(lbLogLevel).InfoS("Updating admin state of LB", a.logValues("from", fi.ValueOf(a.AdminStateUp), "to", fi.ValueOf(e.AdminStateUp))...)
+		updateOpts.AdminStateUp = e.AdminStateUp
+		update = true
+	}
+	if changes.Tags != nil {
+		klog.V(lbLogLevel).InfoS("Updating tags of LB", a.logValues("from", a.Tags, "to", e.Tags)...)
+		// the tags of the loadbalancer are replaced, tags added by others are kept
+		lb, err := t.Cloud.GetLB(fi.ValueOf(a.ID))
+		if err != nil {
+			return fmt.Errorf("Failed to get loadbalancer %s: %v", fi.ValueOf(a.ID), err)
+		}
+		updateOpts.Tags = fi.PtrTo(mergeTags(lb.Tags, e.Tags))
+		update = true
+	}
+	if update {
+		_, err := t.Cloud.UpdateLB(fi.ValueOf(a.ID), updateOpts)
+		if err != nil {
+			return fmt.Errorf("error updating LB: %v", err)
+		}
+		// the loadbalancer is immutable until the update is done
+		if err := waitLoadbalancerActive(t.Cloud.GetLB, e); err != nil {
+			return err
+		}
+	}
+
+	if changes.VipPortTags != nil {
+		klog.V(lbLogLevel).InfoS("Updating tags of LB port", a.logValues("portID", fi.ValueOf(a.PortID), "from", a.VipPortTags, "to", e.VipPortTags)...)
+		e.tagVIPPort(t.Cloud, fi.ValueOf(a.PortID), a.VipPortTags)
+		update = true
 	}
-	// Ensure the loadbalancer port has one security group and it is the one specified,
-	if e.SecurityGroup != nil &&
-		(len(port.SecurityGroups) < 1 || port.SecurityGroups[0] != fi.ValueOf(e.SecurityGroup.ID)) {
+
+	// We may have failed to update the security groups on the load balancer, the port has exactly the one specified
+	if changes.PortSecurityGroups != nil && e.SecurityGroup != nil {
+		klog.V(lbLogLevel).InfoS("Updating security groups of LB port", a.logValues("portID", fi.ValueOf(a.PortID), "from", a.PortSecurityGroups, "to", e.PortSecurityGroups)...)
 
 		opts := ports.UpdateOpts{
 			SecurityGroups: &[]string{fi.ValueOf(e.SecurityGroup.ID)},
 		}
+		_, err := ports.Update(t.Cloud.NetworkingClient(), fi.ValueOf(a.PortID), opts).Extract()
+		if err != nil {
+			return fmt.Errorf("Failed to update security group for port %s: %v", fi.ValueOf(a.PortID), err)
+		}
+		return nil
+	}
+
+	if changes.PortSecurityGroups != nil && e.SecurityGroup == nil {
+		klog.V(lbLogLevel).InfoS("Removing security groups of LB port", a.logValues("portID", fi.ValueOf(a.PortID), "from", a.PortSecurityGroups, "to", e.PortSecurityGroups)...)
+
+		ctx, cancel := context.WithTimeout(taskContext(c), lbLookupTimeout)
+		defer cancel()
+		securityGroupIDs, err := remainingPortSecurityGroups(ctx, t.Cloud, e)
+		if err != nil {
+			return err
+		}
+		opts := ports.UpdateOpts{
+			SecurityGroups: &securityGroupIDs,
+		}
 		_, err = ports.Update(t.Cloud.NetworkingClient(), fi.ValueOf(a.PortID), opts).Extract()
 		if err != nil {
 			return fmt.Errorf("Failed to update security group for port %s: %v", fi.ValueOf(a.PortID), err)
@@ -254,6 +987,8 @@ func (_ *LB) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LB)
 		return nil
 	}
 
-	klog.V(2).Infof("Openstack task LB::RenderOpenstack did nothing")
+	if !update {
+		klog.V(lbLogLevel).InfoS("Openstack task LB::RenderOpenstack did nothing", e.logValues()...)
+	}
 	return nil
 }
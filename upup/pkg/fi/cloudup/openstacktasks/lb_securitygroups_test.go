@@ -0,0 +1,348 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+// lbSecurityGroupHarness is a fake cloud with an existing loadbalancer and its VIP port, the LB task is reconciled
+// against it like by the executor to test the security groups of the VIP port
+type lbSecurityGroupHarness struct {
+	cloud *openstack.MockCloud
+	// groups are the security groups of the cloud by name
+	groups map[string]*sg.SecGroup
+	portID string
+
+	mutex  sync.Mutex
+	writes []string
+}
+
+// newLBSecurityGroupHarness creates the security groups and the loadbalancer with the port security groups, which
+// are referenced by name
+func newLBSecurityGroupHarness(t *testing.T, groupNames []string, portSecurityGroups []string) *lbSecurityGroupHarness {
+	h := &lbSecurityGroupHarness{
+		cloud: &openstack.MockCloud{
+			MockNeutronClient: mocknetworking.CreateClient(),
+			MockLBClient:      mockloadbalancer.CreateClient(),
+		},
+		groups: make(map[string]*sg.SecGroup),
+	}
+	for _, name := range groupNames {
+		group, err := h.cloud.CreateSecurityGroup(sg.CreateOpts{Name: name})
+		if err != nil {
+			t.Fatalf("error creating security group: %v", err)
+		}
+		h.groups[name] = group
+	}
+	securityGroupIDs := []string{}
+	for _, name := range portSecurityGroups {
+		securityGroupIDs = append(securityGroupIDs, h.groups[name].ID)
+	}
+	port, err := h.cloud.CreatePort(ports.CreateOpts{
+		Name:           "vip",
+		NetworkID:      "network",
+		SecurityGroups: &securityGroupIDs,
+	})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+	h.portID = port.ID
+	if _, err := h.cloud.CreateLB(loadbalancers.CreateOpts{
+		Name:        "api.cluster",
+		VipSubnetID: "subnet-id",
+		VipPortID:   port.ID,
+	}); err != nil {
+		t.Fatalf("error creating loadbalancer: %v", err)
+	}
+	recordWrites(h.cloud.MockNeutronClient.Server, &h.mutex, &h.writes)
+	recordWrites(h.cloud.MockLBClient.Server, &h.mutex, &h.writes)
+	return h
+}
+
+// securityGroup returns the task of a security group of the cloud, as found by the SecurityGroup task
+func (h *lbSecurityGroupHarness) securityGroup(name string) *SecurityGroup {
+	return &SecurityGroup{
+		ID:        fi.PtrTo(h.groups[name].ID),
+		Name:      fi.PtrTo(name),
+		Lifecycle: fi.LifecycleSync,
+	}
+}
+
+// reconcile runs the task against the cloud and returns the requests that modified resources
+func (h *lbSecurityGroupHarness) reconcile(t *testing.T, e *LB) []string {
+	h.mutex.Lock()
+	h.writes = nil
+	h.mutex.Unlock()
+
+	target := openstack.NewOpenstackAPITarget(h.cloud)
+	ctx, err := fi.NewCloudupContext(context.TODO(), fi.DeletionProcessingModeDeleteIncludingDeferred, target, nil, h.cloud, nil, nil, nil, map[string]fi.CloudupTask{"LB/api.cluster": e})
+	if err != nil {
+		t.Fatalf("error building context: %v", err)
+	}
+	if err := e.Run(ctx); err != nil {
+		t.Fatalf("unexpected error reconciling loadbalancer: %v", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.writes
+}
+
+// portSecurityGroups returns the sorted names of the security groups of the VIP port
+func (h *lbSecurityGroupHarness) portSecurityGroups(t *testing.T) []string {
+	names, err := getPortSecurityGroupNames(context.Background(), h.cloud, h.portID)
+	if err != nil {
+		t.Fatalf("error getting port security groups: %v", err)
+	}
+	sort.Strings(names)
+	if names == nil {
+		names = []string{}
+	}
+	return names
+}
+
+func Test_LB_ReconcilePortSecurityGroups(t *testing.T) {
+	tests := []struct {
+		desc                     string
+		portSecurityGroups       []string
+		securityGroup            string
+		managePortSecurityGroups *bool
+		expectedSecurityGroups   []string
+		expectedWrites           int
+	}{
+		{
+			desc:                   "no security group desired",
+			portSecurityGroups:     []string{},
+			expectedSecurityGroups: []string{},
+		},
+		{
+			desc:                   "no security group desired loadbalancer security group removed",
+			portSecurityGroups:     []string{"api.cluster"},
+			expectedSecurityGroups: []string{},
+			expectedWrites:         1,
+		},
+		{
+			desc:                   "security group already correct",
+			portSecurityGroups:     []string{"api.cluster"},
+			securityGroup:          "api.cluster",
+			expectedSecurityGroups: []string{"api.cluster"},
+		},
+		{
+			desc:                   "security group missing",
+			portSecurityGroups:     []string{},
+			securityGroup:          "api.cluster",
+			expectedSecurityGroups: []string{"api.cluster"},
+			expectedWrites:         1,
+		},
+		{
+			desc:                   "security group wrong",
+			portSecurityGroups:     []string{"default"},
+			securityGroup:          "api.cluster",
+			expectedSecurityGroups: []string{"api.cluster"},
+			expectedWrites:         1,
+		},
+		{
+			desc:                   "port with extra foreign security groups",
+			portSecurityGroups:     []string{"api.cluster", "octavia"},
+			securityGroup:          "api.cluster",
+			expectedSecurityGroups: []string{"api.cluster"},
+			expectedWrites:         1,
+		},
+		{
+			desc:                   "no security group desired foreign security groups kept",
+			portSecurityGroups:     []string{"api.cluster", "octavia"},
+			expectedSecurityGroups: []string{"octavia"},
+			expectedWrites:         1,
+		},
+		{
+			desc:                   "no security group desired foreign security groups already kept",
+			portSecurityGroups:     []string{"octavia"},
+			expectedSecurityGroups: []string{"octavia"},
+		},
+		{
+			desc:                     "no security group desired managed foreign security groups removed",
+			portSecurityGroups:       []string{"api.cluster", "octavia"},
+			managePortSecurityGroups: fi.PtrTo(true),
+			expectedSecurityGroups:   []string{},
+			expectedWrites:           1,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			h := newLBSecurityGroupHarness(t, []string{"api.cluster", "default", "octavia"}, testCase.portSecurityGroups)
+			newTask := func() *LB {
+				e := &LB{
+					Name:                     fi.PtrTo("api.cluster"),
+					Lifecycle:                fi.LifecycleSync,
+					ManagePortSecurityGroups: testCase.managePortSecurityGroups,
+				}
+				if testCase.securityGroup != "" {
+					e.SecurityGroup = h.securityGroup(testCase.securityGroup)
+				}
+				return e
+			}
+
+			writes := h.reconcile(t, newTask())
+			if len(writes) != testCase.expectedWrites {
+				t.Errorf("expected %d write requests, got %v", testCase.expectedWrites, writes)
+			}
+			if actual := h.portSecurityGroups(t); !reflect.DeepEqual(actual, testCase.expectedSecurityGroups) {
+				t.Errorf("expected port security groups %v, got %v", testCase.expectedSecurityGroups, actual)
+			}
+
+			// the reconcile is idempotent
+			if writes := h.reconcile(t, newTask()); len(writes) != 0 {
+				t.Errorf("expected no write requests reconciling again, got %v", writes)
+			}
+			if actual := h.portSecurityGroups(t); !reflect.DeepEqual(actual, testCase.expectedSecurityGroups) {
+				t.Errorf("expected port security groups %v after reconciling again, got %v", testCase.expectedSecurityGroups, actual)
+			}
+		})
+	}
+}
+
+func Test_LB_CreateSecurityGroupUpdateFailure(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	if _, err := cloud.CreateSubnet(subnets.CreateOpts{
+		Name:       "utility.cluster",
+		NetworkID:  network.ID,
+		CIDR:       "10.0.0.0/24",
+		IPVersion:  gophercloud.IPv4,
+		EnableDHCP: fi.PtrTo(true),
+	}); err != nil {
+		t.Fatalf("error creating subnet: %v", err)
+	}
+	group, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "api.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	port, err := cloud.CreatePort(ports.CreateOpts{Name: "vip", NetworkID: network.ID})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+
+	// Octavia creates the VIP port of the loadbalancer, the mock uses the port created above
+	lbHandler := cloud.MockLBClient.Server.Config.Handler
+	cloud.MockLBClient.Server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/lbaas/loadbalancers" {
+			var create map[string]map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+				t.Errorf("error decoding create loadbalancer request: %v", err)
+			}
+			create["loadbalancer"]["vip_port_id"] = port.ID
+			body, err := json.Marshal(create)
+			if err != nil {
+				t.Errorf("error encoding create loadbalancer request: %v", err)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+		lbHandler.ServeHTTP(w, r)
+	})
+	// the security group cannot be set on the VIP port until the failure is cleared
+	failPortUpdates := true
+	neutronHandler := cloud.MockNeutronClient.Server.Config.Handler
+	cloud.MockNeutronClient.Server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failPortUpdates && r.Method == http.MethodPut && r.URL.Path == "/ports/"+port.ID {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		neutronHandler.ServeHTTP(w, r)
+	})
+
+	newTask := func() *LB {
+		return &LB{
+			Name:      fi.PtrTo("api.cluster"),
+			Subnet:    fi.PtrTo("utility.cluster"),
+			Lifecycle: fi.LifecycleSync,
+			SecurityGroup: &SecurityGroup{
+				ID:        fi.PtrTo(group.ID),
+				Name:      fi.PtrTo("api.cluster"),
+				Lifecycle: fi.LifecycleSync,
+			},
+		}
+	}
+	run := func(e *LB) error {
+		target := openstack.NewOpenstackAPITarget(cloud)
+		ctx, err := fi.NewCloudupContext(context.TODO(), fi.DeletionProcessingModeDeleteIncludingDeferred, target, nil, cloud, nil, nil, nil, map[string]fi.CloudupTask{"LB/api.cluster": e})
+		if err != nil {
+			t.Fatalf("error building context: %v", err)
+		}
+		return e.Run(ctx)
+	}
+
+	err = run(newTask())
+	if err == nil || !strings.Contains(err.Error(), "was created but its VIP port "+port.ID+" is not restricted to security group api.cluster yet") {
+		t.Fatalf("expected an error reporting the incomplete loadbalancer, got %v", err)
+	}
+	lbs, err := cloud.ListLBs(loadbalancers.ListOpts{Name: "api.cluster"})
+	if err != nil {
+		t.Fatalf("error listing loadbalancers: %v", err)
+	}
+	if len(lbs) != 1 {
+		t.Fatalf("expected the loadbalancer not to be rolled back, got %v", lbs)
+	}
+
+	// the retry finds the loadbalancer and sets the security group on its VIP port
+	failPortUpdates = false
+	if err := run(newTask()); err != nil {
+		t.Fatalf("unexpected error retrying: %v", err)
+	}
+	names, err := getPortSecurityGroupNames(context.Background(), cloud, port.ID)
+	if err != nil {
+		t.Fatalf("error getting port security groups: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"api.cluster"}) {
+		t.Errorf("expected port security groups [api.cluster], got %v", names)
+	}
+	lbs, err = cloud.ListLBs(loadbalancers.ListOpts{Name: "api.cluster"})
+	if err != nil {
+		t.Fatalf("error listing loadbalancers: %v", err)
+	}
+	if len(lbs) != 1 {
+		t.Errorf("expected the loadbalancer to be reused, got %v", lbs)
+	}
+}
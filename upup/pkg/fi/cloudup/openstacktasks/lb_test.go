@@ -0,0 +1,1555 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	l3floatingip "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/pkg/assets"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func Test_LB_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *LB
+		expected      *LB
+		changes       *LB
+		expectedError error
+	}{
+		{
+			desc:   "actual nil all required fields set",
+			actual: nil,
+			expected: &LB{
+				Name: fi.PtrTo("name"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc:          "actual nil required field Name nil",
+			actual:        nil,
+			expected:      &LB{},
+			expectedError: fi.RequiredField("Name"),
+		},
+		{
+			desc: "actual not nil rename from previous name",
+			actual: &LB{
+				ID:   fi.PtrTo("id"),
+				Name: fi.PtrTo("old"),
+			},
+			expected: &LB{
+				ID:           fi.PtrTo("id"),
+				Name:         fi.PtrTo("new"),
+				PreviousName: fi.PtrTo("old"),
+			},
+			changes: &LB{
+				Name: fi.PtrTo("new"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil rename without previous name",
+			actual: &LB{
+				ID:   fi.PtrTo("id"),
+				Name: fi.PtrTo("old"),
+			},
+			expected: &LB{
+				ID:   fi.PtrTo("id"),
+				Name: fi.PtrTo("new"),
+			},
+			changes: &LB{
+				Name: fi.PtrTo("new"),
+			},
+			expectedError: fi.CannotChangeField("Name"),
+		},
+		{
+			desc: "actual not nil rename from other previous name",
+			actual: &LB{
+				ID:   fi.PtrTo("id"),
+				Name: fi.PtrTo("old"),
+			},
+			expected: &LB{
+				ID:           fi.PtrTo("id"),
+				Name:         fi.PtrTo("new"),
+				PreviousName: fi.PtrTo("other"),
+			},
+			changes: &LB{
+				Name: fi.PtrTo("new"),
+			},
+			expectedError: fi.CannotChangeField("Name"),
+		},
+		{
+			desc: "actual not nil unchangeable field ID set",
+			actual: &LB{
+				ID:   fi.PtrTo("id"),
+				Name: fi.PtrTo("name"),
+			},
+			expected: &LB{
+				ID:   fi.PtrTo("other"),
+				Name: fi.PtrTo("name"),
+			},
+			changes: &LB{
+				ID: fi.PtrTo("other"),
+			},
+			expectedError: fi.CannotChangeField("ID"),
+		},
+		{
+			desc: "actual not nil flavor not supported by provider",
+			actual: &LB{
+				Name:     fi.PtrTo("name"),
+				Provider: fi.PtrTo("ovn"),
+			},
+			expected: &LB{
+				Name:     fi.PtrTo("name"),
+				Provider: fi.PtrTo("ovn"),
+				FlavorID: fi.PtrTo("flavor"),
+			},
+			changes: &LB{
+				FlavorID: fi.PtrTo("flavor"),
+			},
+			expectedError: fmt.Errorf("LB name: loadbalancer provider \"ovn\" does not support flavors, flavor \"flavor\" cannot be used"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var lb LB
+			err := (&lb).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_NewLBTaskFromCloud_MissingSubnet(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	lb := &loadbalancers.LoadBalancer{
+		ID:          "lb-id",
+		Name:        "api.cluster",
+		VipSubnetID: "deleted-subnet",
+		VipPortID:   "port-id",
+	}
+
+	actual, err := NewLBTaskFromCloud(context.Background(), cloud, fi.LifecycleSync, lb, &LB{Name: fi.PtrTo("api.cluster")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.Subnet != nil {
+		t.Errorf("expected subnet to be unset, got %q", fi.ValueOf(actual.Subnet))
+	}
+	if fi.ValueOf(actual.VipSubnet) != "deleted-subnet" {
+		t.Errorf("expected VIP subnet deleted-subnet, got %q", fi.ValueOf(actual.VipSubnet))
+	}
+}
+
+// stalledNetworkCloud answers the Neutron calls with a server that only returns once the request is cancelled
+type stalledNetworkCloud struct {
+	*openstack.MockCloud
+	server *httptest.Server
+}
+
+func (c *stalledNetworkCloud) NetworkingClient() *gophercloud.ServiceClient {
+	return &gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{},
+		Endpoint:       c.server.URL + "/",
+	}
+}
+
+func Test_LB_Find_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cloud := &stalledNetworkCloud{
+		MockCloud: &openstack.MockCloud{
+			MockLBClient: mockloadbalancer.CreateClient(),
+		},
+		server: server,
+	}
+	if _, err := cloud.CreateLB(loadbalancers.CreateOpts{Name: "api.cluster", VipSubnetID: "subnet-id"}); err != nil {
+		t.Fatalf("error creating loadbalancer: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := fi.NewCloudupContext(ctx, fi.DeletionProcessingModeDeleteIncludingDeferred, nil, nil, cloud, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("error building context: %v", err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := (&LB{Name: fi.PtrTo("api.cluster"), Lifecycle: fi.LifecycleSync}).Find(c)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the lookup to be cancelled, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the lookup did not return after the context was cancelled")
+	}
+}
+
+func Test_LB_Lookups_Deadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// the lookups go through the cloud methods, the networking client of the mock itself is stalled
+	neutron := &mocknetworking.MockClient{}
+	neutron.Server = server
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: neutron,
+	}
+	lb := &LB{
+		Name:         fi.PtrTo("api.cluster"),
+		PortID:       fi.PtrTo("port-id"),
+		VipQosPolicy: fi.PtrTo("policy"),
+	}
+
+	tests := []struct {
+		desc   string
+		lookup func(ctx context.Context) error
+	}{
+		{
+			desc: "port security groups",
+			lookup: func(ctx context.Context) error {
+				_, err := getPortSecurityGroupNames(ctx, cloud, "port-id")
+				return err
+			},
+		},
+		{
+			desc: "security group names",
+			lookup: func(ctx context.Context) error {
+				_, err := securityGroupNames(ctx, cloud, []string{"sg-id"})
+				return err
+			},
+		},
+		{
+			desc: "remaining port security groups",
+			lookup: func(ctx context.Context) error {
+				_, err := remainingPortSecurityGroups(ctx, cloud, lb)
+				return err
+			},
+		},
+		{
+			desc: "QoS policy",
+			lookup: func(ctx context.Context) error {
+				_, err := cloud.FindQosPolicyWithContext(ctx, "policy")
+				return err
+			},
+		},
+		{
+			desc: "VIP port of the loadbalancer",
+			lookup: func(ctx context.Context) error {
+				_, err := NewLBTaskFromCloud(ctx, cloud, fi.LifecycleSync, &loadbalancers.LoadBalancer{
+					ID:        "lb-id",
+					Name:      "api.cluster",
+					VipPortID: "port-id",
+				}, &LB{Name: fi.PtrTo("api.cluster")})
+				return err
+			},
+		},
+		{
+			desc: "QoS policy of the loadbalancer",
+			lookup: func(ctx context.Context) error {
+				_, err := NewLBTaskFromCloud(ctx, cloud, fi.LifecycleSync, &loadbalancers.LoadBalancer{
+					ID:             "lb-id",
+					Name:           "api.cluster",
+					VipQosPolicyID: "policy-id",
+				}, &LB{
+					Name:         fi.PtrTo("api.cluster"),
+					VipPortID:    fi.PtrTo("port-id"),
+					VipQosPolicy: fi.PtrTo("policy"),
+				})
+				return err
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			result := make(chan error, 1)
+			go func() {
+				result <- testCase.lookup(ctx)
+			}()
+			select {
+			case err := <-result:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("expected the lookup to time out, got %v", err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("the lookup did not return after the deadline")
+			}
+		})
+	}
+}
+
+func Test_NewLBTaskFromCloud_Adoption(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	subnet, err := cloud.CreateSubnet(subnets.CreateOpts{
+		Name:       "utility.cluster",
+		NetworkID:  network.ID,
+		CIDR:       "10.0.0.0/24",
+		IPVersion:  gophercloud.IPv4,
+		EnableDHCP: fi.PtrTo(true),
+	})
+	if err != nil {
+		t.Fatalf("error creating subnet: %v", err)
+	}
+	group, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "lb.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	port, err := cloud.CreatePort(ports.CreateOpts{
+		Name:           "vip",
+		NetworkID:      network.ID,
+		SecurityGroups: &[]string{group.ID},
+	})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+	// the loadbalancer predates kOps and is not named like its security group
+	lb := &loadbalancers.LoadBalancer{
+		ID:          "lb-id",
+		Name:        "api.cluster",
+		VipSubnetID: subnet.ID,
+		VipPortID:   port.ID,
+		Provider:    "amphora",
+		FlavorID:    "flavor-id",
+		CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	expected := &LB{
+		Name:      fi.PtrTo("api.cluster"),
+		Subnet:    fi.PtrTo("utility.cluster"),
+		Lifecycle: fi.LifecycleSync,
+		SecurityGroup: &SecurityGroup{
+			ID:        fi.PtrTo(group.ID),
+			Name:      fi.PtrTo("lb.cluster"),
+			Lifecycle: fi.LifecycleSync,
+		},
+		SkipActiveWait:           fi.PtrTo(true),
+		ManagePortSecurityGroups: fi.PtrTo(false),
+	}
+	actual, err := NewLBTaskFromCloud(context.Background(), cloud, fi.LifecycleSync, lb, expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changes := &LB{}
+	if fi.BuildChanges(actual, expected, changes) {
+		t.Errorf("expected no changes for the adopted loadbalancer, got %+v", changes)
+	}
+	if fi.ValueOf(expected.ID) != "lb-id" || fi.ValueOf(expected.PortID) != port.ID || fi.ValueOf(expected.VipSubnet) != subnet.ID {
+		t.Errorf("expected the IDs of the loadbalancer to be adopted, got %q, %q, %q", fi.ValueOf(expected.ID), fi.ValueOf(expected.PortID), fi.ValueOf(expected.VipSubnet))
+	}
+	if fi.ValueOf(expected.Provider) != "amphora" || fi.ValueOf(expected.FlavorID) != "flavor-id" {
+		t.Errorf("expected the provider and flavor to be adopted, got %q, %q", fi.ValueOf(expected.Provider), fi.ValueOf(expected.FlavorID))
+	}
+	if !fi.ValueOf(actual.CreatedAt).Equal(lb.CreatedAt) || !fi.ValueOf(actual.UpdatedAt).Equal(lb.UpdatedAt) {
+		t.Errorf("expected the timestamps of the loadbalancer to be read, got %v, %v", actual.CreatedAt, actual.UpdatedAt)
+	}
+}
+
+func Test_LB_AdminStateUp(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	subnet, err := cloud.CreateSubnet(subnets.CreateOpts{
+		Name:       "utility.cluster",
+		NetworkID:  network.ID,
+		CIDR:       "10.0.0.0/24",
+		IPVersion:  gophercloud.IPv4,
+		EnableDHCP: fi.PtrTo(true),
+	})
+	if err != nil {
+		t.Fatalf("error creating subnet: %v", err)
+	}
+	port, err := cloud.CreatePort(ports.CreateOpts{Name: "vip", NetworkID: network.ID})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+	lb, err := cloud.CreateLB(loadbalancers.CreateOpts{Name: "api.cluster", VipSubnetID: subnet.ID, VipPortID: port.ID})
+	if err != nil {
+		t.Fatalf("error creating loadbalancer: %v", err)
+	}
+
+	e := &LB{
+		Name:         fi.PtrTo("api.cluster"),
+		Subnet:       fi.PtrTo("utility.cluster"),
+		Lifecycle:    fi.LifecycleSync,
+		AdminStateUp: fi.PtrTo(false),
+	}
+	actual, err := e.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fi.ValueOf(actual.AdminStateUp) {
+		t.Fatalf("expected the loadbalancer to be up, got %v", actual.AdminStateUp)
+	}
+
+	changes := &LB{}
+	if !fi.BuildChanges(actual, e, changes) {
+		t.Fatalf("expected the admin state to be changed")
+	}
+	expectedChanges := &LB{AdminStateUp: fi.PtrTo(false)}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("changes differ:\n%+v\n\tinstead of\n%+v", changes, expectedChanges)
+	}
+	if err := (&LB{}).CheckChanges(actual, e, changes); err != nil {
+		t.Fatalf("the admin state must be changed in place: %v", err)
+	}
+
+	if err := (&LB{}).RenderOpenstack(&fi.CloudupContext{}, &openstack.OpenstackAPITarget{Cloud: cloud}, actual, e, changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated, err := cloud.GetLB(lb.ID)
+	if err != nil {
+		t.Fatalf("error getting loadbalancer: %v", err)
+	}
+	if updated.AdminStateUp {
+		t.Errorf("expected the loadbalancer to be down")
+	}
+	if updated.ID != lb.ID {
+		t.Errorf("expected loadbalancer %s to be updated, got %s", lb.ID, updated.ID)
+	}
+}
+
+func Test_LB_VipPortID(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	if _, err := cloud.CreateSubnet(subnets.CreateOpts{
+		Name:       "utility.cluster",
+		NetworkID:  network.ID,
+		CIDR:       "10.0.0.0/24",
+		IPVersion:  gophercloud.IPv4,
+		EnableDHCP: fi.PtrTo(true),
+	}); err != nil {
+		t.Fatalf("error creating subnet: %v", err)
+	}
+	group, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "api.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	userGroup, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "allow-vrrp"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	// the port is created by the user, e.g. with a fixed IP
+	port, err := cloud.CreatePort(ports.CreateOpts{
+		Name:           "vip",
+		NetworkID:      network.ID,
+		SecurityGroups: &[]string{userGroup.ID},
+	})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+
+	newTask := func() *LB {
+		return &LB{
+			Name:          fi.PtrTo("api.cluster"),
+			Subnet:        fi.PtrTo("utility.cluster"),
+			Lifecycle:     fi.LifecycleSync,
+			VipPortID:     fi.PtrTo(port.ID),
+			SecurityGroup: &SecurityGroup{ID: fi.PtrTo(group.ID), Name: fi.PtrTo(group.Name)},
+			Network:       &Network{ID: fi.PtrTo(network.ID), Name: fi.PtrTo(network.Name)},
+		}
+	}
+	e := newTask()
+	if err := (&LB{}).RenderOpenstack(&fi.CloudupContext{}, &openstack.OpenstackAPITarget{Cloud: cloud}, nil, e, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lb, err := cloud.GetLB(fi.ValueOf(e.ID))
+	if err != nil {
+		t.Fatalf("error getting loadbalancer: %v", err)
+	}
+	if lb.VipPortID != port.ID || fi.ValueOf(e.PortID) != port.ID {
+		t.Errorf("expected the loadbalancer to use VIP port %s, got %s", port.ID, lb.VipPortID)
+	}
+	vipPort, err := cloud.GetPort(port.ID)
+	if err != nil {
+		t.Fatalf("error getting port: %v", err)
+	}
+	if !reflect.DeepEqual(vipPort.SecurityGroups, []string{userGroup.ID}) {
+		t.Errorf("expected the security groups of the VIP port to be kept, got %v", vipPort.SecurityGroups)
+	}
+
+	e = newTask()
+	actual, err := e.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fi.ValueOf(actual.VipPortID) != port.ID {
+		t.Errorf("expected VIP port %s to be read back, got %s", port.ID, fi.ValueOf(actual.VipPortID))
+	}
+	changes := &LB{}
+	if fi.BuildChanges(actual, e, changes) {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+
+	e = newTask()
+	e.VipPortID = fi.PtrTo("other-port")
+	actual, err = e.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changes = &LB{}
+	fi.BuildChanges(actual, e, changes)
+	compareErrors(t, (&LB{}).CheckChanges(actual, e, changes), fi.CannotChangeField("VipPortID"))
+}
+
+func Test_LB_VipPortTags(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	// the VIP port is created by Octavia without tags
+	port, err := cloud.CreatePort(ports.CreateOpts{Name: "octavia-lb-vip", NetworkID: network.ID})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+	if _, err := cloud.CreateLB(loadbalancers.CreateOpts{Name: "api.cluster", VipPortID: port.ID}); err != nil {
+		t.Fatalf("error creating loadbalancer: %v", err)
+	}
+
+	context := &fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}}
+	target := &openstack.OpenstackAPITarget{Cloud: cloud}
+	newTask := func() *LB {
+		return &LB{
+			Name:        fi.PtrTo("api.cluster"),
+			Lifecycle:   fi.LifecycleSync,
+			VipPortTags: ClusterTags("cluster"),
+		}
+	}
+	apply := func() (*LB, *LB) {
+		e := newTask()
+		actual, err := e.Find(context)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		changes := &LB{}
+		if fi.BuildChanges(actual, e, changes) {
+			if err := (&LB{}).RenderOpenstack(&fi.CloudupContext{}, target, actual, e, changes); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return actual, changes
+	}
+
+	_, changes := apply()
+	if !reflect.DeepEqual(changes.VipPortTags, ClusterTags("cluster")) {
+		t.Errorf("expected the tags of the VIP port to change, got %v", changes.VipPortTags)
+	}
+	vipPort, err := cloud.GetPort(port.ID)
+	if err != nil {
+		t.Fatalf("error getting port: %v", err)
+	}
+	if !reflect.DeepEqual(vipPort.Tags, ClusterTags("cluster")) {
+		t.Errorf("expected the VIP port to be tagged, got %v", vipPort.Tags)
+	}
+
+	// the tags are reconciled, tags added by others are kept
+	if err := cloud.AppendTag(openstack.ResourceTypePort, port.ID, "policy=api"); err != nil {
+		t.Fatalf("error tagging port: %v", err)
+	}
+	actual, changes := apply()
+	if !reflect.DeepEqual(actual.VipPortTags, ClusterTags("cluster")) || changes.VipPortTags != nil {
+		t.Errorf("expected the tags of the VIP port to be unchanged, got %v", changes.VipPortTags)
+	}
+	vipPort, err = cloud.GetPort(port.ID)
+	if err != nil {
+		t.Fatalf("error getting port: %v", err)
+	}
+	if !reflect.DeepEqual(vipPort.Tags, append(ClusterTags("cluster"), "policy=api")) {
+		t.Errorf("expected the tags added by others to be kept, got %v", vipPort.Tags)
+	}
+
+	// the apply continues if the port cannot be tagged
+	if err := cloud.DeletePort(port.ID); err != nil {
+		t.Fatalf("error deleting port: %v", err)
+	}
+	apply()
+}
+
+func Test_LB_LoadBalancerServiceNotAvailable(t *testing.T) {
+	// the cloud has no load balancer service in its catalog
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	e := &LB{
+		Name:      fi.PtrTo("api.cluster"),
+		Subnet:    fi.PtrTo("utility.cluster"),
+		Lifecycle: fi.LifecycleSync,
+	}
+
+	_, err := e.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+	if !errors.Is(err, openstack.ErrLBServiceNotAvailable) {
+		t.Errorf("expected Find to fail with %q, got %v", openstack.ErrLBServiceNotAvailable, err)
+	}
+	err = (&LB{}).RenderOpenstack(&fi.CloudupContext{}, &openstack.OpenstackAPITarget{Cloud: cloud}, nil, e, nil)
+	if !errors.Is(err, openstack.ErrLBServiceNotAvailable) {
+		t.Errorf("expected RenderOpenstack to fail with %q, got %v", openstack.ErrLBServiceNotAvailable, err)
+	}
+}
+
+func Test_LB_LogValues(t *testing.T) {
+	e := &LB{
+		Name: fi.PtrTo("api.cluster"),
+		ID:   fi.PtrTo("lb-id"),
+		Tags: ClusterTags("cluster"),
+	}
+	expected := []interface{}{"task", "LB/api.cluster", "lb", "api.cluster", "lbID", "lb-id", "cluster", "cluster", "portID", "vip"}
+	if kv := e.logValues("portID", "vip"); !reflect.DeepEqual(kv, expected) {
+		t.Errorf("log values differ:\n%v\n\tinstead of\n%v", kv, expected)
+	}
+
+	// a loadbalancer that was not created yet has no ID
+	expected = []interface{}{"task", "LB/api.cluster", "lb", "api.cluster"}
+	if kv := (&LB{Name: fi.PtrTo("api.cluster")}).logValues(); !reflect.DeepEqual(kv, expected) {
+		t.Errorf("log values differ:\n%v\n\tinstead of\n%v", kv, expected)
+	}
+}
+
+// vipPortCloud creates the loadbalancers with the next VIP port, the mock loadbalancer API does not create ports
+type vipPortCloud struct {
+	*openstack.MockCloud
+	vipPorts []string
+}
+
+func (c *vipPortCloud) CreateLB(opt loadbalancers.CreateOptsBuilder) (*loadbalancers.LoadBalancer, error) {
+	opts := opt.(loadbalancers.CreateOpts)
+	opts.VipPortID, c.vipPorts = c.vipPorts[0], c.vipPorts[1:]
+	return c.MockCloud.CreateLB(opts)
+}
+
+func Test_LB_RecreateOnImmutableChange(t *testing.T) {
+	tests := []struct {
+		desc          string
+		recreate      *bool
+		expectedError error
+	}{
+		{
+			desc:          "a changed subnet is rejected by default",
+			expectedError: fi.CannotChangeField("Subnet"),
+		},
+		{
+			desc:     "the loadbalancer is recreated in the changed subnet",
+			recreate: fi.PtrTo(true),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &vipPortCloud{
+				MockCloud: &openstack.MockCloud{
+					MockNeutronClient: mocknetworking.CreateClient(),
+					MockLBClient:      mockloadbalancer.CreateClient(),
+				},
+			}
+			network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+			if err != nil {
+				t.Fatalf("error creating network: %v", err)
+			}
+			var subnetIDs []string
+			for i, name := range []string{"old.cluster", "new.cluster"} {
+				subnet, err := cloud.CreateSubnet(subnets.CreateOpts{
+					Name:       name,
+					NetworkID:  network.ID,
+					CIDR:       fmt.Sprintf("10.0.%d.0/24", i),
+					IPVersion:  gophercloud.IPv4,
+					EnableDHCP: fi.PtrTo(true),
+				})
+				if err != nil {
+					t.Fatalf("error creating subnet: %v", err)
+				}
+				subnetIDs = append(subnetIDs, subnet.ID)
+				port, err := cloud.CreatePort(ports.CreateOpts{Name: "vip-" + name, NetworkID: network.ID})
+				if err != nil {
+					t.Fatalf("error creating port: %v", err)
+				}
+				cloud.vipPorts = append(cloud.vipPorts, port.ID)
+			}
+			old, err := cloud.CreateLB(loadbalancers.CreateOpts{Name: "api.cluster", VipSubnetID: subnetIDs[0]})
+			if err != nil {
+				t.Fatalf("error creating loadbalancer: %v", err)
+			}
+			fip, err := cloud.CreateL3FloatingIP(l3floatingip.CreateOpts{FloatingNetworkID: "external", FloatingIP: "192.0.2.10", PortID: old.VipPortID})
+			if err != nil {
+				t.Fatalf("error creating floating IP: %v", err)
+			}
+
+			e := &LB{
+				Name:                      fi.PtrTo("api.cluster"),
+				Subnet:                    fi.PtrTo("new.cluster"),
+				Lifecycle:                 fi.LifecycleSync,
+				RecreateOnImmutableChange: testCase.recreate,
+			}
+			actual, err := e.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			changes := &LB{}
+			fi.BuildChanges(actual, e, changes)
+			err = (&LB{}).CheckChanges(actual, e, changes)
+			compareErrors(t, err, testCase.expectedError)
+			if err != nil {
+				return
+			}
+
+			if err := (&LB{}).RenderOpenstack(&fi.CloudupContext{}, &openstack.OpenstackAPITarget{Cloud: cloud}, actual, e, changes); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := cloud.GetLB(old.ID); !openstack.IsNotFound(err) {
+				t.Errorf("expected loadbalancer %s to be deleted, got %v", old.ID, err)
+			}
+			lb, err := cloud.GetLB(fi.ValueOf(e.ID))
+			if err != nil {
+				t.Fatalf("error getting the recreated loadbalancer: %v", err)
+			}
+			if lb.VipSubnetID != subnetIDs[1] {
+				t.Errorf("expected the loadbalancer to be recreated in subnet %s, got %s", subnetIDs[1], lb.VipSubnetID)
+			}
+			if fi.ValueOf(e.PortID) != lb.VipPortID {
+				t.Errorf("expected VIP port %s, got %s", lb.VipPortID, fi.ValueOf(e.PortID))
+			}
+			fips, err := cloud.ListL3FloatingIPs(l3floatingip.ListOpts{PortID: lb.VipPortID})
+			if err != nil {
+				t.Fatalf("error listing floating IPs: %v", err)
+			}
+			if len(fips) != 1 || fips[0].ID != fip.ID {
+				t.Errorf("expected floating IP %s to be associated with the recreated loadbalancer, got %v", fip.FloatingIP, fips)
+			}
+		})
+	}
+}
+
+func Test_GetPortSecurityGroupNames(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	group, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "api.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	port, err := cloud.CreatePort(ports.CreateOpts{
+		Name:           "vip",
+		NetworkID:      "network",
+		SecurityGroups: &[]string{group.ID, "missing"},
+	})
+	if err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+
+	names, err := getPortSecurityGroupNames(context.Background(), cloud, port.ID)
+	if err != nil {
+		t.Fatalf("unexpected error getting security groups: %v", err)
+	}
+	expected := []string{"api.cluster", "missing"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected security groups %v, got %v", expected, names)
+	}
+}
+
+func Test_LB_PortSecurityGroupsWithoutSecurityGroup(t *testing.T) {
+	tests := []struct {
+		desc                     string
+		managePortSecurityGroups *bool
+		expectedNames            []string
+		expectedRemaining        int
+	}{
+		{
+			desc:              "only the loadbalancer security group is removed by default",
+			expectedNames:     []string{"octavia"},
+			expectedRemaining: 1,
+		},
+		{
+			desc:                     "all security groups are removed if the port security groups are managed",
+			managePortSecurityGroups: fi.PtrTo(true),
+			expectedNames:            []string{},
+			expectedRemaining:        0,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockNeutronClient: mocknetworking.CreateClient(),
+			}
+			lbGroup, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "api.cluster"})
+			if err != nil {
+				t.Fatalf("error creating security group: %v", err)
+			}
+			octaviaGroup, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "octavia"})
+			if err != nil {
+				t.Fatalf("error creating security group: %v", err)
+			}
+			port, err := cloud.CreatePort(ports.CreateOpts{
+				Name:           "vip",
+				NetworkID:      "network",
+				SecurityGroups: &[]string{lbGroup.ID, octaviaGroup.ID},
+			})
+			if err != nil {
+				t.Fatalf("error creating port: %v", err)
+			}
+			lb := &loadbalancers.LoadBalancer{
+				ID:          "lb-id",
+				Name:        "api.cluster",
+				VipSubnetID: "subnet-id",
+				VipPortID:   port.ID,
+			}
+			find := &LB{
+				Name:                     fi.PtrTo("api.cluster"),
+				ManagePortSecurityGroups: testCase.managePortSecurityGroups,
+			}
+
+			actual, err := NewLBTaskFromCloud(context.Background(), cloud, fi.LifecycleSync, lb, find)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(find.PortSecurityGroups, testCase.expectedNames) {
+				t.Errorf("expected port security groups %v, got %v", testCase.expectedNames, find.PortSecurityGroups)
+			}
+			if !reflect.DeepEqual(actual.PortSecurityGroups, []string{"api.cluster", "octavia"}) {
+				t.Errorf("expected actual port security groups [api.cluster octavia], got %v", actual.PortSecurityGroups)
+			}
+
+			remaining, err := remainingPortSecurityGroups(context.Background(), cloud, find)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(remaining) != testCase.expectedRemaining {
+				t.Fatalf("expected %d remaining security groups, got %v", testCase.expectedRemaining, remaining)
+			}
+			if len(remaining) == 1 && remaining[0] != octaviaGroup.ID {
+				t.Errorf("expected remaining security group %s, got %s", octaviaGroup.ID, remaining[0])
+			}
+		})
+	}
+}
+
+func Test_WaitLoadbalancerActiveProvisioningStatus(t *testing.T) {
+	tests := []struct {
+		desc           string
+		statuses       []string
+		getError       error
+		expectedStatus string
+		expectedCalls  int
+		expectedError  error
+	}{
+		{
+			desc:           "already active",
+			statuses:       []string{"ACTIVE"},
+			expectedStatus: "ACTIVE",
+			expectedCalls:  1,
+		},
+		{
+			desc:           "active after pending",
+			statuses:       []string{"PENDING_CREATE", "PENDING_CREATE", "ACTIVE"},
+			expectedStatus: "ACTIVE",
+			expectedCalls:  3,
+		},
+		{
+			desc:           "error state",
+			statuses:       []string{"PENDING_UPDATE", "ERROR"},
+			expectedStatus: "ERROR",
+			expectedCalls:  2,
+			expectedError:  fmt.Errorf("loadbalancer has gone into ERROR state"),
+		},
+		{
+			desc:           "timeout",
+			statuses:       []string{"PENDING_CREATE", "PENDING_CREATE", "PENDING_CREATE", "PENDING_CREATE"},
+			expectedStatus: "PENDING_CREATE",
+			expectedCalls:  3,
+			expectedError:  fmt.Errorf("loadbalancer failed to go into ACTIVE provisioning status within allotted time"),
+		},
+		{
+			desc:          "get error",
+			getError:      fmt.Errorf("error getting loadbalancer: 503"),
+			expectedCalls: 1,
+			expectedError: fmt.Errorf("error getting loadbalancer: 503"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			calls := 0
+			getLB := func(loadbalancerID string) (*loadbalancers.LoadBalancer, error) {
+				calls++
+				if testCase.getError != nil {
+					return nil, testCase.getError
+				}
+				return &loadbalancers.LoadBalancer{
+					ID:                 loadbalancerID,
+					ProvisioningStatus: testCase.statuses[calls-1],
+				}, nil
+			}
+			backoff := wait.Backoff{
+				Duration: time.Millisecond,
+				Factor:   1,
+				Steps:    3,
+			}
+
+			status, err := waitLoadbalancerActiveProvisioningStatus(getLB, "lb-id", backoff)
+
+			compareErrors(t, err, testCase.expectedError)
+			if status != testCase.expectedStatus {
+				t.Errorf("expected status %q, got %q", testCase.expectedStatus, status)
+			}
+			if calls != testCase.expectedCalls {
+				t.Errorf("expected %d calls, got %d", testCase.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func Test_WaitForPort(t *testing.T) {
+	notFound := gophercloud.ErrDefault404{}
+	tests := []struct {
+		desc          string
+		errors        []error
+		expectedCalls int
+		expectedError error
+	}{
+		{
+			desc:          "port exists",
+			errors:        []error{nil},
+			expectedCalls: 1,
+		},
+		{
+			desc:          "port exists after not found",
+			errors:        []error{notFound, notFound, nil},
+			expectedCalls: 3,
+		},
+		{
+			desc:          "port not found",
+			errors:        []error{notFound, notFound, notFound},
+			expectedCalls: 3,
+			expectedError: fmt.Errorf("port port-id not found within allotted time"),
+		},
+		{
+			desc:          "get error",
+			errors:        []error{fmt.Errorf("error getting port: 503")},
+			expectedCalls: 1,
+			expectedError: fmt.Errorf("failed to get port port-id: error getting port: 503"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			calls := 0
+			getPort := func(portID string) (*ports.Port, error) {
+				calls++
+				if err := testCase.errors[calls-1]; err != nil {
+					return nil, err
+				}
+				return &ports.Port{ID: portID}, nil
+			}
+			backoff := wait.Backoff{
+				Duration: time.Millisecond,
+				Factor:   1,
+				Steps:    3,
+			}
+
+			err := waitForPort(getPort, "port-id", backoff)
+
+			compareErrors(t, err, testCase.expectedError)
+			if calls != testCase.expectedCalls {
+				t.Errorf("expected %d calls, got %d", testCase.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func Test_WaitLoadbalancerActive_SkipActiveWait(t *testing.T) {
+	tests := []struct {
+		desc           string
+		status         string
+		expectedError  error
+		expectTryAgain bool
+	}{
+		{
+			desc:   "active",
+			status: "ACTIVE",
+		},
+		{
+			desc:           "pending",
+			status:         "PENDING_CREATE",
+			expectTryAgain: true,
+		},
+		{
+			desc:          "error state",
+			status:        "ERROR",
+			expectedError: fmt.Errorf("loadbalancer has gone into ERROR state"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			calls := 0
+			getLB := func(loadbalancerID string) (*loadbalancers.LoadBalancer, error) {
+				calls++
+				return &loadbalancers.LoadBalancer{
+					ID:                 loadbalancerID,
+					ProvisioningStatus: testCase.status,
+				}, nil
+			}
+			lb := &LB{
+				ID:             fi.PtrTo("lb-id"),
+				SkipActiveWait: fi.PtrTo(true),
+			}
+
+			err := waitLoadbalancerActive(getLB, lb)
+
+			if testCase.expectTryAgain {
+				if _, ok := err.(*fi.TryAgainLaterError); !ok {
+					t.Errorf("expected TryAgainLaterError, got %v", err)
+				}
+			} else {
+				compareErrors(t, err, testCase.expectedError)
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 call, got %d", calls)
+			}
+		})
+	}
+}
+
+// recordWrites records the requests of the mock server that modify resources
+func recordWrites(server *httptest.Server, mutex *sync.Mutex, writes *[]string) {
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			mutex.Lock()
+			*writes = append(*writes, r.Method+" "+r.URL.Path)
+			mutex.Unlock()
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func Test_LB_DryRun(t *testing.T) {
+	tests := []struct {
+		desc       string
+		existingLB bool
+	}{
+		{
+			desc: "the loadbalancer is not created",
+		},
+		{
+			desc:       "the security groups of the VIP port are not updated",
+			existingLB: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockNeutronClient: mocknetworking.CreateClient(),
+				MockLBClient:      mockloadbalancer.CreateClient(),
+			}
+			if _, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "api.cluster"}); err != nil {
+				t.Fatalf("error creating security group: %v", err)
+			}
+			port, err := cloud.CreatePort(ports.CreateOpts{
+				Name:      "vip",
+				NetworkID: "network",
+			})
+			if err != nil {
+				t.Fatalf("error creating port: %v", err)
+			}
+			if testCase.existingLB {
+				if _, err := cloud.CreateLB(loadbalancers.CreateOpts{
+					Name:        "api.cluster",
+					VipSubnetID: "subnet-id",
+					VipPortID:   port.ID,
+				}); err != nil {
+					t.Fatalf("error creating loadbalancer: %v", err)
+				}
+			}
+
+			var mutex sync.Mutex
+			var writes []string
+			recordWrites(cloud.MockNeutronClient.Server, &mutex, &writes)
+			recordWrites(cloud.MockLBClient.Server, &mutex, &writes)
+
+			lb := &LB{
+				Name:      fi.PtrTo("api.cluster"),
+				Subnet:    fi.PtrTo("subnet"),
+				Lifecycle: fi.LifecycleSync,
+				SecurityGroup: &SecurityGroup{
+					Name:      fi.PtrTo("api.cluster"),
+					Lifecycle: fi.LifecycleSync,
+				},
+			}
+			allTasks := map[string]fi.CloudupTask{"LB/api.cluster": lb}
+
+			assetBuilder := assets.NewAssetBuilder(vfs.Context, nil, "v1.29.0", false)
+			var out bytes.Buffer
+			target := fi.NewCloudupDryRunTarget(assetBuilder, &out)
+			ctx, err := fi.NewCloudupContext(context.TODO(), fi.DeletionProcessingModeDeleteIncludingDeferred, target, nil, cloud, nil, nil, nil, allTasks)
+			if err != nil {
+				t.Fatalf("error building context: %v", err)
+			}
+			if err := ctx.RunTasks(fi.RunTasksOptions{
+				MaxTaskDuration:         2 * time.Second,
+				WaitAfterAllTasksFailed: 500 * time.Millisecond,
+			}); err != nil {
+				t.Fatalf("unexpected error during Run: %v", err)
+			}
+
+			if len(writes) != 0 {
+				t.Errorf("expected no write requests in dry-run, got %v", writes)
+			}
+			if !target.HasChanges() {
+				t.Errorf("expected the dry-run to report changes")
+			}
+			if err := target.PrintReport(allTasks, &out); err != nil {
+				t.Fatalf("error building report: %v", err)
+			}
+			if !strings.Contains(out.String(), "api.cluster") {
+				t.Errorf("expected the report to contain the loadbalancer, got %q", out.String())
+			}
+		})
+	}
+}
+
+func Test_LB_VIPSubnetOfOtherNetwork(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	clusterNetwork, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	otherNetwork, err := cloud.CreateNetwork(networks.CreateOpts{Name: "other"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	if _, err := cloud.CreateSubnet(subnets.CreateOpts{
+		Name:       "utility.cluster",
+		NetworkID:  otherNetwork.ID,
+		CIDR:       "10.0.0.0/24",
+		IPVersion:  gophercloud.IPv4,
+		EnableDHCP: fi.PtrTo(true),
+	}); err != nil {
+		t.Fatalf("error creating subnet: %v", err)
+	}
+
+	lb := &LB{
+		Name:      fi.PtrTo("api.cluster"),
+		Subnet:    fi.PtrTo("utility.cluster"),
+		Lifecycle: fi.LifecycleSync,
+		Network: &Network{
+			Name: fi.PtrTo("cluster"),
+			ID:   fi.PtrTo(clusterNetwork.ID),
+		},
+	}
+	err = lb.RenderOpenstack(&fi.CloudupContext{}, &openstack.OpenstackAPITarget{Cloud: cloud}, nil, lb, nil)
+	if err == nil || !strings.Contains(err.Error(), "not to the cluster network") {
+		t.Fatalf("expected an error about the network of the VIP subnet, got %v", err)
+	}
+
+	lbs, err := cloud.ListLBs(loadbalancers.ListOpts{})
+	if err != nil {
+		t.Fatalf("error listing loadbalancers: %v", err)
+	}
+	if len(lbs) != 0 {
+		t.Errorf("expected no loadbalancer to be created, got %v", lbs)
+	}
+}
+
+func Test_LB_FlavorOfOtherProvider(t *testing.T) {
+	tests := []struct {
+		desc          string
+		provider      string
+		expectedError error
+	}{
+		{
+			desc:     "flavor of the provider",
+			provider: "amphora",
+		},
+		{
+			desc:          "flavor of another provider",
+			provider:      "amphorav2",
+			expectedError: fmt.Errorf("LB api.cluster: loadbalancer flavor large (flavor-id) uses flavor profile amphora-large of provider \"amphora\", it cannot be used with provider \"amphorav2\""),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			lbClient := mockloadbalancer.CreateClient()
+			lbClient.Mux.HandleFunc("/lbaas/flavors/flavor-id", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"flavor": {"id": "flavor-id", "name": "large", "enabled": true, "flavor_profile_id": "profile-id"}}`))
+			})
+			lbClient.Mux.HandleFunc("/lbaas/flavorprofiles/profile-id", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"flavorprofile": {"id": "profile-id", "name": "amphora-large", "provider_name": "amphora"}}`))
+			})
+			cloud := &openstack.MockCloud{
+				MockLBClient: lbClient,
+			}
+			lb := &LB{
+				Name:      fi.PtrTo("api.cluster"),
+				Lifecycle: fi.LifecycleSync,
+				Provider:  fi.PtrTo(testCase.provider),
+				FlavorID:  fi.PtrTo("flavor-id"),
+			}
+
+			// the flavor is checked when the plan is built, before anything is created
+			actual, err := lb.Find(&fi.CloudupContext{T: fi.CloudupSubContext{Cloud: cloud}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != nil {
+				t.Fatalf("expected the loadbalancer not to exist, got %v", actual)
+			}
+			err = (&LB{}).CheckChanges(nil, lb, lb)
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_LoadbalancerActiveBackoff(t *testing.T) {
+	total := func(backoff wait.Backoff) time.Duration {
+		var d time.Duration
+		// wait.ExponentialBackoff sleeps between the steps
+		for backoff.Steps > 1 {
+			d += backoff.Step()
+		}
+		return d
+	}
+
+	withoutJitter := loadbalancerActiveBackoff
+	withoutJitter.Jitter = 0
+	minimum := total(withoutJitter)
+	maximum := time.Duration(float64(minimum) * (1 + loadbalancerActiveJitter))
+
+	if loadbalancerActiveBackoff.Jitter == 0 {
+		t.Fatalf("expected the backoff to be jittered")
+	}
+	delays := map[time.Duration]bool{}
+	for i := 0; i < 10; i++ {
+		d := total(loadbalancerActiveBackoff)
+		if d < minimum || d > maximum {
+			t.Errorf("expected the total delay to be between %v and %v, got %v", minimum, maximum, d)
+		}
+		delays[d] = true
+	}
+	if len(delays) == 1 {
+		t.Errorf("expected the total delay to vary, got %v", delays)
+	}
+}
+
+func Test_FindLBEndpoint(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	lb, err := cloud.CreateLB(loadbalancers.CreateOpts{
+		Name:       "api.cluster",
+		VipAddress: "10.0.0.10",
+		VipPortID:  "vip-port",
+	})
+	if err != nil {
+		t.Fatalf("error creating loadbalancer: %v", err)
+	}
+	for _, opts := range []l3floatingip.CreateOpts{
+		{FloatingNetworkID: "external", FloatingIP: "192.0.2.10", PortID: "vip-port"},
+		{FloatingNetworkID: "external", FloatingIP: "192.0.2.20", PortID: "other-port"},
+	} {
+		if _, err := cloud.CreateL3FloatingIP(opts); err != nil {
+			t.Fatalf("error creating floating IP: %v", err)
+		}
+	}
+
+	tests := []struct {
+		desc     string
+		lb       *LB
+		expected *LBEndpoint
+	}{
+		{
+			desc: "loadbalancer by name",
+			lb:   &LB{Name: fi.PtrTo("api.cluster"), Lifecycle: fi.LifecycleSync},
+			expected: &LBEndpoint{
+				Name:        "api.cluster",
+				VIP:         "10.0.0.10",
+				FloatingIPs: []string{"192.0.2.10"},
+			},
+		},
+		{
+			desc: "loadbalancer by previous name",
+			lb:   &LB{Name: fi.PtrTo("api.example.com"), PreviousName: fi.PtrTo("api.cluster"), Lifecycle: fi.LifecycleSync},
+			expected: &LBEndpoint{
+				Name:        "api.cluster",
+				VIP:         "10.0.0.10",
+				FloatingIPs: []string{"192.0.2.10"},
+			},
+		},
+		{
+			desc: "shared loadbalancer by ID",
+			lb:   &LB{Name: fi.PtrTo("api.example.com"), ID: fi.PtrTo(lb.ID), Lifecycle: fi.LifecycleExistsAndWarnIfChanges},
+			expected: &LBEndpoint{
+				Name:        "api.cluster",
+				VIP:         "10.0.0.10",
+				FloatingIPs: []string{"192.0.2.10"},
+			},
+		},
+		{
+			desc:     "loadbalancer not found",
+			lb:       &LB{Name: fi.PtrTo("api.example.com"), Lifecycle: fi.LifecycleSync},
+			expected: nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			actual, err := FindLBEndpoint(cloud, testCase.lb)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %+v, got %+v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_FindCloudLB_DuplicateNames(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	subnetIDs := map[string]string{}
+	for i, name := range []string{"subnet.cluster", "subnet.other"} {
+		subnet, err := cloud.CreateSubnet(subnets.CreateOpts{
+			Name:       name,
+			NetworkID:  network.ID,
+			CIDR:       fmt.Sprintf("10.0.%d.0/24", i),
+			IPVersion:  gophercloud.IPv4,
+			EnableDHCP: fi.PtrTo(true),
+		})
+		if err != nil {
+			t.Fatalf("error creating subnet: %v", err)
+		}
+		subnetIDs[name] = subnet.ID
+	}
+	// the loadbalancers of two clusters share a name and a subnet, a third one is in another subnet
+	lbIDs := map[string]string{}
+	for key, opts := range map[string]loadbalancers.CreateOpts{
+		"cluster": {Name: "api.cluster", VipSubnetID: subnetIDs["subnet.cluster"], Tags: ClusterTags("cluster")},
+		"other":   {Name: "api.cluster", VipSubnetID: subnetIDs["subnet.cluster"], Tags: ClusterTags("other")},
+		"subnet":  {Name: "api.cluster", VipSubnetID: subnetIDs["subnet.other"]},
+	} {
+		lb, err := cloud.CreateLB(opts)
+		if err != nil {
+			t.Fatalf("error creating loadbalancer: %v", err)
+		}
+		lbIDs[key] = lb.ID
+	}
+
+	tests := []struct {
+		desc          string
+		lb            *LB
+		expectedLB    string
+		expectedError string
+	}{
+		{
+			desc:       "loadbalancer by subnet",
+			lb:         &LB{Name: fi.PtrTo("api.cluster"), Subnet: fi.PtrTo("subnet.other")},
+			expectedLB: "subnet",
+		},
+		{
+			desc:       "loadbalancer by VIP subnet",
+			lb:         &LB{Name: fi.PtrTo("api.cluster"), VipSubnet: fi.PtrTo(subnetIDs["subnet.other"])},
+			expectedLB: "subnet",
+		},
+		{
+			desc:       "loadbalancer by subnet and tags",
+			lb:         &LB{Name: fi.PtrTo("api.cluster"), Subnet: fi.PtrTo("subnet.cluster"), Tags: ClusterTags("cluster")},
+			expectedLB: "cluster",
+		},
+		{
+			desc: "no loadbalancer in the subnet",
+			lb:   &LB{Name: fi.PtrTo("api.cluster"), Subnet: fi.PtrTo("subnet.missing")},
+		},
+		{
+			desc:          "ambiguous loadbalancers",
+			lb:            &LB{Name: fi.PtrTo("api.cluster"), Subnet: fi.PtrTo("subnet.cluster")},
+			expectedError: "Multiple load balancers for name api.cluster: ",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			testCase.lb.Lifecycle = fi.LifecycleSync
+			lb, err := findCloudLB(cloud, testCase.lb)
+			if testCase.expectedError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), testCase.expectedError) {
+					t.Fatalf("expected error %q, got %v", testCase.expectedError, err)
+				}
+				// the candidates are listed
+				for _, key := range []string{"cluster", "other"} {
+					if !strings.Contains(err.Error(), lbIDs[key]) {
+						t.Errorf("expected loadbalancer %s to be listed in error %q", lbIDs[key], err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if testCase.expectedLB == "" {
+				if lb != nil {
+					t.Errorf("expected no loadbalancer, got %s", lb.ID)
+				}
+				return
+			}
+			if lb == nil || lb.ID != lbIDs[testCase.expectedLB] {
+				t.Errorf("expected loadbalancer %s, got %+v", lbIDs[testCase.expectedLB], lb)
+			}
+		})
+	}
+}
+
+func Test_FindExistingLB(t *testing.T) {
+	createErr := errors.New("error creating loadbalancer: conflict")
+	tests := []struct {
+		desc        string
+		subnet      string
+		tags        []string
+		expectedID  bool
+		expectedErr error
+	}{
+		{
+			desc:       "loadbalancer in the subnet with the tags",
+			subnet:     "subnet-id",
+			tags:       []string{"KubernetesCluster=cluster"},
+			expectedID: true,
+		},
+		{
+			desc:        "loadbalancer in another subnet",
+			subnet:      "other-subnet-id",
+			tags:        []string{"KubernetesCluster=cluster"},
+			expectedErr: createErr,
+		},
+		{
+			desc:        "loadbalancer without the tags",
+			subnet:      "subnet-id",
+			tags:        []string{"KubernetesCluster=other"},
+			expectedErr: createErr,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			cloud := &openstack.MockCloud{
+				MockLBClient: mockloadbalancer.CreateClient(),
+			}
+			existing, err := cloud.CreateLB(loadbalancers.CreateOpts{
+				Name:        "api.cluster",
+				VipSubnetID: testCase.subnet,
+				Tags:        testCase.tags,
+			})
+			if err != nil {
+				t.Fatalf("error creating loadbalancer: %v", err)
+			}
+			e := &LB{
+				Name:      fi.PtrTo("api.cluster"),
+				VipSubnet: fi.PtrTo("subnet-id"),
+				Tags:      []string{"KubernetesCluster=cluster"},
+			}
+
+			lb, err := findExistingLB(cloud, e, createErr)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if testCase.expectedID && (lb == nil || lb.ID != existing.ID) {
+				t.Errorf("expected loadbalancer %s to be adopted, got %v", existing.ID, lb)
+			}
+			if !testCase.expectedID && lb != nil {
+				t.Errorf("expected no loadbalancer to be adopted, got %s", lb.ID)
+			}
+		})
+	}
+}
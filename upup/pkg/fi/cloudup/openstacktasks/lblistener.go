@@ -17,10 +17,12 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
 	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/listeners"
+	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
@@ -34,42 +36,122 @@ type LBListener struct {
 	Pool         *LBPool
 	Lifecycle    fi.Lifecycle
 	AllowedCIDRs []string
+	// DefaultTLSContainerRef is the Barbican secret container with the certificate of a TERMINATED_HTTPS listener,
+	// the listener forwards plain TCP if it is not set
+	DefaultTLSContainerRef *string
+	// SNIContainerRefs are the Barbican secret containers with additional certificates selected by SNI
+	SNIContainerRefs []string
+	// ConnLimit is the maximum number of concurrent connections of the listener, -1 is unlimited
+	ConnLimit *int
+	// InsertHeaders are the headers inserted into the requests by HTTP and TERMINATED_HTTPS listeners, e.g.
+	// "X-Forwarded-For": "true"
+	InsertHeaders map[string]string
+	// TimeoutClientData is the idle timeout of the client connections in milliseconds
+	TimeoutClientData *int
+	// TimeoutMemberData is the idle timeout of the member connections in milliseconds
+	TimeoutMemberData *int
+	// TimeoutMemberConnect is the timeout of connecting to a member in milliseconds
+	TimeoutMemberConnect *int
+	// TimeoutTCPInspect is the time to wait for additional TCP packets for content inspection in milliseconds
+	TimeoutTCPInspect *int
+}
+
+// protocol returns the protocol of the listener, TLS is terminated if the listener has a certificate
+func (e *LBListener) protocol() listeners.Protocol {
+	if e.DefaultTLSContainerRef != nil {
+		return listeners.ProtocolTerminatedHTTPS
+	}
+	return listeners.ProtocolTCP
+}
+
+// listenerTimeout is a timeout of the listener with its name in the Octavia API
+type listenerTimeout struct {
+	name    string
+	timeout *int
+}
+
+// timeouts returns the timeouts of the listener
+func (e *LBListener) timeouts() []listenerTimeout {
+	return []listenerTimeout{
+		{name: "timeout_client_data", timeout: e.TimeoutClientData},
+		{name: "timeout_member_data", timeout: e.TimeoutMemberData},
+		{name: "timeout_member_connect", timeout: e.TimeoutMemberConnect},
+		{name: "timeout_tcp_inspect", timeout: e.TimeoutTCPInspect},
+	}
+}
+
+// supportsTimeouts returns true if the timeouts can be set on listeners of the protocol, UDP and SCTP listeners do
+// not have client and member connections
+func supportsTimeouts(protocol listeners.Protocol) bool {
+	return protocol != listeners.ProtocolUDP && protocol != listeners.ProtocolSCTP
 }
 
 // GetDependencies returns the dependencies of the Instance task
 func (e *LBListener) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 	var deps []fi.CloudupTask
 	for _, task := range tasks {
-		if _, ok := task.(*LB); ok {
+		if e.Pool != nil && isLoadbalancer(task, e.Pool.Loadbalancer) {
 			deps = append(deps, task)
 		}
-		if _, ok := task.(*LBPool); ok {
+		if isPool(task, e.Pool) {
 			deps = append(deps, task)
 		}
 	}
 	return deps
 }
 
+var _ fi.HasPlanParent[fi.CloudupSubContext] = &LBListener{}
+
+// PlanParent returns the loadbalancer of the listener
+func (e *LBListener) PlanParent(tasks map[string]fi.CloudupTask) fi.CloudupTask {
+	if e.Pool == nil {
+		return nil
+	}
+	return planParent(tasks, func(task fi.CloudupTask) bool {
+		return isLoadbalancer(task, e.Pool.Loadbalancer)
+	})
+}
+
 var _ fi.CompareWithID = &LBListener{}
 
 func (s *LBListener) CompareWithID() *string {
 	return s.ID
 }
 
-func NewLBListenerTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, listener *listeners.Listener, find *LBListener) (*LBListener, error) {
+func NewLBListenerTaskFromCloud(ctx context.Context, cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, listener *listeners.Listener, find *LBListener) (*LBListener, error) {
 	// sort for consistent comparison
 	sort.Strings(listener.AllowedCIDRs)
 	listenerTask := &LBListener{
-		ID:           fi.PtrTo(listener.ID),
-		Name:         fi.PtrTo(listener.Name),
-		Port:         fi.PtrTo(listener.ProtocolPort),
-		AllowedCIDRs: listener.AllowedCIDRs,
-		Lifecycle:    lifecycle,
+		ID:                   fi.PtrTo(listener.ID),
+		Name:                 fi.PtrTo(listener.Name),
+		Port:                 fi.PtrTo(listener.ProtocolPort),
+		AllowedCIDRs:         listener.AllowedCIDRs,
+		ConnLimit:            fi.PtrTo(listener.ConnLimit),
+		Lifecycle:            lifecycle,
+		TimeoutClientData:    fi.PtrTo(listener.TimeoutClientData),
+		TimeoutMemberData:    fi.PtrTo(listener.TimeoutMemberData),
+		TimeoutMemberConnect: fi.PtrTo(listener.TimeoutMemberConnect),
+		TimeoutTCPInspect:    fi.PtrTo(listener.TimeoutTCPInspect),
+	}
+	if listener.DefaultTlsContainerRef != "" {
+		listenerTask.DefaultTLSContainerRef = fi.PtrTo(listener.DefaultTlsContainerRef)
+	}
+	// an empty map is returned if no headers are inserted
+	listenerTask.InsertHeaders = listener.InsertHeaders
+	if len(listener.InsertHeaders) == 0 && find != nil && len(find.InsertHeaders) == 0 {
+		listenerTask.InsertHeaders = find.InsertHeaders
+	}
+	// the order of the SNI containers is not relevant
+	if find != nil && find.SNIContainerRefs != nil {
+		listenerTask.SNIContainerRefs = listener.SniContainerRefs
+		if sameElements(listener.SniContainerRefs, find.SNIContainerRefs) {
+			listenerTask.SNIContainerRefs = find.SNIContainerRefs
+		}
 	}
 
 	if len(listener.Pools) > 0 {
 		for _, pool := range listener.Pools {
-			poolTask, err := NewLBPoolTaskFromCloud(cloud, lifecycle, &pool, find.Pool)
+			poolTask, err := NewLBPoolTaskFromCloud(ctx, cloud, lifecycle, &pool, find.Pool)
 			if err != nil {
 				return nil, fmt.Errorf("NewLBListenerTaskFromCloud: Failed to create new LBListener task for pool %s: %v", pool.Name, err)
 			} else {
@@ -83,7 +165,7 @@ func NewLBListenerTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lif
 		if err != nil {
 			return nil, fmt.Errorf("Fail to get pool with ID: %s: %v", listener.DefaultPoolID, err)
 		}
-		poolTask, err := NewLBPoolTaskFromCloud(cloud, lifecycle, pool, find.Pool)
+		poolTask, err := NewLBPoolTaskFromCloud(ctx, cloud, lifecycle, pool, find.Pool)
 		if err != nil {
 			return nil, fmt.Errorf("NewLBListenerTaskFromCloud: Failed to create new LBListener task for pool %s: %v", pool.Name, err)
 		}
@@ -109,7 +191,7 @@ func (s *LBListener) Find(context *fi.CloudupContext) (*LBListener, error) {
 		Name: fi.ValueOf(s.Name),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("Failed to list loadbalancer listeners for name %s: %v", fi.ValueOf(s.Name), err)
+		return nil, fmt.Errorf("Failed to list loadbalancer listeners for name %s: %w", fi.ValueOf(s.Name), err)
 	}
 	if len(listenerList) == 0 {
 		return nil, nil
@@ -118,7 +200,7 @@ func (s *LBListener) Find(context *fi.CloudupContext) (*LBListener, error) {
 		return nil, fmt.Errorf("Multiple listeners found with name %s", fi.ValueOf(s.Name))
 	}
 
-	return NewLBListenerTaskFromCloud(cloud, s.Lifecycle, &listenerList[0], s)
+	return NewLBListenerTaskFromCloud(taskContext(context), cloud, s.Lifecycle, &listenerList[0], s)
 }
 
 func (s *LBListener) Run(context *fi.CloudupContext) error {
@@ -137,11 +219,47 @@ func (_ *LBListener) CheckChanges(a, e, changes *LBListener) error {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
 		}
+		// the certificate can be rotated, but the protocol of the listener cannot be changed
+		if changes.DefaultTLSContainerRef != nil && a.DefaultTLSContainerRef == nil {
+			return fmt.Errorf("LBListener %s forwards TCP and cannot be changed to terminate TLS", fi.ValueOf(e.Name))
+		}
+	}
+	if len(e.SNIContainerRefs) > 0 && e.DefaultTLSContainerRef == nil {
+		return fmt.Errorf("LBListener %s has SNI containers but no default TLS container", fi.ValueOf(e.Name))
+	}
+	if e.ConnLimit != nil && fi.ValueOf(e.ConnLimit) < -1 {
+		return fmt.Errorf("LBListener %s has invalid connection limit %d, must be -1 for unlimited or greater", fi.ValueOf(e.Name), fi.ValueOf(e.ConnLimit))
+	}
+	for _, t := range e.timeouts() {
+		if t.timeout == nil {
+			continue
+		}
+		if fi.ValueOf(t.timeout) < 0 {
+			return fmt.Errorf("LBListener %s has invalid %s %d, must not be negative", fi.ValueOf(e.Name), t.name, fi.ValueOf(t.timeout))
+		}
+		if protocol := e.protocol(); !supportsTimeouts(protocol) {
+			return fmt.Errorf("LBListener %s forwards %s, %s cannot be set", fi.ValueOf(e.Name), protocol, t.name)
+		}
+	}
+	if e.DefaultTLSContainerRef != nil && e.Pool != nil && fi.ValueOf(e.Pool.Protocol) != string(v2pools.ProtocolHTTP) {
+		return fmt.Errorf("LBListener %s terminates TLS and requires a pool with protocol %s", fi.ValueOf(e.Name), v2pools.ProtocolHTTP)
+	}
+	if len(e.InsertHeaders) > 0 {
+		if protocol := e.protocol(); protocol != listeners.ProtocolHTTP && protocol != listeners.ProtocolTerminatedHTTPS {
+			return fmt.Errorf("LBListener %s forwards %s, headers can only be inserted by %s and %s listeners", fi.ValueOf(e.Name), protocol, listeners.ProtocolHTTP, listeners.ProtocolTerminatedHTTPS)
+		}
+		for header, value := range e.InsertHeaders {
+			if value != "true" && value != "false" {
+				return fmt.Errorf("LBListener %s has invalid value %q for inserted header %s, must be \"true\" or \"false\"", fi.ValueOf(e.Name), value, header)
+			}
+		}
 	}
 	return nil
 }
 
 func (_ *LBListener) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LBListener) error {
+	defer openstack.RecordRender("LBListener", fi.ValueOf(e.Name))()
+
 	useVIPACL, err := t.Cloud.UseLoadBalancerVIPACL()
 	if err != nil {
 		return err
@@ -150,16 +268,26 @@ func (_ *LBListener) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, chan
 	if a == nil {
 		klog.V(2).Infof("Creating LB with Name: %q", fi.ValueOf(e.Name))
 		listeneropts := listeners.CreateOpts{
-			Name:           fi.ValueOf(e.Name),
-			DefaultPoolID:  fi.ValueOf(e.Pool.ID),
-			LoadbalancerID: fi.ValueOf(e.Pool.Loadbalancer.ID),
-			Protocol:       listeners.ProtocolTCP,
-			ProtocolPort:   fi.ValueOf(e.Port),
+			Name:                 fi.ValueOf(e.Name),
+			DefaultPoolID:        fi.ValueOf(e.Pool.ID),
+			LoadbalancerID:       fi.ValueOf(e.Pool.Loadbalancer.ID),
+			Protocol:             e.protocol(),
+			ProtocolPort:         fi.ValueOf(e.Port),
+			ConnLimit:            e.ConnLimit,
+			InsertHeaders:        e.InsertHeaders,
+			TimeoutClientData:    e.TimeoutClientData,
+			TimeoutMemberData:    e.TimeoutMemberData,
+			TimeoutMemberConnect: e.TimeoutMemberConnect,
+			TimeoutTCPInspect:    e.TimeoutTCPInspect,
 		}
 
-		if useVIPACL && (fi.ValueOf(e.Pool.Loadbalancer.Provider) != "ovn") {
+		if useVIPACL && openstack.GetLBProviderCapabilities(fi.ValueOf(e.Pool.Loadbalancer.Provider)).AllowedCIDRs {
 			listeneropts.AllowedCIDRs = e.AllowedCIDRs
 		}
+		if e.DefaultTLSContainerRef != nil {
+			listeneropts.DefaultTlsContainerRef = fi.ValueOf(e.DefaultTLSContainerRef)
+			listeneropts.SniContainerRefs = e.SNIContainerRefs
+		}
 
 		listener, err := t.Cloud.CreateListener(listeneropts)
 		if err != nil {
@@ -167,18 +295,65 @@ func (_ *LBListener) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, chan
 		}
 		e.ID = fi.PtrTo(listener.ID)
 		return nil
-	} else if len(changes.AllowedCIDRs) > 0 {
-		if useVIPACL && (fi.ValueOf(a.Pool.Loadbalancer.Provider) != "ovn") {
-			opts := listeners.UpdateOpts{
-				AllowedCIDRs: &changes.AllowedCIDRs,
-			}
-			_, err := listeners.Update(t.Cloud.LoadBalancerClient(), fi.ValueOf(a.ID), opts).Extract()
-			if err != nil {
-				return fmt.Errorf("error updating LB listener: %v", err)
-			}
+	}
+
+	opts := listeners.UpdateOpts{}
+	update := false
+	if len(changes.AllowedCIDRs) > 0 {
+		if useVIPACL && openstack.GetLBProviderCapabilities(fi.ValueOf(a.Pool.Loadbalancer.Provider)).AllowedCIDRs {
+			opts.AllowedCIDRs = &changes.AllowedCIDRs
+			update = true
 		} else {
 			klog.V(2).Infof("Openstack Octavia VIPACLs not supported")
 		}
+	}
+	if changes.DefaultTLSContainerRef != nil {
+		klog.V(2).Infof("Rotating certificate of LB listener %s", fi.ValueOf(a.ID))
+		opts.DefaultTlsContainerRef = e.DefaultTLSContainerRef
+		update = true
+	}
+	if changes.SNIContainerRefs != nil {
+		opts.SniContainerRefs = &e.SNIContainerRefs
+		update = true
+	}
+	// the connection limit is updated in place
+	if changes.ConnLimit != nil {
+		klog.V(2).Infof("Updating connection limit of LB listener %s from %d to %d", fi.ValueOf(a.ID), fi.ValueOf(a.ConnLimit), fi.ValueOf(e.ConnLimit))
+		opts.ConnLimit = e.ConnLimit
+		update = true
+	}
+	// the timeouts are updated in place, e.g. to keep long running watches open
+	if changes.TimeoutClientData != nil {
+		klog.V(2).Infof("Updating client data timeout of LB listener %s from %d to %d", fi.ValueOf(a.ID), fi.ValueOf(a.TimeoutClientData), fi.ValueOf(e.TimeoutClientData))
+		opts.TimeoutClientData = e.TimeoutClientData
+		update = true
+	}
+	if changes.TimeoutMemberData != nil {
+		klog.V(2).Infof("Updating member data timeout of LB listener %s from %d to %d", fi.ValueOf(a.ID), fi.ValueOf(a.TimeoutMemberData), fi.ValueOf(e.TimeoutMemberData))
+		opts.TimeoutMemberData = e.TimeoutMemberData
+		update = true
+	}
+	if changes.TimeoutMemberConnect != nil {
+		klog.V(2).Infof("Updating member connect timeout of LB listener %s from %d to %d", fi.ValueOf(a.ID), fi.ValueOf(a.TimeoutMemberConnect), fi.ValueOf(e.TimeoutMemberConnect))
+		opts.TimeoutMemberConnect = e.TimeoutMemberConnect
+		update = true
+	}
+	if changes.TimeoutTCPInspect != nil {
+		klog.V(2).Infof("Updating TCP inspect timeout of LB listener %s from %d to %d", fi.ValueOf(a.ID), fi.ValueOf(a.TimeoutTCPInspect), fi.ValueOf(e.TimeoutTCPInspect))
+		opts.TimeoutTCPInspect = e.TimeoutTCPInspect
+		update = true
+	}
+	// all inserted headers are replaced, an empty map stops inserting headers
+	if changes.InsertHeaders != nil {
+		klog.V(2).Infof("Updating inserted headers of LB listener %s to %v", fi.ValueOf(a.ID), e.InsertHeaders)
+		opts.InsertHeaders = &e.InsertHeaders
+		update = true
+	}
+	if update {
+		_, err := listeners.Update(t.Cloud.LoadBalancerClient(), fi.ValueOf(a.ID), opts).Extract()
+		if err != nil {
+			return fmt.Errorf("error updating LB listener: %v", err)
+		}
 		return nil
 	}
 	klog.V(2).Infof("Openstack task LB::RenderOpenstack did nothing")
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/listeners"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func Test_LBListener_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *LBListener
+		expected      *LBListener
+		changes       *LBListener
+		expectedError error
+	}{
+		{
+			desc: "actual nil terminating TLS with HTTP pool",
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("container"),
+				SNIContainerRefs:       []string{"sni"},
+				Pool:                   &LBPool{Protocol: fi.PtrTo("HTTP")},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil terminating TLS with TCP pool",
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("container"),
+				Pool:                   &LBPool{},
+			},
+			expectedError: fmt.Errorf("LBListener listener terminates TLS and requires a pool with protocol HTTP"),
+		},
+		{
+			desc: "actual nil SNI containers without default container",
+			expected: &LBListener{
+				Name:             fi.PtrTo("listener"),
+				SNIContainerRefs: []string{"sni"},
+				Pool:             &LBPool{},
+			},
+			expectedError: fmt.Errorf("LBListener listener has SNI containers but no default TLS container"),
+		},
+		{
+			desc: "actual not nil certificate rotated",
+			actual: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("old"),
+			},
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("new"),
+				Pool:                   &LBPool{Protocol: fi.PtrTo("HTTP")},
+			},
+			changes: &LBListener{
+				DefaultTLSContainerRef: fi.PtrTo("new"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil TCP listener changed to terminate TLS",
+			actual: &LBListener{
+				Name: fi.PtrTo("listener"),
+			},
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("new"),
+				Pool:                   &LBPool{Protocol: fi.PtrTo("HTTP")},
+			},
+			changes: &LBListener{
+				DefaultTLSContainerRef: fi.PtrTo("new"),
+			},
+			expectedError: fmt.Errorf("LBListener listener forwards TCP and cannot be changed to terminate TLS"),
+		},
+		{
+			desc: "actual not nil unlimited connections changed to a limit",
+			actual: &LBListener{
+				Name:      fi.PtrTo("listener"),
+				ConnLimit: fi.PtrTo(-1),
+			},
+			expected: &LBListener{
+				Name:      fi.PtrTo("listener"),
+				ConnLimit: fi.PtrTo(1000),
+			},
+			changes: &LBListener{
+				ConnLimit: fi.PtrTo(1000),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil invalid connection limit",
+			expected: &LBListener{
+				Name:      fi.PtrTo("listener"),
+				ConnLimit: fi.PtrTo(-2),
+			},
+			expectedError: fmt.Errorf("LBListener listener has invalid connection limit -2, must be -1 for unlimited or greater"),
+		},
+		{
+			desc: "actual not nil idle timeouts raised in place",
+			actual: &LBListener{
+				Name:              fi.PtrTo("listener"),
+				TimeoutClientData: fi.PtrTo(50000),
+				TimeoutMemberData: fi.PtrTo(50000),
+			},
+			expected: &LBListener{
+				Name:              fi.PtrTo("listener"),
+				TimeoutClientData: fi.PtrTo(3600000),
+				TimeoutMemberData: fi.PtrTo(3600000),
+			},
+			changes: &LBListener{
+				TimeoutClientData: fi.PtrTo(3600000),
+				TimeoutMemberData: fi.PtrTo(3600000),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil negative timeout",
+			expected: &LBListener{
+				Name:                 fi.PtrTo("listener"),
+				TimeoutMemberConnect: fi.PtrTo(-1),
+			},
+			expectedError: fmt.Errorf("LBListener listener has invalid timeout_member_connect -1, must not be negative"),
+		},
+		{
+			desc: "actual nil terminating TLS with inserted headers",
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("container"),
+				Pool:                   &LBPool{Protocol: fi.PtrTo("HTTP")},
+				InsertHeaders:          map[string]string{"X-Forwarded-For": "true", "X-Forwarded-Proto": "true"},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil TCP listener with inserted headers",
+			expected: &LBListener{
+				Name:          fi.PtrTo("listener"),
+				Pool:          &LBPool{},
+				InsertHeaders: map[string]string{"X-Forwarded-For": "true"},
+			},
+			expectedError: fmt.Errorf("LBListener listener forwards TCP, headers can only be inserted by HTTP and TERMINATED_HTTPS listeners"),
+		},
+		{
+			desc: "actual nil invalid inserted header value",
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("container"),
+				Pool:                   &LBPool{Protocol: fi.PtrTo("HTTP")},
+				InsertHeaders:          map[string]string{"X-Forwarded-For": "yes"},
+			},
+			expectedError: fmt.Errorf("LBListener listener has invalid value \"yes\" for inserted header X-Forwarded-For, must be \"true\" or \"false\""),
+		},
+		{
+			desc: "actual not nil inserted headers removed",
+			actual: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("container"),
+				InsertHeaders:          map[string]string{"X-Forwarded-For": "true"},
+			},
+			expected: &LBListener{
+				Name:                   fi.PtrTo("listener"),
+				DefaultTLSContainerRef: fi.PtrTo("container"),
+				Pool:                   &LBPool{Protocol: fi.PtrTo("HTTP")},
+				InsertHeaders:          map[string]string{},
+			},
+			changes: &LBListener{
+				InsertHeaders: map[string]string{},
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var listener LBListener
+			err := (&listener).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_SupportsTimeouts(t *testing.T) {
+	tests := []struct {
+		protocol listeners.Protocol
+		expected bool
+	}{
+		{protocol: listeners.ProtocolTCP, expected: true},
+		{protocol: listeners.ProtocolTerminatedHTTPS, expected: true},
+		{protocol: listeners.ProtocolUDP, expected: false},
+		{protocol: listeners.ProtocolSCTP, expected: false},
+	}
+	for _, testCase := range tests {
+		t.Run(string(testCase.protocol), func(t *testing.T) {
+			if actual := supportsTimeouts(testCase.protocol); actual != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
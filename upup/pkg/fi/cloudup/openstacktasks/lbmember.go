@@ -0,0 +1,282 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+
+	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+	"k8s.io/utils/net"
+)
+
+// lbMemberMaxWeight is the maximum weight of an Octavia pool member
+const lbMemberMaxWeight = 256
+
+// +kops:fitask
+type LBMember struct {
+	ID        *string
+	Name      *string
+	Lifecycle fi.Lifecycle
+	Pool      *LBPool
+	// Instance is the server of the member, the address of its port is used if Address is not set
+	Instance *Instance
+	// Port is the port of the member, its first fixed IP is used if Address is not set
+	Port *Port
+	// Address is the IP address of the member
+	Address      *string
+	ProtocolPort *int
+	// Weight is the share of the connections sent to the member, 0 drains the member: existing connections are kept,
+	// new connections are sent to the other members
+	Weight *int
+	// SubnetID is the subnet of the member address, defaults to the subnet of the port of the member or, if the
+	// member has no port, to the VIP subnet of the loadbalancer. Members of a pool can be in different subnets.
+	SubnetID *string
+}
+
+// GetDependencies returns the dependencies of the LBMember task
+func (e *LBMember) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
+	var deps []fi.CloudupTask
+	for _, task := range tasks {
+		if e.Pool != nil && isLoadbalancer(task, e.Pool.Loadbalancer) {
+			deps = append(deps, task)
+		}
+		if isPool(task, e.Pool) {
+			deps = append(deps, task)
+		}
+		if t, ok := task.(*Instance); ok && e.Instance != nil && fi.ValueOf(t.Name) == fi.ValueOf(e.Instance.Name) {
+			deps = append(deps, task)
+		}
+		if t, ok := task.(*Port); ok && e.memberPort() != nil && fi.ValueOf(t.Name) == fi.ValueOf(e.memberPort().Name) {
+			deps = append(deps, task)
+		}
+	}
+	return deps
+}
+
+var _ fi.HasPlanParent[fi.CloudupSubContext] = &LBMember{}
+
+// PlanParent returns the pool of the member
+func (e *LBMember) PlanParent(tasks map[string]fi.CloudupTask) fi.CloudupTask {
+	return planParent(tasks, func(task fi.CloudupTask) bool {
+		return isPool(task, e.Pool)
+	})
+}
+
+var _ fi.CompareWithID = &LBMember{}
+
+func (e *LBMember) CompareWithID() *string {
+	return e.ID
+}
+
+// memberPort returns the port whose address is the address of the member
+func (e *LBMember) memberPort() *Port {
+	if e.Port != nil {
+		return e.Port
+	}
+	if e.Instance != nil {
+		return e.Instance.Port
+	}
+	return nil
+}
+
+// findMemberAddress returns the first fixed IP of the port of the member and its subnet, it returns empty strings if
+// the port does not exist yet.
+func findMemberAddress(cloud openstack.OpenstackCloud, e *LBMember) (string, string, error) {
+	port := e.memberPort()
+	if port == nil || port.ID == nil {
+		return "", "", nil
+	}
+	p, err := cloud.GetPort(fi.ValueOf(port.ID))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get port %s: %v", fi.ValueOf(port.ID), err)
+	}
+	if len(p.FixedIPs) == 0 {
+		return "", "", fmt.Errorf("port %s of pool member %s has no fixed IP", p.ID, fi.ValueOf(e.Name))
+	}
+	return p.FixedIPs[0].IPAddress, p.FixedIPs[0].SubnetID, nil
+}
+
+// checkMemberSubnet returns an error if the subnet of a pool member does not exist or does not contain the address of
+// the member. Octavia plugs the loadbalancer into the subnet of every member, so a member in another subnet than the
+// VIP is reachable from the pool as long as its address is in its subnet.
+func checkMemberSubnet(cloud openstack.OpenstackCloud, name, subnetID, address string) error {
+	subs, err := cloud.ListSubnets(subnets.ListOpts{ID: subnetID})
+	if err != nil {
+		return fmt.Errorf("failed to get subnet %s of pool member %s: %w", subnetID, name, err)
+	}
+	if len(subs) == 0 {
+		return fmt.Errorf("subnet %s of pool member %s does not exist", subnetID, name)
+	}
+	subnet := subs[0]
+	_, cidr, err := net.ParseCIDRSloppy(subnet.CIDR)
+	if err != nil {
+		return fmt.Errorf("subnet %s of pool member %s has invalid CIDR %q: %w", subnetID, name, subnet.CIDR, err)
+	}
+	if ip := net.ParseIPSloppy(address); ip == nil || !cidr.Contains(ip) {
+		return fmt.Errorf("address %s of pool member %s is not in subnet %s (%s)", address, name, subnetID, subnet.CIDR)
+	}
+	return nil
+}
+
+func (e *LBMember) Find(c *fi.CloudupContext) (*LBMember, error) {
+	if e == nil || e.Name == nil || e.Pool == nil || e.Pool.ID == nil {
+		return nil, nil
+	}
+	cloud := c.T.Cloud.(openstack.OpenstackCloud)
+
+	if e.Address == nil {
+		address, _, err := findMemberAddress(cloud, e)
+		if err != nil {
+			return nil, err
+		}
+		if address != "" {
+			e.Address = fi.PtrTo(address)
+		}
+	}
+
+	members, err := cloud.ListPoolMembers(fi.ValueOf(e.Pool.ID), v2pools.ListMembersOpts{
+		Name: fi.ValueOf(e.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	if len(members) > 1 {
+		return nil, fmt.Errorf("found multiple members with name %s in pool %s", fi.ValueOf(e.Name), fi.ValueOf(e.Pool.ID))
+	}
+	member := members[0]
+
+	actual := &LBMember{
+		ID:           fi.PtrTo(member.ID),
+		Name:         fi.PtrTo(member.Name),
+		Lifecycle:    e.Lifecycle,
+		Pool:         e.Pool,
+		Instance:     e.Instance,
+		Port:         e.Port,
+		Address:      fi.PtrTo(member.Address),
+		ProtocolPort: fi.PtrTo(member.ProtocolPort),
+		Weight:       fi.PtrTo(member.Weight),
+		SubnetID:     fi.PtrTo(member.SubnetID),
+	}
+	e.ID = actual.ID
+
+	return actual, nil
+}
+
+func (e *LBMember) Run(c *fi.CloudupContext) error {
+	return fi.CloudupDefaultDeltaRunMethod(e, c)
+}
+
+func (_ *LBMember) CheckChanges(a, e, changes *LBMember) error {
+	if a == nil {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+		if e.Pool == nil {
+			return fi.RequiredField("Pool")
+		}
+		if e.ProtocolPort == nil {
+			return fi.RequiredField("ProtocolPort")
+		}
+		if e.Address == nil && e.memberPort() == nil {
+			return fmt.Errorf("LBMember %s must have an address, an instance or a port", fi.ValueOf(e.Name))
+		}
+	} else {
+		if changes.ID != nil {
+			return fi.CannotChangeField("ID")
+		}
+		if changes.Name != nil {
+			return fi.CannotChangeField("Name")
+		}
+		if changes.Address != nil {
+			return fi.CannotChangeField("Address")
+		}
+		if changes.ProtocolPort != nil {
+			return fi.CannotChangeField("ProtocolPort")
+		}
+		if changes.SubnetID != nil {
+			return fi.CannotChangeField("SubnetID")
+		}
+	}
+	if e.Weight != nil && (fi.ValueOf(e.Weight) < 0 || fi.ValueOf(e.Weight) > lbMemberMaxWeight) {
+		return fmt.Errorf("LBMember %s has invalid weight %d, must be between 0 and %d", fi.ValueOf(e.Name), fi.ValueOf(e.Weight), lbMemberMaxWeight)
+	}
+	return nil
+}
+
+func (_ *LBMember) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LBMember) error {
+	defer openstack.RecordRender("LBMember", fi.ValueOf(e.Name))()
+
+	if a == nil {
+		if e.Address == nil || e.SubnetID == nil {
+			address, subnetID, err := findMemberAddress(t.Cloud, e)
+			if err != nil {
+				return err
+			}
+			if e.Address == nil {
+				if address == "" {
+					return fmt.Errorf("address of LBMember %s is not known", fi.ValueOf(e.Name))
+				}
+				e.Address = fi.PtrTo(address)
+			}
+			if e.SubnetID == nil && subnetID != "" && address == fi.ValueOf(e.Address) {
+				e.SubnetID = fi.PtrTo(subnetID)
+			}
+		}
+		if e.SubnetID != nil {
+			if err := checkMemberSubnet(t.Cloud, fi.ValueOf(e.Name), fi.ValueOf(e.SubnetID), fi.ValueOf(e.Address)); err != nil {
+				return err
+			}
+		}
+
+		klog.V(2).Infof("Creating LBMember with Name: %q", fi.ValueOf(e.Name))
+
+		opts := v2pools.CreateMemberOpts{
+			Name:         fi.ValueOf(e.Name),
+			Address:      fi.ValueOf(e.Address),
+			ProtocolPort: fi.ValueOf(e.ProtocolPort),
+			Weight:       e.Weight,
+			SubnetID:     fi.ValueOf(e.SubnetID),
+		}
+		member, err := t.Cloud.CreatePoolMember(fi.ValueOf(e.Pool.ID), opts)
+		if err != nil {
+			return fmt.Errorf("error creating LBMember: %v", err)
+		}
+		e.ID = fi.PtrTo(member.ID)
+
+		return nil
+	}
+
+	if changes.Weight != nil {
+		klog.V(2).Infof("Updating LBMember %q weight to %d", fi.ValueOf(e.Name), fi.ValueOf(e.Weight))
+
+		_, err := t.Cloud.UpdateMemberInPool(fi.ValueOf(e.Pool.ID), fi.ValueOf(a.ID), v2pools.UpdateMemberOpts{
+			Weight: e.Weight,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating LBMember: %v", err)
+		}
+	}
+
+	return nil
+}
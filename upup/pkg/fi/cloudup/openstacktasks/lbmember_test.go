@@ -0,0 +1,243 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_LBMember_GetDependencies(t *testing.T) {
+	tasks := map[string]fi.CloudupTask{
+		"foo":  &LB{Name: fi.PtrTo("api")},
+		"bar":  &LBPool{Name: fi.PtrTo("api-https")},
+		"baz":  &LBPool{Name: fi.PtrTo("other-https")},
+		"qux":  &Instance{Name: fi.PtrTo("master-1")},
+		"quux": &Instance{Name: fi.PtrTo("master-2")},
+		"port": &Port{Name: fi.PtrTo("port-master-1")},
+	}
+
+	member := &LBMember{
+		Name: fi.PtrTo("master-1"),
+		Pool: &LBPool{
+			Name:         fi.PtrTo("api-https"),
+			Loadbalancer: &LB{Name: fi.PtrTo("api")},
+		},
+		Instance: &Instance{
+			Name: fi.PtrTo("master-1"),
+			Port: &Port{Name: fi.PtrTo("port-master-1")},
+		},
+	}
+
+	actual := member.GetDependencies(tasks)
+
+	expected := []fi.CloudupTask{
+		&LB{Name: fi.PtrTo("api")},
+		&LBPool{Name: fi.PtrTo("api-https")},
+		&Instance{Name: fi.PtrTo("master-1")},
+		&Port{Name: fi.PtrTo("port-master-1")},
+	}
+
+	actualSorted := sortedTasks(actual)
+	expectedSorted := sortedTasks(expected)
+	sort.Sort(actualSorted)
+	sort.Sort(expectedSorted)
+
+	if !reflect.DeepEqual(expectedSorted, actualSorted) {
+		t.Errorf("Dependencies differ:\n%v\n\tinstead of\n%v", actualSorted, expectedSorted)
+	}
+}
+
+func Test_LBMember_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *LBMember
+		expected      *LBMember
+		changes       *LBMember
+		expectedError error
+	}{
+		{
+			desc:   "actual nil all required fields set",
+			actual: nil,
+			expected: &LBMember{
+				Name:         fi.PtrTo("master-1"),
+				Pool:         &LBPool{Name: fi.PtrTo("api-https")},
+				Address:      fi.PtrTo("10.0.0.10"),
+				ProtocolPort: fi.PtrTo(443),
+				Weight:       fi.PtrTo(1),
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil address from port",
+			actual: nil,
+			expected: &LBMember{
+				Name:         fi.PtrTo("master-1"),
+				Pool:         &LBPool{Name: fi.PtrTo("api-https")},
+				Port:         &Port{Name: fi.PtrTo("port-master-1")},
+				ProtocolPort: fi.PtrTo(443),
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil pool not set",
+			actual: nil,
+			expected: &LBMember{
+				Name:         fi.PtrTo("master-1"),
+				Address:      fi.PtrTo("10.0.0.10"),
+				ProtocolPort: fi.PtrTo(443),
+			},
+			expectedError: fi.RequiredField("Pool"),
+		},
+		{
+			desc:   "actual nil no address, instance or port",
+			actual: nil,
+			expected: &LBMember{
+				Name:         fi.PtrTo("master-1"),
+				Pool:         &LBPool{Name: fi.PtrTo("api-https")},
+				ProtocolPort: fi.PtrTo(443),
+			},
+			expectedError: fmt.Errorf("LBMember master-1 must have an address, an instance or a port"),
+		},
+		{
+			desc:   "actual nil weight too high",
+			actual: nil,
+			expected: &LBMember{
+				Name:         fi.PtrTo("master-1"),
+				Pool:         &LBPool{Name: fi.PtrTo("api-https")},
+				Address:      fi.PtrTo("10.0.0.10"),
+				ProtocolPort: fi.PtrTo(443),
+				Weight:       fi.PtrTo(257),
+			},
+			expectedError: fmt.Errorf("LBMember master-1 has invalid weight 257, must be between 0 and 256"),
+		},
+		{
+			desc: "actual not nil draining member",
+			actual: &LBMember{
+				Name:   fi.PtrTo("master-1"),
+				Weight: fi.PtrTo(1),
+			},
+			expected: &LBMember{
+				Name:   fi.PtrTo("master-1"),
+				Weight: fi.PtrTo(0),
+			},
+			changes: &LBMember{
+				Weight: fi.PtrTo(0),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil unchangeable field Address set",
+			actual: &LBMember{
+				Name:    fi.PtrTo("master-1"),
+				Address: fi.PtrTo("10.0.0.10"),
+			},
+			expected: &LBMember{
+				Name:    fi.PtrTo("master-1"),
+				Address: fi.PtrTo("10.0.0.11"),
+			},
+			changes: &LBMember{
+				Address: fi.PtrTo("10.0.0.11"),
+			},
+			expectedError: fi.CannotChangeField("Address"),
+		},
+		{
+			desc: "actual not nil unchangeable field SubnetID set",
+			actual: &LBMember{
+				Name:     fi.PtrTo("master-1"),
+				SubnetID: fi.PtrTo("subnet-a"),
+			},
+			expected: &LBMember{
+				Name:     fi.PtrTo("master-1"),
+				SubnetID: fi.PtrTo("subnet-b"),
+			},
+			changes: &LBMember{
+				SubnetID: fi.PtrTo("subnet-b"),
+			},
+			expectedError: fi.CannotChangeField("SubnetID"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var member LBMember
+			err := (&member).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_CheckMemberSubnet(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	subnet, err := cloud.CreateSubnet(subnets.CreateOpts{
+		Name:       "backends-b",
+		NetworkID:  network.ID,
+		CIDR:       "10.1.0.0/24",
+		IPVersion:  gophercloud.IPv4,
+		EnableDHCP: fi.PtrTo(true),
+	})
+	if err != nil {
+		t.Fatalf("error creating subnet: %v", err)
+	}
+
+	tests := []struct {
+		desc          string
+		subnetID      string
+		address       string
+		expectedError error
+	}{
+		{
+			desc:     "address in the subnet",
+			subnetID: subnet.ID,
+			address:  "10.1.0.10",
+		},
+		{
+			desc:          "address outside the subnet",
+			subnetID:      subnet.ID,
+			address:       "10.2.0.10",
+			expectedError: fmt.Errorf("address 10.2.0.10 of pool member master-1 is not in subnet %s (10.1.0.0/24)", subnet.ID),
+		},
+		{
+			desc:          "missing subnet",
+			subnetID:      "missing",
+			address:       "10.1.0.10",
+			expectedError: fmt.Errorf("subnet missing of pool member master-1 does not exist"),
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			err := checkMemberSubnet(cloud, "master-1", testCase.subnetID, testCase.address)
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
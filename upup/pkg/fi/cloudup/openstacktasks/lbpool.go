@@ -17,6 +17,7 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"context"
 	"fmt"
 
 	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
@@ -31,26 +32,72 @@ type LBPool struct {
 	Name         *string
 	Lifecycle    fi.Lifecycle
 	Loadbalancer *LB
+	// LBMethod is the load balancing algorithm of the pool, defaults to the algorithm supported by the provider
+	LBMethod *string
+	// Protocol is the protocol to the members, defaults to TCP. Listeners terminating TLS require HTTP.
+	Protocol *string
+	// SessionPersistence is the session persistence of the pool, an empty type disables session persistence
+	SessionPersistence *LBSessionPersistence
+}
+
+// LBSessionPersistence is the session persistence of a pool
+type LBSessionPersistence struct {
+	// Type is SOURCE_IP, HTTP_COOKIE or APP_COOKIE
+	Type string
+	// CookieName is the name of the application cookie of APP_COOKIE persistence
+	CookieName string
+}
+
+// poolUpdateOpts disables the session persistence of a pool by sending it as null, which UpdateOpts omits
+type poolUpdateOpts struct {
+	v2pools.UpdateOpts
+	clearPersistence bool
+}
+
+func (opts poolUpdateOpts) ToPoolUpdateMap() (map[string]interface{}, error) {
+	b, err := opts.UpdateOpts.ToPoolUpdateMap()
+	if err != nil {
+		return nil, err
+	}
+	if opts.clearPersistence {
+		b["pool"].(map[string]interface{})["session_persistence"] = nil
+	}
+	return b, nil
 }
 
 // GetDependencies returns the dependencies of the Instance task
 func (e *LBPool) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 	var deps []fi.CloudupTask
 	for _, task := range tasks {
-		if _, ok := task.(*LB); ok {
+		if isLoadbalancer(task, e.Loadbalancer) {
 			deps = append(deps, task)
 		}
 	}
 	return deps
 }
 
+var _ fi.HasPlanParent[fi.CloudupSubContext] = &LBPool{}
+
+// PlanParent returns the listener of the pool, or its loadbalancer if no listener uses the pool
+func (e *LBPool) PlanParent(tasks map[string]fi.CloudupTask) fi.CloudupTask {
+	if listener := planParent(tasks, func(task fi.CloudupTask) bool {
+		t, ok := task.(*LBListener)
+		return ok && isPool(e, t.Pool)
+	}); listener != nil {
+		return listener
+	}
+	return planParent(tasks, func(task fi.CloudupTask) bool {
+		return isLoadbalancer(task, e.Loadbalancer)
+	})
+}
+
 var _ fi.CompareWithID = &LBPool{}
 
 func (s *LBPool) CompareWithID() *string {
 	return s.ID
 }
 
-func NewLBPoolTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, pool *v2pools.Pool, find *LBPool) (*LBPool, error) {
+func NewLBPoolTaskFromCloud(ctx context.Context, cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle, pool *v2pools.Pool, find *LBPool) (*LBPool, error) {
 	if len(pool.Loadbalancers) > 1 {
 		return nil, fmt.Errorf("Openstack cloud pools with multiple loadbalancers not yet supported!")
 	}
@@ -59,6 +106,12 @@ func NewLBPoolTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecyc
 		ID:        fi.PtrTo(pool.ID),
 		Name:      fi.PtrTo(pool.Name),
 		Lifecycle: lifecycle,
+		LBMethod:  fi.PtrTo(pool.LBMethod),
+		Protocol:  fi.PtrTo(pool.Protocol),
+		SessionPersistence: &LBSessionPersistence{
+			Type:       pool.Persistence.Type,
+			CookieName: pool.Persistence.CookieName,
+		},
 	}
 	if len(pool.Loadbalancers) == 1 {
 		lbID := pool.Loadbalancers[0]
@@ -66,7 +119,7 @@ func NewLBPoolTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecyc
 		if err != nil {
 			return nil, fmt.Errorf("NewLBPoolTaskFromCloud: Failed to get lb with id %s: %v", lbID.ID, err)
 		}
-		loadbalancerTask, err := NewLBTaskFromCloud(cloud, lifecycle, lb, nil)
+		loadbalancerTask, err := NewLBTaskFromCloud(ctx, cloud, lifecycle, lb, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -91,7 +144,7 @@ func (p *LBPool) Find(context *fi.CloudupContext) (*LBPool, error) {
 		Name: fi.ValueOf(p.Name),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("Failed to list pools: %v", err)
+		return nil, fmt.Errorf("Failed to list pools: %w", err)
 	}
 	if len(poolList) == 0 {
 		return nil, nil
@@ -100,7 +153,7 @@ func (p *LBPool) Find(context *fi.CloudupContext) (*LBPool, error) {
 		return nil, fmt.Errorf("Multiple pools found for name %s", fi.ValueOf(p.Name))
 	}
 
-	return NewLBPoolTaskFromCloud(cloud, p.Lifecycle, &poolList[0], p)
+	return NewLBPoolTaskFromCloud(taskContext(context), cloud, p.Lifecycle, &poolList[0], p)
 }
 
 func (s *LBPool) Run(context *fi.CloudupContext) error {
@@ -119,22 +172,41 @@ func (_ *LBPool) CheckChanges(a, e, changes *LBPool) error {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
 		}
+		if changes.Protocol != nil {
+			return fi.CannotChangeField("Protocol")
+		}
+	}
+	if e.SessionPersistence != nil && e.SessionPersistence.Type != "" {
+		if err := openstack.ValidateLBSessionPersistence(e.SessionPersistence.Type, e.SessionPersistence.CookieName); err != nil {
+			return fmt.Errorf("LBPool %s: %w", fi.ValueOf(e.Name), err)
+		}
+	} else if e.SessionPersistence != nil && e.SessionPersistence.CookieName != "" {
+		return fmt.Errorf("LBPool %s: cookie name %q requires session persistence type APP_COOKIE", fi.ValueOf(e.Name), e.SessionPersistence.CookieName)
+	}
+	if e.LBMethod != nil && e.Loadbalancer != nil && e.Loadbalancer.Provider != nil {
+		if err := openstack.ValidateLBProviderMethod(fi.ValueOf(e.Loadbalancer.Provider), fi.ValueOf(e.LBMethod)); err != nil {
+			return fmt.Errorf("LBPool %s: %w", fi.ValueOf(e.Name), err)
+		}
 	}
 	return nil
 }
 
 func (_ *LBPool) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *LBPool) error {
+	defer openstack.RecordRender("LBPool", fi.ValueOf(e.Name))()
+
 	if a == nil {
 
 		// wait that lb is in ACTIVE state
-		provisioningStatus, err := waitLoadbalancerActiveProvisioningStatus(t.Cloud.LoadBalancerClient(), fi.ValueOf(e.Loadbalancer.ID))
-		if err != nil {
-			return fmt.Errorf("failed to loadbalancer ACTIVE provisioning status %v: %v", provisioningStatus, err)
+		if err := waitLoadbalancerActive(t.Cloud.GetLB, e.Loadbalancer); err != nil {
+			return err
 		}
 
 		LbMethod := v2pools.LBMethodRoundRobin
-		if fi.ValueOf(e.Loadbalancer.Provider) == "ovn" {
-			LbMethod = v2pools.LBMethodSourceIpPort
+		if methods := openstack.GetLBProviderCapabilities(fi.ValueOf(e.Loadbalancer.Provider)).LBMethods; len(methods) > 0 {
+			LbMethod = v2pools.LBMethod(methods[0])
+		}
+		if e.LBMethod != nil {
+			LbMethod = v2pools.LBMethod(fi.ValueOf(e.LBMethod))
 		}
 		poolopts := v2pools.CreateOpts{
 			Name:           fi.ValueOf(e.Name),
@@ -142,6 +214,15 @@ func (_ *LBPool) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 			Protocol:       v2pools.ProtocolTCP,
 			LoadbalancerID: fi.ValueOf(e.Loadbalancer.ID),
 		}
+		if e.Protocol != nil {
+			poolopts.Protocol = v2pools.Protocol(fi.ValueOf(e.Protocol))
+		}
+		if e.SessionPersistence != nil && e.SessionPersistence.Type != "" {
+			poolopts.Persistence = &v2pools.SessionPersistence{
+				Type:       e.SessionPersistence.Type,
+				CookieName: e.SessionPersistence.CookieName,
+			}
+		}
 		pool, err := t.Cloud.CreatePool(poolopts)
 		if err != nil {
 			return fmt.Errorf("error creating LB pool: %v", err)
@@ -151,6 +232,33 @@ func (_ *LBPool) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 		return nil
 	}
 
+	opts := poolUpdateOpts{}
+	update := false
+	if changes.LBMethod != nil {
+		klog.V(2).Infof("Updating LB pool %s algorithm from %s to %s", fi.ValueOf(a.ID), fi.ValueOf(a.LBMethod), fi.ValueOf(e.LBMethod))
+		opts.LBMethod = v2pools.LBMethod(fi.ValueOf(e.LBMethod))
+		update = true
+	}
+	if changes.SessionPersistence != nil {
+		klog.V(2).Infof("Updating LB pool %s session persistence from %+v to %+v", fi.ValueOf(a.ID), a.SessionPersistence, e.SessionPersistence)
+		if e.SessionPersistence.Type == "" {
+			opts.clearPersistence = true
+		} else {
+			opts.Persistence = &v2pools.SessionPersistence{
+				Type:       e.SessionPersistence.Type,
+				CookieName: e.SessionPersistence.CookieName,
+			}
+		}
+		update = true
+	}
+	if update {
+		_, err := v2pools.Update(t.Cloud.LoadBalancerClient(), fi.ValueOf(a.ID), opts).Extract()
+		if err != nil {
+			return fmt.Errorf("error updating LB pool: %v", err)
+		}
+		return nil
+	}
+
 	klog.V(2).Infof("Openstack task LB::RenderOpenstack did nothing")
 	return nil
 }
@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func Test_LBPool_GetDependencies(t *testing.T) {
+	tasks := map[string]fi.CloudupTask{
+		"foo": &LB{Name: fi.PtrTo("api")},
+		"bar": &LB{Name: fi.PtrTo("other")},
+		"baz": &LBPool{Name: fi.PtrTo("other-https")},
+	}
+
+	pool := &LBPool{
+		Name:         fi.PtrTo("api-https"),
+		Loadbalancer: &LB{Name: fi.PtrTo("api")},
+	}
+
+	actual := pool.GetDependencies(tasks)
+
+	expected := []fi.CloudupTask{
+		&LB{Name: fi.PtrTo("api")},
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Dependencies differ:\n%v\n\tinstead of\n%v", actual, expected)
+	}
+}
+
+func Test_LBListener_GetDependencies(t *testing.T) {
+	tasks := map[string]fi.CloudupTask{
+		"foo": &LB{Name: fi.PtrTo("api")},
+		"bar": &LB{Name: fi.PtrTo("other")},
+		"baz": &LBPool{Name: fi.PtrTo("api-https")},
+		"qux": &LBPool{Name: fi.PtrTo("other-https")},
+	}
+
+	listener := &LBListener{
+		Name: fi.PtrTo("api"),
+		Pool: &LBPool{
+			Name:         fi.PtrTo("api-https"),
+			Loadbalancer: &LB{Name: fi.PtrTo("api")},
+		},
+	}
+
+	actual := listener.GetDependencies(tasks)
+
+	expected := []fi.CloudupTask{
+		&LB{Name: fi.PtrTo("api")},
+		&LBPool{Name: fi.PtrTo("api-https")},
+	}
+
+	actualSorted := sortedTasks(actual)
+	expectedSorted := sortedTasks(expected)
+	sort.Sort(actualSorted)
+	sort.Sort(expectedSorted)
+
+	if !reflect.DeepEqual(expectedSorted, actualSorted) {
+		t.Errorf("Dependencies differ:\n%v\n\tinstead of\n%v", actualSorted, expectedSorted)
+	}
+}
+
+func Test_LBPool_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *LBPool
+		expected      *LBPool
+		changes       *LBPool
+		expectedError error
+	}{
+		{
+			desc: "actual nil supported algorithm",
+			expected: &LBPool{
+				Name:         fi.PtrTo("pool"),
+				LBMethod:     fi.PtrTo("SOURCE_IP_PORT"),
+				Loadbalancer: &LB{Provider: fi.PtrTo("ovn")},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil algorithm not supported by provider",
+			expected: &LBPool{
+				Name:         fi.PtrTo("pool"),
+				LBMethod:     fi.PtrTo("ROUND_ROBIN"),
+				Loadbalancer: &LB{Provider: fi.PtrTo("ovn")},
+			},
+			expectedError: fmt.Errorf("LBPool pool: loadbalancer provider \"ovn\" does not support the ROUND_ROBIN algorithm, supported algorithms are [SOURCE_IP_PORT]"),
+		},
+		{
+			desc: "actual nil provider unknown",
+			expected: &LBPool{
+				Name:         fi.PtrTo("pool"),
+				LBMethod:     fi.PtrTo("ROUND_ROBIN"),
+				Loadbalancer: &LB{},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil algorithm changed",
+			actual: &LBPool{
+				Name:     fi.PtrTo("pool"),
+				LBMethod: fi.PtrTo("ROUND_ROBIN"),
+			},
+			expected: &LBPool{
+				Name:         fi.PtrTo("pool"),
+				LBMethod:     fi.PtrTo("LEAST_CONNECTIONS"),
+				Loadbalancer: &LB{Provider: fi.PtrTo("amphora")},
+			},
+			changes: &LBPool{
+				LBMethod: fi.PtrTo("LEAST_CONNECTIONS"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil application cookie persistence",
+			expected: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{Type: "APP_COOKIE", CookieName: "session"},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual nil application cookie persistence without cookie name",
+			expected: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{Type: "APP_COOKIE"},
+			},
+			expectedError: fmt.Errorf("LBPool pool: session persistence type APP_COOKIE requires a cookie name"),
+		},
+		{
+			desc: "actual nil cookie name with source IP persistence",
+			expected: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{Type: "SOURCE_IP", CookieName: "session"},
+			},
+			expectedError: fmt.Errorf("LBPool pool: cookie name \"session\" is only supported by session persistence type APP_COOKIE, not SOURCE_IP"),
+		},
+		{
+			desc: "actual nil unknown persistence type",
+			expected: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{Type: "STICKY"},
+			},
+			expectedError: fmt.Errorf("LBPool pool: unsupported session persistence type \"STICKY\", supported types are [SOURCE_IP HTTP_COOKIE APP_COOKIE]"),
+		},
+		{
+			desc: "actual not nil persistence disabled",
+			actual: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{Type: "SOURCE_IP"},
+			},
+			expected: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{},
+			},
+			changes: &LBPool{
+				SessionPersistence: &LBSessionPersistence{},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil cookie name without persistence",
+			actual: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{Type: "SOURCE_IP"},
+			},
+			expected: &LBPool{
+				Name:               fi.PtrTo("pool"),
+				SessionPersistence: &LBSessionPersistence{CookieName: "session"},
+			},
+			changes: &LBPool{
+				SessionPersistence: &LBSessionPersistence{CookieName: "session"},
+			},
+			expectedError: fmt.Errorf("LBPool pool: cookie name \"session\" requires session persistence type APP_COOKIE"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var pool LBPool
+			err := (&pool).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_PoolUpdateOpts(t *testing.T) {
+	tests := []struct {
+		desc     string
+		opts     poolUpdateOpts
+		expected map[string]interface{}
+	}{
+		{
+			desc: "session persistence set",
+			opts: poolUpdateOpts{
+				UpdateOpts: v2pools.UpdateOpts{
+					Persistence: &v2pools.SessionPersistence{Type: "SOURCE_IP"},
+				},
+			},
+			expected: map[string]interface{}{
+				"pool": map[string]interface{}{
+					"session_persistence": map[string]interface{}{"type": "SOURCE_IP"},
+				},
+			},
+		},
+		{
+			desc: "session persistence cleared",
+			opts: poolUpdateOpts{
+				UpdateOpts: v2pools.UpdateOpts{
+					LBMethod: v2pools.LBMethodLeastConnections,
+				},
+				clearPersistence: true,
+			},
+			expected: map[string]interface{}{
+				"pool": map[string]interface{}{
+					"lb_algorithm":        "LEAST_CONNECTIONS",
+					"session_persistence": nil,
+				},
+			},
+		},
+		{
+			desc: "only session persistence cleared",
+			opts: poolUpdateOpts{
+				clearPersistence: true,
+			},
+			expected: map[string]interface{}{
+				"pool": map[string]interface{}{
+					"session_persistence": nil,
+				},
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			actual, err := testCase.opts.ToPoolUpdateMap()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
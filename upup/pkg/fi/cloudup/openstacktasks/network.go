@@ -115,6 +115,8 @@ func (_ *Network) ShouldCreate(a, e, changes *Network) (bool, error) {
 }
 
 func (_ *Network) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Network) error {
+	defer openstack.RecordRender("Network", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating Network with name:%q", fi.ValueOf(e.Name))
 
@@ -129,18 +131,16 @@ func (_ *Network) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 			return fmt.Errorf("Error creating network: %v", err)
 		}
 
-		err = t.Cloud.AppendTag(openstack.ResourceTypeNetwork, v.ID, fi.ValueOf(e.Tag))
-		if err != nil {
-			return fmt.Errorf("Error appending tag to network: %v", err)
+		if err := appendMissingTags(t.Cloud, openstack.ResourceTypeNetwork, v.ID, v.Tags, []string{fi.ValueOf(e.Tag)}); err != nil {
+			return err
 		}
 
 		e.ID = fi.PtrTo(v.ID)
 		klog.V(2).Infof("Creating a new Openstack network, id=%s", v.ID)
 		return nil
 	} else {
-		err := t.Cloud.AppendTag(openstack.ResourceTypeNetwork, fi.ValueOf(a.ID), fi.ValueOf(changes.Tag))
-		if err != nil {
-			return fmt.Errorf("Error appending tag to network: %v", err)
+		if err := appendMissingTags(t.Cloud, openstack.ResourceTypeNetwork, fi.ValueOf(a.ID), []string{fi.ValueOf(a.Tag)}, []string{fi.ValueOf(changes.Tag)}); err != nil {
+			return err
 		}
 	}
 	e.ID = a.ID
@@ -23,6 +23,7 @@ import (
 
 	"github.com/gophercloud/gophercloud"
 	v2pools "github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/pools"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/util/pkg/vfs"
@@ -45,10 +46,10 @@ type PoolAssociation struct {
 func (e *PoolAssociation) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 	var deps []fi.CloudupTask
 	for _, task := range tasks {
-		if _, ok := task.(*LB); ok {
+		if e.Pool != nil && isLoadbalancer(task, e.Pool.Loadbalancer) {
 			deps = append(deps, task)
 		}
-		if _, ok := task.(*LBPool); ok {
+		if isPool(task, e.Pool) {
 			deps = append(deps, task)
 		}
 		if _, ok := task.(*Instance); ok {
@@ -58,6 +59,15 @@ func (e *PoolAssociation) GetDependencies(tasks map[string]fi.CloudupTask) []fi.
 	return deps
 }
 
+var _ fi.HasPlanParent[fi.CloudupSubContext] = &PoolAssociation{}
+
+// PlanParent returns the pool of the members
+func (e *PoolAssociation) PlanParent(tasks map[string]fi.CloudupTask) fi.CloudupTask {
+	return planParent(tasks, func(task fi.CloudupTask) bool {
+		return isPool(task, e.Pool)
+	})
+}
+
 var _ fi.CompareWithID = &PoolAssociation{}
 
 func (s *PoolAssociation) CompareWithID() *string {
@@ -76,7 +86,7 @@ func (p *PoolAssociation) Find(context *fi.CloudupContext) (*PoolAssociation, er
 	if err != nil {
 		return nil, err
 	}
-	if rs == nil {
+	if len(rs) == 0 {
 		return nil, nil
 	} else if len(rs) != 1 {
 		return nil, fmt.Errorf("found multiple pools with name: %s", fi.ValueOf(p.Pool.Name))
@@ -87,8 +97,12 @@ func (p *PoolAssociation) Find(context *fi.CloudupContext) (*PoolAssociation, er
 	var found *v2pools.Member
 	for _, member := range a.Members {
 		poolMember, err := cloud.GetPoolMember(a.ID, member.ID)
+		if openstack.IsNotFound(err) {
+			// the member was deleted after the pool was listed
+			continue
+		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to get member %s of pool %s: %w", member.ID, a.ID, err)
 		}
 		if fi.ValueOf(p.Name) == poolMember.Name {
 			found = poolMember
@@ -100,7 +114,7 @@ func (p *PoolAssociation) Find(context *fi.CloudupContext) (*PoolAssociation, er
 	if found == nil {
 		return nil, nil
 	}
-	pool, err := NewLBPoolTaskFromCloud(cloud, p.Lifecycle, &a, nil)
+	pool, err := NewLBPoolTaskFromCloud(taskContext(context), cloud, p.Lifecycle, &a, nil)
 	if err != nil {
 		return nil, fmt.Errorf("NewLBListenerTaskFromCloud: failed to fetch pool %s: %v", fi.ValueOf(pool.Name), err)
 	}
@@ -155,7 +169,26 @@ func GetServerFixedIP(client *gophercloud.ServiceClient, server *servers.Server,
 	return memberAddress, err
 }
 
+// serverSubnetID returns the subnet of the fixed IP address of the server, the servers of a pool can be in different
+// subnets. It returns an empty string if no port of the server has the address.
+func serverSubnetID(cloud openstack.OpenstackCloud, server *servers.Server, address string) (string, error) {
+	serverPorts, err := cloud.ListPorts(ports.ListOpts{DeviceID: server.ID})
+	if err != nil {
+		return "", fmt.Errorf("failed to list ports of server %s: %w", server.ID, err)
+	}
+	for _, port := range serverPorts {
+		for _, fixedIP := range port.FixedIPs {
+			if fixedIP.IPAddress == address {
+				return fixedIP.SubnetID, nil
+			}
+		}
+	}
+	return "", nil
+}
+
 func (_ *PoolAssociation) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *PoolAssociation) error {
+	defer openstack.RecordRender("PoolAssociation", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		serverList, err := t.Cloud.ListInstances(servers.ListOpts{
 			Name: fmt.Sprintf("^%s", fi.ValueOf(e.ServerPrefix)),
@@ -175,10 +208,18 @@ func (_ *PoolAssociation) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e,
 				return err
 			}
 
+			subnetID, err := serverSubnetID(t.Cloud, &server, memberAddress)
+			if err != nil {
+				return err
+			}
+			if subnetID == "" {
+				subnetID = fi.ValueOf(e.Pool.Loadbalancer.VipSubnet)
+			}
+
 			member, err := t.Cloud.AssociateToPool(&server, fi.ValueOf(e.Pool.ID), v2pools.CreateMemberOpts{
 				Name:         fi.ValueOf(e.Name),
 				ProtocolPort: fi.ValueOf(e.ProtocolPort),
-				SubnetID:     fi.ValueOf(e.Pool.Loadbalancer.VipSubnet),
+				SubnetID:     subnetID,
 				Address:      memberAddress,
 			})
 			if err != nil {
@@ -31,19 +31,51 @@ type PoolMonitor struct {
 	Name      *string
 	Lifecycle fi.Lifecycle
 	Pool      *LBPool
+	// Type is the type of the health monitor, defaults to UDP-CONNECT for UDP pools and TCP otherwise
+	Type *string
+	// Disabled deletes the health monitor of the pool, connections are then sent to all members
+	Disabled *bool
+}
+
+// poolProtocol returns the protocol of the pool, pools are created with TCP if no protocol is set
+func poolProtocol(pool *LBPool) string {
+	if pool == nil || pool.Protocol == nil {
+		return "TCP"
+	}
+	return fi.ValueOf(pool.Protocol)
+}
+
+// monitorType returns the type of the health monitor, defaulting to the type matching the pool protocol
+func (p *PoolMonitor) monitorType() string {
+	if p.Type != nil {
+		return fi.ValueOf(p.Type)
+	}
+	if poolProtocol(p.Pool) == "UDP" {
+		return monitors.TypeUDPConnect
+	}
+	return monitors.TypeTCP
 }
 
 // GetDependencies returns the dependencies of the Instance task
 func (p *PoolMonitor) GetDependencies(tasks map[string]fi.CloudupTask) []fi.CloudupTask {
 	var deps []fi.CloudupTask
 	for _, task := range tasks {
-		if _, ok := task.(*LBPool); ok {
+		if isPool(task, p.Pool) {
 			deps = append(deps, task)
 		}
 	}
 	return deps
 }
 
+var _ fi.HasPlanParent[fi.CloudupSubContext] = &PoolMonitor{}
+
+// PlanParent returns the pool of the health monitor
+func (p *PoolMonitor) PlanParent(tasks map[string]fi.CloudupTask) fi.CloudupTask {
+	return planParent(tasks, func(task fi.CloudupTask) bool {
+		return isPool(task, p.Pool)
+	})
+}
+
 var _ fi.CompareWithID = &PoolMonitor{}
 
 func (p *PoolMonitor) CompareWithID() *string {
@@ -63,6 +95,16 @@ func (p *PoolMonitor) Find(context *fi.CloudupContext) (*PoolMonitor, error) {
 		return nil, err
 	}
 	if rs == nil || len(rs) == 0 {
+		if fi.ValueOf(p.Disabled) {
+			// the monitor is already deleted
+			return &PoolMonitor{
+				Name:      p.Name,
+				Pool:      p.Pool,
+				Lifecycle: p.Lifecycle,
+				Type:      p.Type,
+				Disabled:  p.Disabled,
+			}, nil
+		}
 		return nil, nil
 	} else if len(rs) != 1 {
 		return nil, fmt.Errorf("found multiple monitors with name: %s", fi.ValueOf(p.Name))
@@ -73,6 +115,14 @@ func (p *PoolMonitor) Find(context *fi.CloudupContext) (*PoolMonitor, error) {
 		Name:      fi.PtrTo(found.Name),
 		Pool:      p.Pool,
 		Lifecycle: p.Lifecycle,
+		Type:      fi.PtrTo(found.Type),
+	}
+	if p.Disabled != nil {
+		// the monitor exists, so it is not disabled yet
+		actual.Disabled = fi.PtrTo(false)
+	}
+	if p.Type == nil {
+		p.Type = fi.PtrTo(p.monitorType())
 	}
 	p.ID = actual.ID
 	return actual, nil
@@ -94,18 +144,39 @@ func (_ *PoolMonitor) CheckChanges(a, e, changes *PoolMonitor) error {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
 		}
+		if changes.Type != nil && !fi.ValueOf(e.Disabled) {
+			return fi.CannotChangeField("Type")
+		}
+	}
+	if !fi.ValueOf(e.Disabled) {
+		if err := openstack.ValidateLBMonitorType(poolProtocol(e.Pool), e.monitorType()); err != nil {
+			return fmt.Errorf("PoolMonitor %s: %w", fi.ValueOf(e.Name), err)
+		}
 	}
 	return nil
 }
 
 func (_ *PoolMonitor) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *PoolMonitor) error {
+	defer openstack.RecordRender("PoolMonitor", fi.ValueOf(e.Name))()
+
+	if fi.ValueOf(e.Disabled) {
+		if a != nil && a.ID != nil {
+			klog.V(2).Infof("Deleting PoolMonitor with Name: %q", fi.ValueOf(e.Name))
+
+			if err := t.Cloud.DeleteMonitor(fi.ValueOf(a.ID)); err != nil {
+				return fmt.Errorf("error deleting PoolMonitor: %v", err)
+			}
+		}
+		return nil
+	}
+
 	if a == nil {
 		klog.V(2).Infof("Creating PoolMonitor with Name: %q", fi.ValueOf(e.Name))
 
 		poolMonitor, err := t.Cloud.CreatePoolMonitor(monitors.CreateOpts{
 			Name:           fi.ValueOf(e.Name),
 			PoolID:         fi.ValueOf(e.Pool.ID),
-			Type:           monitors.TypeTCP,
+			Type:           e.monitorType(),
 			Delay:          10,
 			Timeout:        5,
 			MaxRetries:     3,
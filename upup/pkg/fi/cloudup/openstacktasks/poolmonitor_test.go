@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func Test_PoolMonitor_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *PoolMonitor
+		expected      *PoolMonitor
+		changes       *PoolMonitor
+		expectedError error
+	}{
+		{
+			desc:   "actual nil TCP pool default type",
+			actual: nil,
+			expected: &PoolMonitor{
+				Name: fi.PtrTo("api"),
+				Pool: &LBPool{Name: fi.PtrTo("api-https")},
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil UDP pool default type",
+			actual: nil,
+			expected: &PoolMonitor{
+				Name: fi.PtrTo("dns"),
+				Pool: &LBPool{Name: fi.PtrTo("dns-udp"), Protocol: fi.PtrTo("UDP")},
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil UDP pool TCP monitor",
+			actual: nil,
+			expected: &PoolMonitor{
+				Name: fi.PtrTo("dns"),
+				Pool: &LBPool{Name: fi.PtrTo("dns-udp"), Protocol: fi.PtrTo("UDP")},
+				Type: fi.PtrTo("TCP"),
+			},
+			expectedError: fmt.Errorf("PoolMonitor dns: health monitor type TCP is not supported by UDP pools, UDP-CONNECT is required"),
+		},
+		{
+			desc:   "actual nil UDP pool HTTP monitor",
+			actual: nil,
+			expected: &PoolMonitor{
+				Name: fi.PtrTo("dns"),
+				Pool: &LBPool{Name: fi.PtrTo("dns-udp"), Protocol: fi.PtrTo("UDP")},
+				Type: fi.PtrTo("HTTP"),
+			},
+			expectedError: fmt.Errorf("PoolMonitor dns: health monitor type HTTP is not supported by UDP pools, UDP-CONNECT is required"),
+		},
+		{
+			desc:   "actual nil TCP pool UDP-CONNECT monitor",
+			actual: nil,
+			expected: &PoolMonitor{
+				Name: fi.PtrTo("api"),
+				Pool: &LBPool{Name: fi.PtrTo("api-https")},
+				Type: fi.PtrTo("UDP-CONNECT"),
+			},
+			expectedError: fmt.Errorf("PoolMonitor api: health monitor type UDP-CONNECT is only supported by UDP pools, not TCP"),
+		},
+		{
+			desc:   "actual nil disabled UDP pool monitor",
+			actual: nil,
+			expected: &PoolMonitor{
+				Name:     fi.PtrTo("dns"),
+				Pool:     &LBPool{Name: fi.PtrTo("dns-udp"), Protocol: fi.PtrTo("UDP")},
+				Type:     fi.PtrTo("TCP"),
+				Disabled: fi.PtrTo(true),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil monitor disabled",
+			actual: &PoolMonitor{
+				Name:     fi.PtrTo("api"),
+				Type:     fi.PtrTo("TCP"),
+				Disabled: fi.PtrTo(false),
+			},
+			expected: &PoolMonitor{
+				Name:     fi.PtrTo("api"),
+				Type:     fi.PtrTo("TCP"),
+				Disabled: fi.PtrTo(true),
+			},
+			changes: &PoolMonitor{
+				Disabled: fi.PtrTo(true),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil unchangeable field Type set",
+			actual: &PoolMonitor{
+				Name: fi.PtrTo("api"),
+				Type: fi.PtrTo("TCP"),
+			},
+			expected: &PoolMonitor{
+				Name: fi.PtrTo("api"),
+				Type: fi.PtrTo("HTTP"),
+			},
+			changes: &PoolMonitor{
+				Type: fi.PtrTo("HTTP"),
+			},
+			expectedError: fi.CannotChangeField("Type"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var monitor PoolMonitor
+			err := (&monitor).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
@@ -18,9 +18,11 @@ package openstacktasks
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strings"
 
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/portsbinding"
 	secgroup "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 	"k8s.io/klog/v2"
@@ -42,6 +44,16 @@ type Port struct {
 	Tags                     []string
 	AllowedAddressPairs      []ports.AddressPair
 
+	// FixedIPs pins the port to the given IP addresses. Neutron picks the subnet
+	// containing each address, so when set it is used instead of Subnets.
+	FixedIPs []string
+
+	// VNICType is the binding:vnic_type of the port, e.g. "direct" for SR-IOV.
+	// Neutron does not allow changing it on a bound port.
+	VNICType *string
+	// BindingProfile is the binding:profile of the port.
+	BindingProfile map[string]string
+
 	// WellKnownServices indicates which services are supported by this resource.
 	// This field is internal and is not rendered to the cloud.
 	WellKnownServices []wellknownservices.WellKnownService
@@ -158,16 +170,34 @@ func newPortTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle
 		}
 	}
 
-	var tags []string
+	var fixedIPs []string
+	if find != nil && len(find.FixedIPs) > 0 {
+		for _, ip := range port.FixedIPs {
+			fixedIPs = append(fixedIPs, ip.IPAddress)
+		}
+		sort.Strings(fixedIPs)
+		// subnets are derived by Neutron from the fixed IPs, avoid reporting them as changed
+		if find.Subnets == nil {
+			subnets = nil
+		}
+	}
 
-	if find != nil {
-		for _, t := range find.Tags {
-			if fi.ArrayContains(port.Tags, t) {
-				tags = append(tags, t)
-			}
+	var vnicType *string
+	var bindingProfile map[string]string
+	if find != nil && (find.VNICType != nil || find.BindingProfile != nil) {
+		binding, err := getPortBinding(cloud, port.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get binding for port %s: %v", port.ID, err)
+		}
+		vnicType = fi.PtrTo(binding.VNICType)
+		if find.BindingProfile != nil {
+			bindingProfile = bindingProfileFromCloud(binding.Profile)
 		}
-	} else {
-		tags = port.Tags
+	}
+
+	tags := port.Tags
+	if find != nil {
+		tags = intersectTags(port.Tags, find.Tags)
 	}
 
 	var cloudInstanceGroupName *string
@@ -193,6 +223,9 @@ func newPortTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle
 		Lifecycle:           lifecycle,
 		Tags:                tags,
 		AllowedAddressPairs: getActualAllowedAddressPairs(port, find),
+		FixedIPs:            fixedIPs,
+		VNICType:            vnicType,
+		BindingProfile:      bindingProfile,
 	}
 	if find != nil {
 		find.ID = actual.ID
@@ -203,8 +236,59 @@ func newPortTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecycle
 	return actual, nil
 }
 
+// getPortBinding returns the port binding attributes, which are not part of ports.Port
+func getPortBinding(cloud openstack.OpenstackCloud, id string) (*portsbinding.PortsBindingExt, error) {
+	var port struct {
+		ports.Port
+		portsbinding.PortsBindingExt
+	}
+	err := ports.Get(cloud.NetworkingClient(), id).ExtractInto(&port)
+	if err != nil {
+		return nil, err
+	}
+	return &port.PortsBindingExt, nil
+}
+
+// bindingProfileFromCloud converts the binding profile returned by Neutron, non string values are JSON encoded
+func bindingProfileFromCloud(profile map[string]interface{}) map[string]string {
+	bindingProfile := make(map[string]string, len(profile))
+	for k, v := range profile {
+		if s, ok := v.(string); ok {
+			bindingProfile[k] = s
+		} else {
+			bindingProfile[k] = fi.DebugAsJsonString(v)
+		}
+	}
+	return bindingProfile
+}
+
+func bindingProfileToCloud(bindingProfile map[string]string) map[string]interface{} {
+	if bindingProfile == nil {
+		return nil
+	}
+	profile := make(map[string]interface{}, len(bindingProfile))
+	for k, v := range bindingProfile {
+		profile[k] = v
+	}
+	return profile
+}
+
 func (s *Port) Find(context *fi.CloudupContext) (*Port, error) {
 	cloud := context.T.Cloud.(openstack.OpenstackCloud)
+	port, err := findCloudPort(cloud, s)
+	if err != nil || port == nil {
+		return nil, err
+	}
+
+	// sort for consistent comparison
+	sort.Sort(SecurityGroupsByID(s.SecurityGroups))
+	sort.Strings(s.FixedIPs)
+
+	return newPortTaskFromCloud(cloud, s.Lifecycle, port, s)
+}
+
+// findCloudPort returns the port of the task, nil if it does not exist. The task is not modified.
+func findCloudPort(cloud openstack.OpenstackCloud, s *Port) (*ports.Port, error) {
 	opt := ports.ListOpts{
 		Name: fi.ValueOf(s.Name),
 	}
@@ -217,11 +301,7 @@ func (s *Port) Find(context *fi.CloudupContext) (*Port, error) {
 	} else if len(rs) != 1 {
 		return nil, fmt.Errorf("found multiple ports with name: %s", fi.ValueOf(s.Name))
 	}
-
-	// sort for consistent comparison
-	sort.Sort(SecurityGroupsByID(s.SecurityGroups))
-
-	return newPortTaskFromCloud(cloud, s.Lifecycle, &rs[0], s)
+	return &rs[0], nil
 }
 
 func (s *Port) Run(context *fi.CloudupContext) error {
@@ -236,6 +316,9 @@ func (_ *Port) CheckChanges(a, e, changes *Port) error {
 		if e.Network == nil {
 			return fi.RequiredField("Network")
 		}
+		if err := validateFixedIPs(e.FixedIPs); err != nil {
+			return err
+		}
 	} else {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
@@ -243,11 +326,25 @@ func (_ *Port) CheckChanges(a, e, changes *Port) error {
 		if changes.Network != nil {
 			return fi.CannotChangeField("Network")
 		}
+		if err := validateFixedIPs(changes.FixedIPs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFixedIPs(fixedIPs []string) error {
+	for _, ip := range fixedIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid fixed IP address %q", ip)
+		}
 	}
 	return nil
 }
 
 func (*Port) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Port) error {
+	defer openstack.RecordRender("Port", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating Port with name: %q", fi.ValueOf(e.Name))
 
@@ -261,26 +358,36 @@ func (*Port) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Por
 			return fmt.Errorf("Error creating port: %v", err)
 		}
 
-		if e.Tags != nil {
-			for _, tag := range e.Tags {
-				err = t.Cloud.AppendTag(openstack.ResourceTypePort, v.ID, tag)
-				if err != nil {
-					return fmt.Errorf("Error appending tag to port: %v", err)
-				}
-			}
+		if err := appendMissingTags(t.Cloud, openstack.ResourceTypePort, v.ID, v.Tags, e.Tags); err != nil {
+			return err
 		}
 		e.ID = fi.PtrTo(v.ID)
 		klog.V(2).Infof("Creating a new Openstack port, id=%s", v.ID)
 		return nil
 	}
+	if changes != nil && changes.VNICType != nil {
+		port, err := t.Cloud.GetPort(fi.ValueOf(a.ID))
+		if err != nil {
+			return fmt.Errorf("error getting port %s: %v", fi.ValueOf(a.ID), err)
+		}
+		if port.DeviceID != "" {
+			// Neutron rejects changing the vnic_type of a bound port, the port is
+			// replaced together with the server during a rolling update
+			klog.Warningf("Cannot change vnic_type of Port %q from %q to %q while it is attached to %s, a rolling update is required to replace it",
+				fi.ValueOf(e.Name), fi.ValueOf(a.VNICType), fi.ValueOf(e.VNICType), port.DeviceID)
+		} else {
+			klog.V(2).Infof("Replacing unattached Port %q to change vnic_type to %q", fi.ValueOf(e.Name), fi.ValueOf(e.VNICType))
+			if err := t.Cloud.DeletePort(port.ID); err != nil {
+				return fmt.Errorf("error deleting port %s: %v", port.ID, err)
+			}
+			return (&Port{}).RenderOpenstack(t, nil, e, changes)
+		}
+	}
 	if changes != nil {
 		if changes.Tags != nil {
 			klog.V(2).Infof("Updating tags for Port with name: %q", fi.ValueOf(e.Name))
-			for _, tag := range e.Tags {
-				err := t.Cloud.AppendTag(openstack.ResourceTypePort, fi.ValueOf(a.ID), tag)
-				if err != nil {
-					return fmt.Errorf("Error appending tag to port: %v", err)
-				}
+			if err := appendMissingTags(t.Cloud, openstack.ResourceTypePort, fi.ValueOf(a.ID), a.Tags, e.Tags); err != nil {
+				return err
 			}
 		}
 		if changes.AllowedAddressPairs != nil {
@@ -292,6 +399,25 @@ func (*Port) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Por
 				return fmt.Errorf("error updating port: %v", err)
 			}
 		}
+		if changes.BindingProfile != nil {
+			klog.V(2).Infof("Updating binding profile for Port with name: %q", fi.ValueOf(e.Name))
+			_, err := t.Cloud.UpdatePort(fi.ValueOf(a.ID), portsbinding.UpdateOptsExt{
+				UpdateOptsBuilder: ports.UpdateOpts{},
+				Profile:           bindingProfileToCloud(e.BindingProfile),
+			})
+			if err != nil {
+				return fmt.Errorf("error updating port: %v", err)
+			}
+		}
+		if changes.FixedIPs != nil {
+			klog.V(2).Infof("Updating fixed IPs for Port with name: %q", fi.ValueOf(e.Name))
+			_, err := t.Cloud.UpdatePort(fi.ValueOf(a.ID), ports.UpdateOpts{
+				FixedIPs: fixedIPsFromAddresses(e.FixedIPs),
+			})
+			if err != nil {
+				return fmt.Errorf("error updating port: %v", err)
+			}
+		}
 	}
 	e.ID = a.ID
 	klog.V(2).Infof("Using an existing Openstack port, id=%s", fi.ValueOf(e.ID))
@@ -316,18 +442,42 @@ func portCreateOptsFromPortTask(t *openstack.OpenstackAPITarget, a, e, changes *
 		}
 		sgs[i+len(e.SecurityGroups)] = gs[0].ID
 	}
-	fixedIPs := make([]ports.IP, len(e.Subnets))
-	for i, subn := range e.Subnets {
-		fixedIPs[i] = ports.IP{
-			SubnetID: fi.ValueOf(subn.ID),
+	var fixedIPs []ports.IP
+	if len(e.FixedIPs) > 0 {
+		fixedIPs = fixedIPsFromAddresses(e.FixedIPs)
+	} else {
+		fixedIPs = make([]ports.IP, len(e.Subnets))
+		for i, subn := range e.Subnets {
+			fixedIPs[i] = ports.IP{
+				SubnetID: fi.ValueOf(subn.ID),
+			}
 		}
 	}
 
-	return ports.CreateOpts{
+	opts := ports.CreateOpts{
 		Name:                fi.ValueOf(e.Name),
 		NetworkID:           fi.ValueOf(e.Network.ID),
 		SecurityGroups:      &sgs,
 		FixedIPs:            fixedIPs,
 		AllowedAddressPairs: e.AllowedAddressPairs,
-	}, nil
+	}
+	if e.VNICType != nil || e.BindingProfile != nil {
+		return portsbinding.CreateOptsExt{
+			CreateOptsBuilder: opts,
+			VNICType:          fi.ValueOf(e.VNICType),
+			Profile:           bindingProfileToCloud(e.BindingProfile),
+		}, nil
+	}
+	return opts, nil
+}
+
+// fixedIPsFromAddresses builds the Neutron fixed IPs for the given addresses, leaving the subnet selection to Neutron
+func fixedIPsFromAddresses(addresses []string) []ports.IP {
+	fixedIPs := make([]ports.IP, len(addresses))
+	for i, address := range addresses {
+		fixedIPs[i] = ports.IP{
+			IPAddress: address,
+		}
+	}
+	return fixedIPs
 }
@@ -22,6 +22,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/portsbinding"
 	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -918,6 +919,16 @@ func Test_Port_CheckChanges(t *testing.T) {
 			},
 			expectedError: fi.RequiredField("Network"),
 		},
+		{
+			desc:   "actual nil invalid fixed IP",
+			actual: nil,
+			expected: &Port{
+				Name:     fi.PtrTo("name"),
+				Network:  &Network{ID: fi.PtrTo("networkID")},
+				FixedIPs: []string{"10.0.0.300"},
+			},
+			expectedError: fmt.Errorf("invalid fixed IP address \"10.0.0.300\""),
+		},
 		{
 			desc: "actual not nil all changeable fields set",
 			actual: &Port{
@@ -1290,6 +1301,60 @@ func Test_Port_createOptsFromPortTask(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "fixed IPs take precedence over subnets",
+			target: &openstack.OpenstackAPITarget{
+				Cloud: &portCloud{},
+			},
+			expected: &Port{
+				ID:      fi.PtrTo("expected-id"),
+				Name:    fi.PtrTo("name"),
+				Network: &Network{ID: fi.PtrTo("networkID")},
+				Subnets: []*Subnet{
+					{ID: fi.PtrTo("subnet-a")},
+				},
+				FixedIPs: []string{
+					"10.0.0.10",
+					"10.0.1.10",
+				},
+			},
+			expectedCreateOpts: ports.CreateOpts{
+				Name:           "name",
+				NetworkID:      "networkID",
+				SecurityGroups: &[]string{},
+				FixedIPs: []ports.IP{
+					{IPAddress: "10.0.0.10"},
+					{IPAddress: "10.0.1.10"},
+				},
+			},
+		},
+		{
+			desc: "binding set",
+			target: &openstack.OpenstackAPITarget{
+				Cloud: &portCloud{},
+			},
+			expected: &Port{
+				ID:       fi.PtrTo("expected-id"),
+				Name:     fi.PtrTo("name"),
+				Network:  &Network{ID: fi.PtrTo("networkID")},
+				VNICType: fi.PtrTo("direct"),
+				BindingProfile: map[string]string{
+					"capabilities": "switchdev",
+				},
+			},
+			expectedCreateOpts: portsbinding.CreateOptsExt{
+				CreateOptsBuilder: ports.CreateOpts{
+					Name:           "name",
+					NetworkID:      "networkID",
+					SecurityGroups: &[]string{},
+					FixedIPs:       []ports.IP{},
+				},
+				VNICType: "direct",
+				Profile: map[string]interface{}{
+					"capabilities": "switchdev",
+				},
+			},
+		},
 		{
 			desc: "nonexisting additional security groups",
 			target: &openstack.OpenstackAPITarget{
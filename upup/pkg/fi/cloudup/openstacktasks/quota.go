@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/loadbalancer/v2/loadbalancers"
+	"k8s.io/klog/v2"
+
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+// CheckQuota returns an error if creating the missing resources of the tasks would exceed the quotas of the project,
+// so that the apply fails before anything is created. Quotas that cannot be read are not checked.
+func CheckQuota(cloud openstack.OpenstackCloud, tasks map[string]fi.CloudupTask) error {
+	quotas, err := cloud.GetProjectQuotas()
+	if err != nil {
+		klog.Warningf("Unable to read the quotas of the project, skipping the quota check: %v", err)
+		return nil
+	}
+
+	request, err := quotaRequest(cloud, tasks)
+	if err != nil {
+		return fmt.Errorf("error counting the resources to create: %w", err)
+	}
+	klog.V(2).Infof("Resources to create: %+v", request)
+
+	return quotas.Check(request)
+}
+
+// quotaRequest counts the resources the tasks create because they do not exist yet. The resources are looked up
+// without the Find methods of the tasks, which would set the IDs of the tasks before they run.
+func quotaRequest(cloud openstack.OpenstackCloud, tasks map[string]fi.CloudupTask) (openstack.QuotaRequest, error) {
+	var request openstack.QuotaRequest
+
+	// the loadbalancers are looked up once, the floating IPs of the loadbalancers depend on them
+	lbs := make(map[*LB]*loadbalancers.LoadBalancer)
+	findLB := func(e *LB) (*loadbalancers.LoadBalancer, error) {
+		if lb, ok := lbs[e]; ok {
+			return lb, nil
+		}
+		lb, err := findCloudLB(cloud, e)
+		if err != nil {
+			return nil, err
+		}
+		lbs[e] = lb
+		return lb, nil
+	}
+
+	for _, task := range tasks {
+		switch e := task.(type) {
+		case *LB:
+			if e.Lifecycle != fi.LifecycleSync || openstack.CheckLoadBalancerClient(cloud) != nil {
+				continue
+			}
+			lb, err := findLB(e)
+			if err != nil {
+				return request, err
+			}
+			if lb == nil {
+				// every loadbalancer has a VIP port
+				request.LoadBalancers++
+				request.Ports++
+			}
+
+		case *Port:
+			if e.Lifecycle != fi.LifecycleSync {
+				continue
+			}
+			port, err := findCloudPort(cloud, e)
+			if err != nil {
+				return request, err
+			}
+			if port == nil {
+				request.Ports++
+			}
+
+		case *Volume:
+			if e.Lifecycle != fi.LifecycleSync {
+				continue
+			}
+			volume, err := findCloudVolume(cloud, e)
+			if err != nil {
+				return request, err
+			}
+			if volume == nil {
+				request.Volumes++
+				request.VolumeGigabytes += int(fi.ValueOf(e.SizeGB))
+			}
+
+		case *FloatingIP:
+			if e.Lifecycle != fi.LifecycleSync {
+				continue
+			}
+			if e.LB != nil {
+				lb, err := findLB(e.LB)
+				if err != nil {
+					return request, err
+				}
+				if lb == nil {
+					request.FloatingIPs++
+					continue
+				}
+				fip, err := findFipByPortID(cloud, lb.VipPortID)
+				if err != nil {
+					return request, err
+				}
+				if fip == nil {
+					request.FloatingIPs++
+				}
+				continue
+			}
+			fip, _, err := findNamedFip(cloud, fi.ValueOf(e.Name))
+			if err != nil {
+				return request, err
+			}
+			if fip == nil {
+				request.FloatingIPs++
+			}
+
+		case *Instance:
+			if e.Lifecycle != fi.LifecycleSync {
+				continue
+			}
+			server, err := findServer(cloud, e)
+			if err != nil {
+				return request, err
+			}
+			if server == nil {
+				request.Instances++
+				if bootFromVolume(e.Metadata) {
+					// the size of the boot volume is only known here if it is set, otherwise it is the size of the image
+					request.Volumes++
+					request.VolumeGigabytes += int(fi.ValueOf(e.BootVolumeSizeGB))
+				}
+			}
+		}
+	}
+
+	return request, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"testing"
+
+	cinderv3 "github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumes"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"k8s.io/kops/cloudmock/openstack/mockblockstorage"
+	"k8s.io/kops/cloudmock/openstack/mockloadbalancer"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_QuotaRequest(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+		MockLBClient:      mockloadbalancer.CreateClient(),
+		MockCinderClient:  mockblockstorage.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+	if _, err := cloud.CreatePort(ports.CreateOpts{Name: "port-existing", NetworkID: network.ID}); err != nil {
+		t.Fatalf("error creating port: %v", err)
+	}
+	if _, err := cloud.CreateVolume(cinderv3.CreateOpts{Name: "volume-existing", Size: 20}); err != nil {
+		t.Fatalf("error creating volume: %v", err)
+	}
+	lb := &LB{
+		Name:      fi.PtrTo("api.cluster"),
+		Lifecycle: fi.LifecycleSync,
+	}
+	tasks := map[string]fi.CloudupTask{
+		"LB/api.cluster": lb,
+		"FloatingIP/fip-api.cluster": &FloatingIP{
+			Name:      fi.PtrTo("fip-api.cluster"),
+			LB:        lb,
+			Lifecycle: fi.LifecycleSync,
+		},
+		"Port/port-existing": &Port{
+			Name:      fi.PtrTo("port-existing"),
+			Lifecycle: fi.LifecycleSync,
+		},
+		"Port/port-new": &Port{
+			Name:      fi.PtrTo("port-new"),
+			Lifecycle: fi.LifecycleSync,
+		},
+		"Port/port-shared": &Port{
+			Name:      fi.PtrTo("port-shared"),
+			Lifecycle: fi.LifecycleExistsAndValidates,
+		},
+		"Volume/volume-existing": &Volume{
+			Name:      fi.PtrTo("volume-existing"),
+			SizeGB:    fi.PtrTo(int64(20)),
+			Lifecycle: fi.LifecycleSync,
+		},
+		"Volume/volume-new": &Volume{
+			Name:      fi.PtrTo("volume-new"),
+			SizeGB:    fi.PtrTo(int64(50)),
+			Lifecycle: fi.LifecycleSync,
+		},
+	}
+
+	request, err := quotaRequest(cloud, tasks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := openstack.QuotaRequest{
+		Volumes:         1,
+		VolumeGigabytes: 50,
+		Ports:           2,
+		FloatingIPs:     1,
+		LoadBalancers:   1,
+	}
+	if request != expected {
+		t.Errorf("Quota request differs:\n%+v\n\tinstead of\n%+v", request, expected)
+	}
+	// the tasks are only found when they run
+	if id := tasks["Port/port-existing"].(*Port).ID; id != nil {
+		t.Errorf("expected the ID of the existing port not to be set, got %s", *id)
+	}
+	if id := tasks["Volume/volume-existing"].(*Volume).ID; id != nil {
+		t.Errorf("expected the ID of the existing volume not to be set, got %s", *id)
+	}
+}
@@ -92,6 +92,8 @@ func (_ *Router) CheckChanges(a, e, changes *Router) error {
 }
 
 func (_ *Router) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Router) error {
+	defer openstack.RecordRender("Router", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating Router with name:%q", fi.ValueOf(e.Name))
 
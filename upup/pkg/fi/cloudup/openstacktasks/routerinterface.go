@@ -120,6 +120,8 @@ func (*RouterInterface) CheckChanges(a, e, changes *RouterInterface) error {
 }
 
 func (_ *RouterInterface) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *RouterInterface) error {
+	defer openstack.RecordRender("RouterInterface", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		routerID := fi.ValueOf(e.Router.ID)
 		subnetID := fi.ValueOf(e.Subnet.ID)
@@ -17,6 +17,7 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -59,14 +60,14 @@ func (s *SecurityGroup) Find(context *fi.CloudupContext) (*SecurityGroup, error)
 	if s.RemoveGroup {
 		return s, nil
 	}
-	return getSecurityGroupByName(s, cloud)
+	return getSecurityGroupByName(taskContext(context), s, cloud)
 }
 
-func getSecurityGroupByName(s *SecurityGroup, cloud openstack.OpenstackCloud) (*SecurityGroup, error) {
+func getSecurityGroupByName(ctx context.Context, s *SecurityGroup, cloud openstack.OpenstackCloud) (*SecurityGroup, error) {
 	opt := sg.ListOpts{
 		Name: fi.ValueOf(s.Name),
 	}
-	gs, err := cloud.ListSecurityGroups(opt)
+	gs, err := cloud.ListSecurityGroupsWithContext(ctx, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +111,8 @@ func (_ *SecurityGroup) CheckChanges(a, e, changes *SecurityGroup) error {
 }
 
 func (_ *SecurityGroup) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *SecurityGroup) error {
+	defer openstack.RecordRender("SecurityGroup", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating SecurityGroup with Name:%q", fi.ValueOf(e.Name))
 
@@ -140,7 +143,7 @@ func (s *SecurityGroup) FindDeletions(c *fi.CloudupContext) ([]fi.CloudupDeletio
 
 	cloud := c.T.Cloud.(openstack.OpenstackCloud)
 	if s.RemoveGroup {
-		sg, err := getSecurityGroupByName(s, cloud)
+		sg, err := getSecurityGroupByName(taskContext(c), s, cloud)
 		if err != nil {
 			return nil, err
 		}
@@ -160,7 +163,7 @@ func (s *SecurityGroup) FindDeletions(c *fi.CloudupContext) ([]fi.CloudupDeletio
 		rules = append(rules, rule)
 	}
 
-	sg, err := getSecurityGroupByName(s, cloud)
+	sg, err := getSecurityGroupByName(taskContext(c), s, cloud)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +191,10 @@ func (s *SecurityGroup) FindDeletions(c *fi.CloudupContext) ([]fi.CloudupDeletio
 			klog.V(4).Infof("Ignoring security group permission %q (did not match removal rules)", permission)
 			continue
 		}
+		if !IsManagedRule(permission) {
+			klog.V(4).Infof("Ignoring security group permission %q (described as not managed by kOps)", permission)
+			continue
+		}
 
 		found := false
 		for _, t := range c.AllTasks() {
@@ -18,8 +18,11 @@ package openstacktasks
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	sgr "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
@@ -50,6 +53,22 @@ type SecurityGroupRule struct {
 	RemoteGroup    *SecurityGroup
 	Lifecycle      fi.Lifecycle
 	Delete         *bool
+	// Description is set when the rule is created, the descriptions of existing rules cannot be changed
+	Description *string
+}
+
+// managedRuleDescriptionPrefix starts the descriptions of the security group rules created by kOps
+const managedRuleDescriptionPrefix = "Managed by kOps"
+
+// ManagedRuleDescription returns the description of the security group rules kOps creates for the cluster
+func ManagedRuleDescription(clusterName string) string {
+	return fmt.Sprintf("%s for cluster %s", managedRuleDescriptionPrefix, clusterName)
+}
+
+// IsManagedRule returns true if the security group rule may be managed by kOps. Rules with a description set by
+// others are never managed by kOps, rules without description may have been created by older versions of kOps.
+func IsManagedRule(rule sgr.SecGroupRule) bool {
+	return rule.Description == "" || strings.HasPrefix(rule.Description, managedRuleDescriptionPrefix)
 }
 
 // GetDependencies returns the dependencies of the Instance task
@@ -76,41 +95,64 @@ func (r *SecurityGroupRule) Find(context *fi.CloudupContext) (*SecurityGroupRule
 
 	cloud := context.T.Cloud.(openstack.OpenstackCloud)
 
+	// the rules are matched on their semantic key, Neutron may return the fields of equivalent rules differently
 	opt := sgr.ListOpts{
-		Direction:      fi.ValueOf(r.Direction),
-		EtherType:      fi.ValueOf(r.EtherType),
-		PortRangeMax:   IntValue(r.PortRangeMax),
-		PortRangeMin:   IntValue(r.PortRangeMin),
-		Protocol:       fi.ValueOf(r.Protocol),
-		RemoteIPPrefix: fi.ValueOf(r.RemoteIPPrefix),
-		SecGroupID:     fi.ValueOf(r.SecGroup.ID),
+		Direction:  fi.ValueOf(r.Direction),
+		SecGroupID: fi.ValueOf(r.SecGroup.ID),
 	}
 	if r.RemoteGroup != nil {
 		opt.RemoteGroupID = fi.ValueOf(r.RemoteGroup.ID)
 	}
-	rs, err := cloud.ListSecurityGroupRules(opt)
+	listed, err := cloud.ListSecurityGroupRules(opt)
 	if err != nil {
 		return nil, err
 	}
-	n := len(rs)
-	if n == 0 {
+	key := r.key()
+	var rs []sgr.SecGroupRule
+	for _, rule := range listed {
+		if ruleKey(rule) == key {
+			rs = append(rs, rule)
+		}
+	}
+	// the rule described like the rule of the task is used if other rules match as well
+	if len(rs) > 1 && r.Description != nil {
+		var described []sgr.SecGroupRule
+		for _, rule := range rs {
+			if rule.Description == fi.ValueOf(r.Description) {
+				described = append(described, rule)
+			}
+		}
+		if len(described) > 0 {
+			rs = described
+		}
+	}
+	if len(rs) == 0 {
 		return nil, nil
-	} else if n != 1 {
-		return nil, fmt.Errorf("found multiple SecurityGroupRules")
+	}
+	if len(rs) > 1 {
+		// equivalent rules were created before, the same one is adopted on every apply
+		sort.Slice(rs, func(i, j int) bool { return rs[i].ID < rs[j].ID })
+		klog.V(2).Infof("Found %d equivalent SecurityGroupRules %s, adopting %s", len(rs), r, rs[0].ID)
 	}
 	rule := rs[0]
+	if r.Description != nil && rule.Description != fi.ValueOf(r.Description) {
+		klog.V(2).Infof("SecurityGroupRule %s has description %q instead of %q, it is kept as rules cannot be updated", rule.ID, rule.Description, fi.ValueOf(r.Description))
+	}
+	// the rule is equivalent to the rule of the task, it is reported like the task to not show spurious changes
 	actual := &SecurityGroupRule{
 		ID:             fi.PtrTo(rule.ID),
-		Direction:      fi.PtrTo(rule.Direction),
-		EtherType:      fi.PtrTo(rule.EtherType),
-		PortRangeMax:   Int(rule.PortRangeMax),
-		PortRangeMin:   Int(rule.PortRangeMin),
-		Protocol:       fi.PtrTo(rule.Protocol),
-		RemoteIPPrefix: fi.PtrTo(rule.RemoteIPPrefix),
+		Direction:      r.Direction,
+		EtherType:      r.EtherType,
+		PortRangeMax:   r.PortRangeMax,
+		PortRangeMin:   r.PortRangeMin,
+		Protocol:       r.Protocol,
+		RemoteIPPrefix: r.RemoteIPPrefix,
 		RemoteGroup:    r.RemoteGroup,
 		SecGroup:       r.SecGroup,
 		Lifecycle:      r.Lifecycle,
 		Delete:         fi.PtrTo(false),
+		// the description is never reported as a change, it cannot be updated
+		Description: r.Description,
 	}
 
 	r.ID = actual.ID
@@ -150,24 +192,19 @@ func (*SecurityGroupRule) CheckChanges(a, e, changes *SecurityGroupRule) error {
 }
 
 func (*SecurityGroupRule) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *SecurityGroupRule) error {
+	defer openstack.RecordRender("SecurityGroupRule", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating SecurityGroupRule")
-		etherType := fi.ValueOf(e.EtherType)
-		if e.RemoteIPPrefix != nil {
-			if net.IsIPv4CIDRString(*e.RemoteIPPrefix) {
-				etherType = "IPv4"
-			} else {
-				etherType = "IPv6"
-			}
-		}
 		opt := sgr.CreateOpts{
 			Direction:      sgr.RuleDirection(fi.ValueOf(e.Direction)),
-			EtherType:      sgr.RuleEtherType(etherType),
+			EtherType:      sgr.RuleEtherType(e.etherType()),
 			SecGroupID:     fi.ValueOf(e.SecGroup.ID),
 			PortRangeMax:   IntValue(e.PortRangeMax),
 			PortRangeMin:   IntValue(e.PortRangeMin),
 			Protocol:       sgr.RuleProtocol(fi.ValueOf(e.Protocol)),
 			RemoteIPPrefix: fi.ValueOf(e.RemoteIPPrefix),
+			Description:    fi.ValueOf(e.Description),
 		}
 		if e.RemoteGroup != nil {
 			opt.RemoteGroupID = fi.ValueOf(e.RemoteGroup.ID)
@@ -186,6 +223,83 @@ func (*SecurityGroupRule) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e,
 	return nil
 }
 
+// etherType returns the ether type of the rule, it is set by the remote prefix if there is one
+func (r *SecurityGroupRule) etherType() string {
+	if r.RemoteIPPrefix != nil {
+		if net.IsIPv4CIDRString(*r.RemoteIPPrefix) {
+			return "IPv4"
+		}
+		return "IPv6"
+	}
+	return fi.ValueOf(r.EtherType)
+}
+
+// securityGroupRuleKey identifies equivalent security group rules, the description and other fields that do not
+// change the traffic the rule allows are not part of it
+type securityGroupRuleKey struct {
+	direction      string
+	etherType      string
+	protocol       string
+	portRangeMin   int
+	portRangeMax   int
+	remoteGroupID  string
+	remoteIPPrefix string
+}
+
+// key returns the key of the rule created by the task
+func (r *SecurityGroupRule) key() securityGroupRuleKey {
+	remoteGroupID := ""
+	if r.RemoteGroup != nil {
+		remoteGroupID = fi.ValueOf(r.RemoteGroup.ID)
+	}
+	return newSecurityGroupRuleKey(fi.ValueOf(r.Direction), r.etherType(), fi.ValueOf(r.Protocol), IntValue(r.PortRangeMin), IntValue(r.PortRangeMax),
+		remoteGroupID, fi.ValueOf(r.RemoteIPPrefix))
+}
+
+// ruleKey returns the key of an existing rule
+func ruleKey(rule sgr.SecGroupRule) securityGroupRuleKey {
+	return newSecurityGroupRuleKey(rule.Direction, rule.EtherType, rule.Protocol, rule.PortRangeMin, rule.PortRangeMax, rule.RemoteGroupID, rule.RemoteIPPrefix)
+}
+
+// protocolNames maps the protocol numbers and aliases Neutron accepts to the names kOps uses
+var protocolNames = map[string]string{
+	"1":      "icmp",
+	"6":      "tcp",
+	"17":     "udp",
+	"58":     "ipv6-icmp",
+	"icmpv6": "ipv6-icmp",
+	"any":    "",
+}
+
+// fullPortRangeProtocols are the protocols a port range of 1-65535 is equivalent to no port range for
+var fullPortRangeProtocols = sets.New("tcp", "udp", "sctp", "dccp", "udplite")
+
+func newSecurityGroupRuleKey(direction, etherType, protocol string, portRangeMin, portRangeMax int, remoteGroupID, remoteIPPrefix string) securityGroupRuleKey {
+	protocol = strings.ToLower(protocol)
+	if name, ok := protocolNames[protocol]; ok {
+		protocol = name
+	}
+	if fullPortRangeProtocols.Has(protocol) && portRangeMin == 1 && portRangeMax == 65535 {
+		portRangeMin, portRangeMax = 0, 0
+	}
+	if _, cidr, err := net.ParseCIDRSloppy(remoteIPPrefix); err == nil {
+		remoteIPPrefix = cidr.String()
+	}
+	// a rule for any address is the same as a rule without remote prefix
+	if remoteIPPrefix == "0.0.0.0/0" || remoteIPPrefix == "::/0" {
+		remoteIPPrefix = ""
+	}
+	return securityGroupRuleKey{
+		direction:      strings.ToLower(direction),
+		etherType:      strings.ToLower(etherType),
+		protocol:       protocol,
+		portRangeMin:   portRangeMin,
+		portRangeMax:   portRangeMax,
+		remoteGroupID:  remoteGroupID,
+		remoteIPPrefix: remoteIPPrefix,
+	}
+}
+
 var _ fi.HasLifecycle = &SecurityGroupRule{}
 
 // GetLifecycle returns the Lifecycle of the object, implementing fi.HasLifecycle
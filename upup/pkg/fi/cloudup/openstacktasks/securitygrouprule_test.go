@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"context"
+	"testing"
+
+	sg "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
+	sgr "github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_IsManagedRule(t *testing.T) {
+	tests := []struct {
+		desc        string
+		description string
+		expected    bool
+	}{
+		{
+			desc:        "rule of the cluster",
+			description: ManagedRuleDescription("cluster"),
+			expected:    true,
+		},
+		{
+			desc:     "rule created by older versions of kOps",
+			expected: true,
+		},
+		{
+			desc:        "rule added by others",
+			description: "allow monitoring",
+			expected:    false,
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			if actual := IsManagedRule(sgr.SecGroupRule{Description: testCase.description}); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+// newSecurityGroupRuleCloud creates a security group with a https rule added by kOps and a https rule added by others
+func newSecurityGroupRuleCloud(t *testing.T) (*openstack.MockCloud, *sg.SecGroup, map[string]string) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	group, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "masters.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	ruleIDs := map[string]string{}
+	for key, opts := range map[string]sgr.CreateOpts{
+		"kops":    {RemoteIPPrefix: "10.0.0.0/8", Description: ManagedRuleDescription("cluster")},
+		"foreign": {RemoteIPPrefix: "192.168.0.0/16", Description: "allow the office"},
+	} {
+		opts.Direction = sgr.DirIngress
+		opts.EtherType = sgr.EtherType4
+		opts.Protocol = sgr.ProtocolTCP
+		opts.PortRangeMin = 443
+		opts.PortRangeMax = 443
+		opts.SecGroupID = group.ID
+		rule, err := cloud.CreateSecurityGroupRule(opts)
+		if err != nil {
+			t.Fatalf("error creating security group rule: %v", err)
+		}
+		ruleIDs[key] = rule.ID
+	}
+	return cloud, group, ruleIDs
+}
+
+func Test_SecurityGroupRule_FindDescribed(t *testing.T) {
+	cloud, group, ruleIDs := newSecurityGroupRuleCloud(t)
+	rule := &SecurityGroupRule{
+		Direction:      fi.PtrTo(string(sgr.DirIngress)),
+		EtherType:      fi.PtrTo(string(sgr.EtherType4)),
+		Protocol:       fi.PtrTo(string(sgr.ProtocolTCP)),
+		PortRangeMin:   Int(443),
+		PortRangeMax:   Int(443),
+		RemoteIPPrefix: fi.PtrTo("10.0.0.0/8"),
+		SecGroup:       &SecurityGroup{ID: fi.PtrTo(group.ID), Name: fi.PtrTo(group.Name)},
+		Description:    fi.PtrTo(ManagedRuleDescription("cluster")),
+		Lifecycle:      fi.LifecycleSync,
+	}
+
+	ctx, err := fi.NewCloudupContext(context.TODO(), fi.DeletionProcessingModeDeleteIncludingDeferred, openstack.NewOpenstackAPITarget(cloud), nil, cloud, nil, nil, nil, map[string]fi.CloudupTask{})
+	if err != nil {
+		t.Fatalf("error building context: %v", err)
+	}
+	actual, err := rule.Find(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual == nil || fi.ValueOf(actual.ID) != ruleIDs["kops"] {
+		t.Errorf("expected the rule described by kOps %s, got %+v", ruleIDs["kops"], actual)
+	}
+}
+
+func Test_SecurityGroup_FindDeletionsKeepsForeignRules(t *testing.T) {
+	cloud, group, ruleIDs := newSecurityGroupRuleCloud(t)
+	securityGroup := &SecurityGroup{
+		Name:             fi.PtrTo(group.Name),
+		Lifecycle:        fi.LifecycleSync,
+		RemoveExtraRules: []string{"port=443"},
+	}
+
+	ctx, err := fi.NewCloudupContext(context.TODO(), fi.DeletionProcessingModeDeleteIncludingDeferred, openstack.NewOpenstackAPITarget(cloud), nil, cloud, nil, nil, nil, map[string]fi.CloudupTask{"SecurityGroup/masters.cluster": securityGroup})
+	if err != nil {
+		t.Fatalf("error building context: %v", err)
+	}
+	deletions, err := securityGroup.FindDeletions(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deletions) != 1 {
+		t.Fatalf("expected only the rule of kOps to be deleted, got %d deletions", len(deletions))
+	}
+	if id := deletions[0].(*deleteSecurityGroupRule).rule.ID; id != ruleIDs["kops"] {
+		t.Errorf("expected rule %s to be deleted, got %s", ruleIDs["kops"], id)
+	}
+}
+
+func Test_SecurityGroupRule_RepeatedApplies(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	group, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "masters.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	nodes, err := cloud.CreateSecurityGroup(sg.CreateOpts{Name: "nodes.cluster"})
+	if err != nil {
+		t.Fatalf("error creating security group: %v", err)
+	}
+	secGroup := &SecurityGroup{ID: fi.PtrTo(group.ID), Name: fi.PtrTo(group.Name)}
+
+	// equivalent rules created by others, Neutron accepts protocol numbers and does not mask remote prefixes
+	existing := map[string]sgr.CreateOpts{
+		"protocol number": {Protocol: "6", PortRangeMin: 443, PortRangeMax: 443, RemoteIPPrefix: "10.1.2.3/16"},
+		"any address":     {Protocol: sgr.ProtocolTCP, PortRangeMin: 1, PortRangeMax: 65535, RemoteIPPrefix: "0.0.0.0/0"},
+	}
+	existingIDs := map[string]string{}
+	for desc, opts := range existing {
+		opts.Direction = sgr.DirIngress
+		opts.EtherType = sgr.EtherType4
+		opts.SecGroupID = group.ID
+		opts.Description = "added by others"
+		rule, err := cloud.CreateSecurityGroupRule(opts)
+		if err != nil {
+			t.Fatalf("error creating security group rule: %v", err)
+		}
+		existingIDs[desc] = rule.ID
+	}
+
+	desired := map[string]*SecurityGroupRule{
+		"prefix": {
+			Protocol:       fi.PtrTo(string(sgr.ProtocolTCP)),
+			PortRangeMin:   Int(443),
+			PortRangeMax:   Int(443),
+			RemoteIPPrefix: fi.PtrTo("10.0.0.0/8"),
+		},
+		"ipv6 prefix": {
+			Protocol:       fi.PtrTo(string(sgr.ProtocolTCP)),
+			PortRangeMin:   Int(443),
+			PortRangeMax:   Int(443),
+			RemoteIPPrefix: fi.PtrTo("2001:db8::/32"),
+		},
+		"remote group": {
+			Protocol:     fi.PtrTo(string(sgr.ProtocolTCP)),
+			PortRangeMin: Int(22),
+			PortRangeMax: Int(22),
+			RemoteGroup:  &SecurityGroup{ID: fi.PtrTo(nodes.ID), Name: fi.PtrTo(nodes.Name)},
+		},
+		"protocol number": {
+			Protocol:       fi.PtrTo(string(sgr.ProtocolTCP)),
+			PortRangeMin:   Int(443),
+			PortRangeMax:   Int(443),
+			RemoteIPPrefix: fi.PtrTo("10.1.0.0/16"),
+		},
+		"any address": {
+			Protocol: fi.PtrTo(string(sgr.ProtocolTCP)),
+		},
+	}
+
+	ctx, err := fi.NewCloudupContext(context.TODO(), fi.DeletionProcessingModeDeleteIncludingDeferred, openstack.NewOpenstackAPITarget(cloud), nil, cloud, nil, nil, nil, map[string]fi.CloudupTask{})
+	if err != nil {
+		t.Fatalf("error building context: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		for desc, desiredRule := range desired {
+			// every apply builds the tasks again
+			rule := *desiredRule
+			rule.Direction = fi.PtrTo(string(sgr.DirIngress))
+			rule.EtherType = fi.PtrTo(string(sgr.EtherType4))
+			rule.SecGroup = secGroup
+			rule.Lifecycle = fi.LifecycleSync
+			if err := rule.Run(ctx); err != nil {
+				t.Fatalf("apply %d: unexpected error applying rule %q: %v", i, desc, err)
+			}
+			if id, ok := existingIDs[desc]; ok && fi.ValueOf(rule.ID) != id {
+				t.Errorf("apply %d: expected rule %q to adopt the equivalent rule %s, got %s", i, desc, id, fi.ValueOf(rule.ID))
+			}
+		}
+	}
+
+	// the mock only lists the rules of a remote group if it is filtered for
+	var rules []sgr.SecGroupRule
+	for _, remoteGroupID := range []string{"", nodes.ID} {
+		listed, err := cloud.ListSecurityGroupRules(sgr.ListOpts{SecGroupID: group.ID, RemoteGroupID: remoteGroupID})
+		if err != nil {
+			t.Fatalf("error listing security group rules: %v", err)
+		}
+		rules = append(rules, listed...)
+	}
+	if len(rules) != len(desired) {
+		t.Errorf("expected one rule per desired rule, got %d rules instead of %d", len(rules), len(desired))
+	}
+}
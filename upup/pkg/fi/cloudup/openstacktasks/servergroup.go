@@ -18,6 +18,7 @@ package openstacktasks
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/servergroups"
@@ -124,11 +125,22 @@ func (s *ServerGroup) Run(context *fi.CloudupContext) error {
 	return fi.CloudupDefaultDeltaRunMethod(s, context)
 }
 
+// serverGroupPolicies are the policies supported by the compute API microversion we use
+var serverGroupPolicies = []string{"affinity", "anti-affinity", "soft-affinity", "soft-anti-affinity"}
+
 func (_ *ServerGroup) CheckChanges(a, e, changes *ServerGroup) error {
 	if a == nil {
 		if e.Name == nil {
 			return fi.RequiredField("Name")
 		}
+		if len(e.Policies) > 1 {
+			return fmt.Errorf("ServerGroup %s must have a single policy, got %v", fi.ValueOf(e.Name), e.Policies)
+		}
+		for _, policy := range e.Policies {
+			if !slices.Contains(serverGroupPolicies, policy) {
+				return fmt.Errorf("ServerGroup %s has unsupported policy %q, must be one of %v", fi.ValueOf(e.Name), policy, serverGroupPolicies)
+			}
+		}
 	} else {
 		if changes.ID != nil {
 			return fi.CannotChangeField("ID")
@@ -136,11 +148,18 @@ func (_ *ServerGroup) CheckChanges(a, e, changes *ServerGroup) error {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
 		}
+		if changes.Policies != nil {
+			// Nova does not support updating server groups, members would have to be moved to a new group
+			return fmt.Errorf("cannot change policy of ServerGroup %s from %v to %v: server group policies are immutable, delete the server group and its instances to change it",
+				fi.ValueOf(a.Name), a.Policies, e.Policies)
+		}
 	}
 	return nil
 }
 
 func (_ *ServerGroup) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *ServerGroup) error {
+	defer openstack.RecordRender("ServerGroup", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating ServerGroup with Name:%q", fi.ValueOf(e.Name))
 
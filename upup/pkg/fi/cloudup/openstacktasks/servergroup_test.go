@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func Test_ServerGroup_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *ServerGroup
+		expected      *ServerGroup
+		changes       *ServerGroup
+		expectedError error
+	}{
+		{
+			desc:   "actual nil soft-anti-affinity",
+			actual: nil,
+			expected: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"soft-anti-affinity"},
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil required field Name missing",
+			actual: nil,
+			expected: &ServerGroup{
+				Policies: []string{"anti-affinity"},
+			},
+			expectedError: fi.RequiredField("Name"),
+		},
+		{
+			desc:   "actual nil unsupported policy",
+			actual: nil,
+			expected: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"spread"},
+			},
+			expectedError: fmt.Errorf("ServerGroup name has unsupported policy \"spread\", must be one of [affinity anti-affinity soft-affinity soft-anti-affinity]"),
+		},
+		{
+			desc:   "actual nil multiple policies",
+			actual: nil,
+			expected: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"affinity", "anti-affinity"},
+			},
+			expectedError: fmt.Errorf("ServerGroup name must have a single policy, got [affinity anti-affinity]"),
+		},
+		{
+			desc: "actual not nil policy changed",
+			actual: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"anti-affinity"},
+			},
+			expected: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"soft-anti-affinity"},
+			},
+			changes: &ServerGroup{
+				Policies: []string{"soft-anti-affinity"},
+			},
+			expectedError: fmt.Errorf("cannot change policy of ServerGroup name from [anti-affinity] to [soft-anti-affinity]: server group policies are immutable, delete the server group and its instances to change it"),
+		},
+		{
+			desc: "actual not nil instance group size changed",
+			actual: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"anti-affinity"},
+			},
+			expected: &ServerGroup{
+				Name:     fi.PtrTo("name"),
+				Policies: []string{"anti-affinity"},
+				IGMap:    map[string]*int32{"node": fi.PtrTo(int32(2))},
+			},
+			changes: &ServerGroup{
+				IGMap: map[string]*int32{"node": fi.PtrTo(int32(2))},
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var serverGroup ServerGroup
+			err := (&serverGroup).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
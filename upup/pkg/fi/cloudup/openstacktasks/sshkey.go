@@ -114,6 +114,8 @@ func openstackKeyPairName(org string) string {
 }
 
 func (_ *SSHKey) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *SSHKey) error {
+	defer openstack.RecordRender("SSHKey", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating Keypair with name:%q", fi.ValueOf(e.Name))
 
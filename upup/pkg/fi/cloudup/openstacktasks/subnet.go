@@ -17,7 +17,10 @@ limitations under the License.
 package openstacktasks
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"slices"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
@@ -35,6 +38,63 @@ type Subnet struct {
 	DNSServers []*string
 	Tag        *string
 	Lifecycle  fi.Lifecycle
+
+	// AllocationPools are the IP ranges from which Neutron allocates addresses.
+	// When empty, Neutron uses a pool spanning the whole CIDR and kOps does not manage it.
+	AllocationPools []subnets.AllocationPool
+
+	// Preserve marks the subnet as owned by others, e.g. because it is shared with resources outside of the cluster.
+	// A preserved subnet is only detached from the cluster when the cluster is deleted, other subnets are tagged as
+	// created by kOps and deleted with the cluster.
+	Preserve *bool
+
+	// SegmentID is the segment of a routed provider network the subnet is created on, it cannot be changed
+	SegmentID *string
+
+	// IPv6AddressMode is how the addresses of an IPv6 subnet are assigned, dhcpv6-stateful, dhcpv6-stateless or slaac
+	IPv6AddressMode *string
+	// IPv6RAMode is how the router advertisements of an IPv6 subnet are sent, dhcpv6-stateful, dhcpv6-stateless or slaac
+	IPv6RAMode *string
+}
+
+// ipv6SubnetModes are the address and router advertisement modes of IPv6 subnets
+var ipv6SubnetModes = []string{"dhcpv6-stateful", "dhcpv6-stateless", "slaac"}
+
+// ipVersion returns the IP version of the subnet CIDR, subnets without a valid CIDR are IPv4
+func (e *Subnet) ipVersion() gophercloud.IPVersion {
+	ip, _, err := net.ParseCIDR(fi.ValueOf(e.CIDR))
+	if err == nil && ip.To4() == nil {
+		return gophercloud.IPv6
+	}
+	return gophercloud.IPv4
+}
+
+// subnetSegmentExt sets the segment of the subnet, which is not supported by subnets.CreateOpts
+type subnetSegmentExt struct {
+	subnets.CreateOptsBuilder
+	SegmentID string
+}
+
+func (opts subnetSegmentExt) ToSubnetCreateMap() (map[string]interface{}, error) {
+	base, err := opts.CreateOptsBuilder.ToSubnetCreateMap()
+	if err != nil {
+		return nil, err
+	}
+	base["subnet"].(map[string]interface{})["segment_id"] = opts.SegmentID
+	return base, nil
+}
+
+// ownershipTags returns the tag marking the subnet as created by kOps or as preserved, and the tag of the other
+// ownership that is removed from the subnet
+func (e *Subnet) ownershipTags() (string, string) {
+	clusterName := fi.ValueOf(e.Tag)
+	if clusterName == "" {
+		return "", ""
+	}
+	if fi.ValueOf(e.Preserve) {
+		return PreserveTag(clusterName), ClusterTag(clusterName)
+	}
+	return ClusterTag(clusterName), PreserveTag(clusterName)
 }
 
 // GetDependencies returns the dependencies of the Port task
@@ -83,6 +143,29 @@ func NewSubnetTaskFromCloud(cloud openstack.OpenstackCloud, lifecycle fi.Lifecyc
 		DNSServers: nameservers,
 		Tag:        fi.PtrTo(tag),
 	}
+	// Only report allocation pools when they are managed, otherwise the
+	// default pool chosen by Neutron would always show up as a change
+	if find != nil && len(find.AllocationPools) > 0 {
+		actual.AllocationPools = subnet.AllocationPools
+	}
+	if subnet.IPv6AddressMode != "" {
+		actual.IPv6AddressMode = fi.PtrTo(subnet.IPv6AddressMode)
+	}
+	if subnet.IPv6RAMode != "" {
+		actual.IPv6RAMode = fi.PtrTo(subnet.IPv6RAMode)
+	}
+	// only subnets tagged as preserved are preserved, subnets created by older versions of kOps are not tagged
+	if find != nil && find.Preserve != nil {
+		actual.Preserve = fi.PtrTo(slices.Contains(subnet.Tags, PreserveTag(fi.ValueOf(find.Tag))))
+	}
+	// the segment is not returned by gophercloud, it is only read when it is managed
+	if find != nil && find.SegmentID != nil {
+		segmentID, err := cloud.GetSubnetSegmentID(subnet.ID)
+		if err != nil {
+			return nil, fmt.Errorf("NewSubnetTaskFromCloud: Failed to get segment of subnet %s: %v", subnet.ID, err)
+		}
+		actual.SegmentID = fi.PtrTo(segmentID)
+	}
 	if find != nil {
 		find.ID = actual.ID
 	}
@@ -97,7 +180,7 @@ func (s *Subnet) Find(context *fi.CloudupContext) (*Subnet, error) {
 		NetworkID:  fi.ValueOf(s.Network.ID),
 		CIDR:       fi.ValueOf(s.CIDR),
 		EnableDHCP: fi.PtrTo(true),
-		IPVersion:  4,
+		IPVersion:  int(s.ipVersion()),
 	}
 	rs, err := cloud.ListSubnets(opt)
 	if err != nil {
@@ -126,6 +209,12 @@ func (*Subnet) CheckChanges(a, e, changes *Subnet) error {
 		if e.CIDR == nil {
 			return fi.RequiredField("CIDR")
 		}
+		if err := validateAllocationPools(e.CIDR, e.AllocationPools); err != nil {
+			return err
+		}
+		if err := e.validateIPv6Modes(); err != nil {
+			return err
+		}
 	} else {
 		if changes.Name != nil {
 			return fi.CannotChangeField("Name")
@@ -136,20 +225,89 @@ func (*Subnet) CheckChanges(a, e, changes *Subnet) error {
 		if changes.CIDR != nil {
 			return fi.CannotChangeField("CIDR")
 		}
+		if changes.SegmentID != nil {
+			return fi.CannotChangeField("SegmentID")
+		}
+		if changes.IPv6AddressMode != nil {
+			return fi.CannotChangeField("IPv6AddressMode")
+		}
+		if changes.IPv6RAMode != nil {
+			return fi.CannotChangeField("IPv6RAMode")
+		}
+		if changes.AllocationPools != nil {
+			if err := validateAllocationPools(a.CIDR, e.AllocationPools); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateIPv6Modes ensures that the IPv6 address and router advertisement modes are only set on IPv6 subnets
+func (e *Subnet) validateIPv6Modes() error {
+	modes := []struct {
+		field string
+		mode  *string
+	}{
+		{"IPv6AddressMode", e.IPv6AddressMode},
+		{"IPv6RAMode", e.IPv6RAMode},
+	}
+	for _, m := range modes {
+		if m.mode == nil {
+			continue
+		}
+		if e.ipVersion() != gophercloud.IPv6 {
+			return fmt.Errorf("%s can only be set on IPv6 subnets, subnet %s has CIDR %s", m.field, fi.ValueOf(e.Name), fi.ValueOf(e.CIDR))
+		}
+		if !slices.Contains(ipv6SubnetModes, fi.ValueOf(m.mode)) {
+			return fmt.Errorf("unsupported %s %q of subnet %s, supported modes are %v", m.field, fi.ValueOf(m.mode), fi.ValueOf(e.Name), ipv6SubnetModes)
+		}
+	}
+	return nil
+}
+
+// validateAllocationPools ensures that every allocation pool is a valid range inside of the subnet CIDR
+func validateAllocationPools(cidr *string, pools []subnets.AllocationPool) error {
+	if len(pools) == 0 {
+		return nil
+	}
+	_, ipNet, err := net.ParseCIDR(fi.ValueOf(cidr))
+	if err != nil {
+		return fmt.Errorf("error parsing subnet CIDR %q: %v", fi.ValueOf(cidr), err)
+	}
+	for _, pool := range pools {
+		start := net.ParseIP(pool.Start)
+		if start == nil {
+			return fmt.Errorf("invalid allocation pool start address %q", pool.Start)
+		}
+		end := net.ParseIP(pool.End)
+		if end == nil {
+			return fmt.Errorf("invalid allocation pool end address %q", pool.End)
+		}
+		if !ipNet.Contains(start) || !ipNet.Contains(end) {
+			return fmt.Errorf("allocation pool %s-%s is not within subnet CIDR %s", pool.Start, pool.End, fi.ValueOf(cidr))
+		}
+		if bytes.Compare(start.To16(), end.To16()) > 0 {
+			return fmt.Errorf("allocation pool start address %s is greater than end address %s", pool.Start, pool.End)
+		}
 	}
 	return nil
 }
 
 func (*Subnet) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Subnet) error {
+	defer openstack.RecordRender("Subnet", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating Subnet with name:%q", fi.ValueOf(e.Name))
 
 		opt := subnets.CreateOpts{
-			Name:       fi.ValueOf(e.Name),
-			NetworkID:  fi.ValueOf(e.Network.ID),
-			IPVersion:  gophercloud.IPv4,
-			CIDR:       fi.ValueOf(e.CIDR),
-			EnableDHCP: fi.PtrTo(true),
+			Name:            fi.ValueOf(e.Name),
+			NetworkID:       fi.ValueOf(e.Network.ID),
+			IPVersion:       e.ipVersion(),
+			CIDR:            fi.ValueOf(e.CIDR),
+			EnableDHCP:      fi.PtrTo(true),
+			IPv6AddressMode: fi.ValueOf(e.IPv6AddressMode),
+			IPv6RAMode:      fi.ValueOf(e.IPv6RAMode),
 		}
 
 		if len(e.DNSServers) > 0 {
@@ -159,14 +317,24 @@ func (*Subnet) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *S
 			}
 			opt.DNSNameservers = dnsNameSrv
 		}
-		v, err := t.Cloud.CreateSubnet(opt)
+		if len(e.AllocationPools) > 0 {
+			opt.AllocationPools = e.AllocationPools
+		}
+		var createOpts subnets.CreateOptsBuilder = opt
+		if e.SegmentID != nil {
+			createOpts = subnetSegmentExt{
+				CreateOptsBuilder: opt,
+				SegmentID:         fi.ValueOf(e.SegmentID),
+			}
+		}
+		v, err := t.Cloud.CreateSubnet(createOpts)
 		if err != nil {
 			return fmt.Errorf("Error creating subnet: %v", err)
 		}
 
-		err = t.Cloud.AppendTag(openstack.ResourceTypeSubnet, v.ID, fi.ValueOf(e.Tag))
-		if err != nil {
-			return fmt.Errorf("Error appending tag to subnet: %v", err)
+		ownershipTag, _ := e.ownershipTags()
+		if err := appendMissingTags(t.Cloud, openstack.ResourceTypeSubnet, v.ID, v.Tags, []string{fi.ValueOf(e.Tag), ownershipTag}); err != nil {
+			return err
 		}
 
 		e.ID = fi.PtrTo(v.ID)
@@ -174,9 +342,25 @@ func (*Subnet) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *S
 		return nil
 	} else {
 		if changes.Tag != nil {
-			err := t.Cloud.AppendTag(openstack.ResourceTypeSubnet, fi.ValueOf(a.ID), fi.ValueOf(changes.Tag))
+			if err := appendMissingTags(t.Cloud, openstack.ResourceTypeSubnet, fi.ValueOf(a.ID), []string{fi.ValueOf(a.Tag)}, []string{fi.ValueOf(changes.Tag)}); err != nil {
+				return err
+			}
+		}
+		if changes.Preserve != nil {
+			klog.V(2).Infof("Updating preservation of Subnet %q to %t", fi.ValueOf(e.Name), fi.ValueOf(e.Preserve))
+
+			subnet, err := t.Cloud.GetSubnet(fi.ValueOf(a.ID))
 			if err != nil {
-				return fmt.Errorf("error appending tag to subnet: %v", err)
+				return fmt.Errorf("error getting subnet %s: %v", fi.ValueOf(a.ID), err)
+			}
+			ownershipTag, otherTag := e.ownershipTags()
+			if err := appendMissingTags(t.Cloud, openstack.ResourceTypeSubnet, subnet.ID, subnet.Tags, []string{ownershipTag}); err != nil {
+				return err
+			}
+			if otherTag != "" && slices.Contains(subnet.Tags, otherTag) {
+				if err := t.Cloud.DeleteTag(openstack.ResourceTypeSubnet, subnet.ID, otherTag); err != nil {
+					return fmt.Errorf("error deleting tag %q from subnet %s: %v", otherTag, subnet.ID, err)
+				}
 			}
 		}
 		client := t.Cloud.NetworkingClient()
@@ -190,6 +374,9 @@ func (*Subnet) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *S
 			}
 			opt.DNSNameservers = &dnsNameSrv
 		}
+		if changes.AllocationPools != nil {
+			opt.AllocationPools = e.AllocationPools
+		}
 		result := subnets.Update(client, fi.ValueOf(a.ID), opt)
 		klog.Infof("Updated %v", opt)
 		if result.Err != nil {
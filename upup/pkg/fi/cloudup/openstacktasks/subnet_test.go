@@ -0,0 +1,351 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"k8s.io/kops/cloudmock/openstack/mocknetworking"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_Subnet_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *Subnet
+		expected      *Subnet
+		changes       *Subnet
+		expectedError error
+	}{
+		{
+			desc:   "actual nil all required fields set",
+			actual: nil,
+			expected: &Subnet{
+				Name:    fi.PtrTo("name"),
+				Network: &Network{ID: fi.PtrTo("networkID")},
+				CIDR:    fi.PtrTo("10.0.0.0/24"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil allocation pool within CIDR",
+			actual: nil,
+			expected: &Subnet{
+				Name:    fi.PtrTo("name"),
+				Network: &Network{ID: fi.PtrTo("networkID")},
+				CIDR:    fi.PtrTo("10.0.0.0/24"),
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "10.0.0.10", End: "10.0.0.254"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil allocation pool outside of CIDR",
+			actual: nil,
+			expected: &Subnet{
+				Name:    fi.PtrTo("name"),
+				Network: &Network{ID: fi.PtrTo("networkID")},
+				CIDR:    fi.PtrTo("10.0.0.0/24"),
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "10.0.0.10", End: "10.0.1.10"},
+				},
+			},
+			expectedError: fmt.Errorf("allocation pool 10.0.0.10-10.0.1.10 is not within subnet CIDR 10.0.0.0/24"),
+		},
+		{
+			desc:   "actual nil allocation pool start after end",
+			actual: nil,
+			expected: &Subnet{
+				Name:    fi.PtrTo("name"),
+				Network: &Network{ID: fi.PtrTo("networkID")},
+				CIDR:    fi.PtrTo("10.0.0.0/24"),
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "10.0.0.200", End: "10.0.0.100"},
+				},
+			},
+			expectedError: fmt.Errorf("allocation pool start address 10.0.0.200 is greater than end address 10.0.0.100"),
+		},
+		{
+			desc:   "actual nil allocation pool invalid address",
+			actual: nil,
+			expected: &Subnet{
+				Name:    fi.PtrTo("name"),
+				Network: &Network{ID: fi.PtrTo("networkID")},
+				CIDR:    fi.PtrTo("10.0.0.0/24"),
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "10.0.0", End: "10.0.0.100"},
+				},
+			},
+			expectedError: fmt.Errorf("invalid allocation pool start address \"10.0.0\""),
+		},
+		{
+			desc: "actual not nil allocation pools changed",
+			actual: &Subnet{
+				Name: fi.PtrTo("name"),
+				CIDR: fi.PtrTo("10.0.0.0/24"),
+			},
+			expected: &Subnet{
+				Name: fi.PtrTo("name"),
+				CIDR: fi.PtrTo("10.0.0.0/24"),
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "10.0.0.50", End: "10.0.0.60"},
+				},
+			},
+			changes: &Subnet{
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "10.0.0.50", End: "10.0.0.60"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil invalid allocation pools changed",
+			actual: &Subnet{
+				Name: fi.PtrTo("name"),
+				CIDR: fi.PtrTo("10.0.0.0/24"),
+			},
+			expected: &Subnet{
+				Name: fi.PtrTo("name"),
+				CIDR: fi.PtrTo("10.0.0.0/24"),
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "192.168.0.50", End: "192.168.0.60"},
+				},
+			},
+			changes: &Subnet{
+				AllocationPools: []subnets.AllocationPool{
+					{Start: "192.168.0.50", End: "192.168.0.60"},
+				},
+			},
+			expectedError: fmt.Errorf("allocation pool 192.168.0.50-192.168.0.60 is not within subnet CIDR 10.0.0.0/24"),
+		},
+		{
+			desc: "actual not nil unchangeable field CIDR set",
+			actual: &Subnet{
+				Name: fi.PtrTo("name"),
+				CIDR: fi.PtrTo("10.0.0.0/24"),
+			},
+			expected: &Subnet{
+				Name: fi.PtrTo("name"),
+				CIDR: fi.PtrTo("10.0.1.0/24"),
+			},
+			changes: &Subnet{
+				CIDR: fi.PtrTo("10.0.1.0/24"),
+			},
+			expectedError: fi.CannotChangeField("CIDR"),
+		},
+		{
+			desc:   "actual nil IPv6 modes on IPv6 subnet",
+			actual: nil,
+			expected: &Subnet{
+				Name:            fi.PtrTo("name"),
+				Network:         &Network{ID: fi.PtrTo("networkID")},
+				CIDR:            fi.PtrTo("2001:db8::/64"),
+				IPv6AddressMode: fi.PtrTo("slaac"),
+				IPv6RAMode:      fi.PtrTo("slaac"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil IPv6 address mode on IPv4 subnet",
+			actual: nil,
+			expected: &Subnet{
+				Name:            fi.PtrTo("name"),
+				Network:         &Network{ID: fi.PtrTo("networkID")},
+				CIDR:            fi.PtrTo("10.0.0.0/24"),
+				IPv6AddressMode: fi.PtrTo("slaac"),
+			},
+			expectedError: fmt.Errorf("IPv6AddressMode can only be set on IPv6 subnets, subnet name has CIDR 10.0.0.0/24"),
+		},
+		{
+			desc:   "actual nil unsupported IPv6 RA mode",
+			actual: nil,
+			expected: &Subnet{
+				Name:       fi.PtrTo("name"),
+				Network:    &Network{ID: fi.PtrTo("networkID")},
+				CIDR:       fi.PtrTo("2001:db8::/64"),
+				IPv6RAMode: fi.PtrTo("dhcpv6"),
+			},
+			expectedError: fmt.Errorf("unsupported IPv6RAMode \"dhcpv6\" of subnet name, supported modes are [dhcpv6-stateful dhcpv6-stateless slaac]"),
+		},
+		{
+			desc: "actual not nil unchangeable field IPv6AddressMode set",
+			actual: &Subnet{
+				Name:            fi.PtrTo("name"),
+				CIDR:            fi.PtrTo("2001:db8::/64"),
+				IPv6AddressMode: fi.PtrTo("slaac"),
+			},
+			expected: &Subnet{
+				Name:            fi.PtrTo("name"),
+				CIDR:            fi.PtrTo("2001:db8::/64"),
+				IPv6AddressMode: fi.PtrTo("dhcpv6-stateless"),
+			},
+			changes: &Subnet{
+				IPv6AddressMode: fi.PtrTo("dhcpv6-stateless"),
+			},
+			expectedError: fi.CannotChangeField("IPv6AddressMode"),
+		},
+		{
+			desc: "actual not nil unchangeable field SegmentID set",
+			actual: &Subnet{
+				Name:      fi.PtrTo("name"),
+				CIDR:      fi.PtrTo("10.0.0.0/24"),
+				SegmentID: fi.PtrTo("segment-a"),
+			},
+			expected: &Subnet{
+				Name:      fi.PtrTo("name"),
+				CIDR:      fi.PtrTo("10.0.0.0/24"),
+				SegmentID: fi.PtrTo("segment-b"),
+			},
+			changes: &Subnet{
+				SegmentID: fi.PtrTo("segment-b"),
+			},
+			expectedError: fi.CannotChangeField("SegmentID"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var subnet Subnet
+			err := (&subnet).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_Subnet_OwnershipTags(t *testing.T) {
+	tests := []struct {
+		desc          string
+		subnet        *Subnet
+		expectedTag   string
+		expectedOther string
+	}{
+		{
+			desc:          "created subnet",
+			subnet:        &Subnet{Tag: fi.PtrTo("cluster")},
+			expectedTag:   "KubernetesCluster=cluster",
+			expectedOther: "KopsPreserve=cluster",
+		},
+		{
+			desc:          "preserved subnet",
+			subnet:        &Subnet{Tag: fi.PtrTo("cluster"), Preserve: fi.PtrTo(true)},
+			expectedTag:   "KopsPreserve=cluster",
+			expectedOther: "KubernetesCluster=cluster",
+		},
+		{
+			desc:          "subnet without cluster",
+			subnet:        &Subnet{Preserve: fi.PtrTo(true)},
+			expectedTag:   "",
+			expectedOther: "",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			tag, other := testCase.subnet.ownershipTags()
+			if tag != testCase.expectedTag || other != testCase.expectedOther {
+				t.Errorf("expected tags %q and %q, got %q and %q", testCase.expectedTag, testCase.expectedOther, tag, other)
+			}
+		})
+	}
+}
+
+func Test_Subnet_SegmentID(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+
+	expected := &Subnet{
+		Name:      fi.PtrTo("subnet.cluster"),
+		Network:   &Network{ID: fi.PtrTo(network.ID)},
+		CIDR:      fi.PtrTo("10.0.0.0/24"),
+		SegmentID: fi.PtrTo("segment-id"),
+		Lifecycle: fi.LifecycleSync,
+	}
+	target := openstack.NewOpenstackAPITarget(cloud)
+	if err := (&Subnet{}).RenderOpenstack(target, nil, expected, expected); err != nil {
+		t.Fatalf("unexpected error creating subnet: %v", err)
+	}
+
+	subnet, err := cloud.GetSubnet(fi.ValueOf(expected.ID))
+	if err != nil {
+		t.Fatalf("error getting subnet: %v", err)
+	}
+	actual, err := NewSubnetTaskFromCloud(cloud, fi.LifecycleSync, subnet, expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fi.ValueOf(actual.SegmentID) != "segment-id" {
+		t.Errorf("expected the segment segment-id to be read back, got %q", fi.ValueOf(actual.SegmentID))
+	}
+
+	// the segment of subnets that do not manage it is not read
+	actual, err = NewSubnetTaskFromCloud(cloud, fi.LifecycleSync, subnet, &Subnet{Name: fi.PtrTo("subnet.cluster")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.SegmentID != nil {
+		t.Errorf("expected the segment to be unset, got %q", fi.ValueOf(actual.SegmentID))
+	}
+}
+
+func Test_Subnet_IPv6Modes(t *testing.T) {
+	cloud := &openstack.MockCloud{
+		MockNeutronClient: mocknetworking.CreateClient(),
+	}
+	network, err := cloud.CreateNetwork(networks.CreateOpts{Name: "cluster"})
+	if err != nil {
+		t.Fatalf("error creating network: %v", err)
+	}
+
+	expected := &Subnet{
+		Name:            fi.PtrTo("subnet.cluster"),
+		Network:         &Network{ID: fi.PtrTo(network.ID)},
+		CIDR:            fi.PtrTo("2001:db8::/64"),
+		IPv6AddressMode: fi.PtrTo("dhcpv6-stateless"),
+		IPv6RAMode:      fi.PtrTo("dhcpv6-stateless"),
+		Lifecycle:       fi.LifecycleSync,
+	}
+	target := openstack.NewOpenstackAPITarget(cloud)
+	if err := (&Subnet{}).RenderOpenstack(target, nil, expected, expected); err != nil {
+		t.Fatalf("unexpected error creating subnet: %v", err)
+	}
+
+	subnet, err := cloud.GetSubnet(fi.ValueOf(expected.ID))
+	if err != nil {
+		t.Fatalf("error getting subnet: %v", err)
+	}
+	if subnet.IPVersion != 6 {
+		t.Errorf("expected an IPv6 subnet, got IP version %d", subnet.IPVersion)
+	}
+	actual, err := NewSubnetTaskFromCloud(cloud, fi.LifecycleSync, subnet, expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fi.ValueOf(actual.IPv6AddressMode) != "dhcpv6-stateless" || fi.ValueOf(actual.IPv6RAMode) != "dhcpv6-stateless" {
+		t.Errorf("expected the IPv6 modes to be read back, got %q and %q", fi.ValueOf(actual.IPv6AddressMode), fi.ValueOf(actual.IPv6RAMode))
+	}
+}
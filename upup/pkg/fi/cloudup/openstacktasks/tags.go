@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/kops/pkg/truncate"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+// ClusterTag returns the tag of the resources owned by the cluster
+func ClusterTag(clusterName string) string {
+	return truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagClusterName, clusterName), TRUNCATE_OPT)
+}
+
+// ClusterTags returns the canonical tags of a resource owned by the cluster, the cluster tag followed by the given tags
+func ClusterTags(clusterName string, tags ...string) []string {
+	return append([]string{ClusterTag(clusterName)}, tags...)
+}
+
+// PreserveTag returns the tag of resources that are used but not owned by the cluster, they are not deleted with the
+// cluster even if they are named like resources created by kOps
+func PreserveTag(clusterName string) string {
+	return truncate.TruncateString(fmt.Sprintf("%s=%s", openstack.TagKopsPreserve, clusterName), TRUNCATE_OPT)
+}
+
+// HasClusterTag returns true if the tags mark the resource as owned by the cluster. Networks and subnets are tagged
+// with the plain cluster name, all other resources with the cluster tag.
+func HasClusterTag(tags []string, clusterName string) bool {
+	return slices.Contains(tags, ClusterTag(clusterName)) || slices.Contains(tags, clusterName)
+}
+
+// clusterNameFromTags returns the name of the cluster owning the resource from its cluster tag, empty if it has none.
+// The name may be truncated like the tag.
+func clusterNameFromTags(tags []string) string {
+	for _, tag := range tags {
+		if name, ok := strings.CutPrefix(tag, openstack.TagClusterName+"="); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// intersectTags returns the tags of interest of a resource; because we only add tags, the tags of interest are the
+// tags that occur in the desired set. Tags added by others are neither reported as changes nor removed.
+func intersectTags(tags []string, desired []string) []string {
+	var actual []string
+	for _, tag := range desired {
+		if slices.Contains(tags, tag) {
+			actual = append(actual, tag)
+		}
+	}
+	return actual
+}
+
+// mergeTags returns the tags of the resource with the missing desired tags added, for APIs that replace all tags
+func mergeTags(tags []string, desired []string) []string {
+	merged := slices.Clone(tags)
+	for _, tag := range desired {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// appendMissingTags adds the desired tags that the resource does not have yet, other tags are kept
+func appendMissingTags(cloud openstack.OpenstackCloud, resourceType string, id string, tags []string, desired []string) error {
+	for _, tag := range desired {
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if err := cloud.AppendTag(resourceType, id, tag); err != nil {
+			return fmt.Errorf("error appending tag %q to %s %s: %v", tag, resourceType, id, err)
+		}
+	}
+	return nil
+}
+
+// intersectMetadata returns the metadata of interest of a resource, the keys that occur in the desired metadata
+func intersectMetadata(metadata map[string]string, desired map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	actual := make(map[string]string)
+	for k, v := range metadata {
+		if _, found := desired[k]; found {
+			actual[k] = v
+		}
+	}
+	if len(actual) == 0 && desired == nil {
+		// Avoid problems with comparison between nil & {}
+		return nil
+	}
+	return actual
+}
+
+// mergeMetadata returns the metadata of the resource updated with the desired metadata, for APIs that replace all
+// metadata
+func mergeMetadata(metadata map[string]string, desired map[string]string) map[string]string {
+	merged := make(map[string]string, len(metadata)+len(desired))
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	for k, v := range desired {
+		merged[k] = v
+	}
+	return merged
+}
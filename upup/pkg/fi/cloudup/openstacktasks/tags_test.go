@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ClusterTags(t *testing.T) {
+	tags := ClusterTags("cluster", "KopsInstanceGroup=master")
+	expected := []string{"KubernetesCluster=cluster", "KopsInstanceGroup=master"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, tags)
+	}
+
+	if !HasClusterTag(tags, "cluster") {
+		t.Errorf("expected tags %v to be owned by the cluster", tags)
+	}
+	if !HasClusterTag([]string{"cluster"}, "cluster") {
+		t.Errorf("expected the plain cluster name to mark the resource as owned by the cluster")
+	}
+	if HasClusterTag(ClusterTags("other"), "cluster") {
+		t.Errorf("expected the tags of another cluster not to mark the resource as owned by the cluster")
+	}
+
+	if name := clusterNameFromTags(append([]string{"foreign"}, tags...)); name != "cluster" {
+		t.Errorf("expected the cluster name to be read from the tags, got %q", name)
+	}
+	if name := clusterNameFromTags([]string{"foreign"}); name != "" {
+		t.Errorf("expected no cluster name without cluster tag, got %q", name)
+	}
+}
+
+func Test_IntersectTags(t *testing.T) {
+	tests := []struct {
+		desc     string
+		tags     []string
+		desired  []string
+		expected []string
+	}{
+		{
+			desc:     "foreign tags are ignored",
+			tags:     []string{"foreign", "KubernetesCluster=cluster"},
+			desired:  []string{"KubernetesCluster=cluster"},
+			expected: []string{"KubernetesCluster=cluster"},
+		},
+		{
+			desc:     "missing tags are not reported",
+			tags:     []string{"foreign"},
+			desired:  []string{"KubernetesCluster=cluster"},
+			expected: nil,
+		},
+		{
+			desc:     "no desired tags",
+			tags:     []string{"foreign"},
+			desired:  nil,
+			expected: nil,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			actual := intersectTags(testCase.tags, testCase.desired)
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected tags %v, got %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_MergeTags(t *testing.T) {
+	tags := []string{"foreign", "KubernetesCluster=cluster"}
+	merged := mergeTags(tags, []string{"KubernetesCluster=cluster", "KopsName=api"})
+
+	expected := []string{"foreign", "KubernetesCluster=cluster", "KopsName=api"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected tags %v, got %v", expected, merged)
+	}
+	if len(tags) != 2 {
+		t.Errorf("expected the tags of the resource not to be modified, got %v", tags)
+	}
+}
+
+func Test_IntersectMetadata(t *testing.T) {
+	metadata := map[string]string{
+		"KubernetesCluster": "cluster",
+		"readonly":          "False",
+		"attached_mode":     "rw",
+	}
+	desired := map[string]string{
+		"KubernetesCluster": "cluster",
+		"KopsName":          "etcd-1",
+	}
+
+	actual := intersectMetadata(metadata, desired)
+	expected := map[string]string{"KubernetesCluster": "cluster"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected metadata %v, got %v", expected, actual)
+	}
+
+	merged := mergeMetadata(metadata, desired)
+	expected = map[string]string{
+		"KubernetesCluster": "cluster",
+		"KopsName":          "etcd-1",
+		"readonly":          "False",
+		"attached_mode":     "rw",
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected metadata %v, got %v", expected, merged)
+	}
+}
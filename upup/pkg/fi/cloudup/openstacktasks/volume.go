@@ -18,6 +18,7 @@ package openstacktasks
 
 import (
 	"fmt"
+	"time"
 
 	cinderv3 "github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumes"
 	"k8s.io/klog/v2"
@@ -34,6 +35,9 @@ type Volume struct {
 	SizeGB           *int64
 	Tags             map[string]string
 	Lifecycle        fi.Lifecycle
+
+	// Encrypted requires the volume to be encrypted, the apply fails if the volume type does not have encryption configured.
+	Encrypted *bool
 }
 
 var _ fi.CompareWithID = &Volume{}
@@ -43,40 +47,57 @@ func (c *Volume) CompareWithID() *string {
 	return c.ID
 }
 
+// volumeExtendTimeout is the time to wait for a volume to be extended
+const volumeExtendTimeout = 5 * time.Minute
+
 func (c *Volume) Find(context *fi.CloudupContext) (*Volume, error) {
 	cloud := context.T.Cloud.(openstack.OpenstackCloud)
-	opt := cinderv3.ListOpts{
-		Name: fi.ValueOf(c.Name),
-	}
-	volumes, err := cloud.ListVolumes(opt)
-	if err != nil {
+	v, err := findCloudVolume(cloud, c)
+	if err != nil || v == nil {
 		return nil, err
 	}
-	n := len(volumes)
-	if n == 0 {
-		return nil, nil
-	} else if n != 1 {
-		return nil, fmt.Errorf("found multiple Volumes with name: %s", fi.ValueOf(c.Name))
-	}
-	v := volumes[0]
+	// metadata added by others, e.g. "readonly" and "attached_mode" added by OpenStack, is not compared
 	actual := &Volume{
 		ID:               fi.PtrTo(v.ID),
 		Name:             fi.PtrTo(v.Name),
 		AvailabilityZone: fi.PtrTo(v.AvailabilityZone),
 		VolumeType:       fi.PtrTo(v.VolumeType),
 		SizeGB:           fi.PtrTo(int64(v.Size)),
-		Tags:             v.Metadata,
+		Tags:             intersectMetadata(v.Metadata, c.Tags),
 		Lifecycle:        c.Lifecycle,
+		Encrypted:        fi.PtrTo(v.Encrypted),
+	}
+	// encryption is only compared if it is required
+	if !fi.ValueOf(c.Encrypted) {
+		actual.Encrypted = c.Encrypted
+	}
+	// the default volume type is used if none is specified
+	if fi.ValueOf(c.VolumeType) == "" {
+		actual.VolumeType = c.VolumeType
 	}
-	// remove tags "readonly" and "attached_mode", openstack are adding these and if not removed
-	// kops will always try to update volumes
-	delete(actual.Tags, "readonly")
-	delete(actual.Tags, "attached_mode")
 	c.ID = actual.ID
 	c.AvailabilityZone = actual.AvailabilityZone
 	return actual, nil
 }
 
+// findCloudVolume returns the volume of the task, nil if it does not exist. The task is not modified.
+func findCloudVolume(cloud openstack.OpenstackCloud, c *Volume) (*cinderv3.Volume, error) {
+	opt := cinderv3.ListOpts{
+		Name: fi.ValueOf(c.Name),
+	}
+	volumes, err := cloud.ListVolumes(opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(volumes)
+	if n == 0 {
+		return nil, nil
+	} else if n != 1 {
+		return nil, fmt.Errorf("found multiple Volumes with name: %s", fi.ValueOf(c.Name))
+	}
+	return &volumes[0], nil
+}
+
 func (c *Volume) Normalize(context *fi.CloudupContext) error {
 	cloud := context.T.Cloud.(openstack.OpenstackCloud)
 	for k, v := range cloud.GetCloudTags() {
@@ -110,17 +131,33 @@ func (_ *Volume) CheckChanges(a, e, changes *Volume) error {
 		if changes.AvailabilityZone != nil {
 			return fi.CannotChangeField("AvailabilityZone")
 		}
-		if changes.VolumeType != nil {
-			return fi.CannotChangeField("VolumeType")
+		if changes.Encrypted != nil {
+			return fmt.Errorf("Volume %s is not encrypted, encryption cannot be enabled on an existing volume", fi.ValueOf(a.Name))
 		}
-		if changes.SizeGB != nil {
-			return fi.CannotChangeField("SizeGB")
+		if changes.SizeGB != nil && fi.ValueOf(e.SizeGB) < fi.ValueOf(a.SizeGB) {
+			return fmt.Errorf("cannot shrink Volume %s from %dGB to %dGB", fi.ValueOf(a.Name), fi.ValueOf(a.SizeGB), fi.ValueOf(e.SizeGB))
 		}
 	}
 	return nil
 }
 
+// checkVolumeTypeEncrypted fails if the volume type is not encrypted. Inspecting volume types usually
+// requires admin permissions, if that is not possible the encryption of the created volume is checked.
+func checkVolumeTypeEncrypted(cloud openstack.OpenstackCloud, volumeType string) error {
+	encrypted, err := cloud.IsVolumeTypeEncrypted(volumeType)
+	if err != nil {
+		klog.Warningf("Could not verify encryption of volume type %q: %v", volumeType, err)
+		return nil
+	}
+	if !encrypted {
+		return fmt.Errorf("volume type %q is not encrypted, but encryption is required", volumeType)
+	}
+	return nil
+}
+
 func (_ *Volume) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes *Volume) error {
+	defer openstack.RecordRender("Volume", fi.ValueOf(e.Name))()
+
 	if a == nil {
 		klog.V(2).Infof("Creating PersistentVolume with Name:%q", fi.ValueOf(e.Name))
 
@@ -137,20 +174,66 @@ func (_ *Volume) RenderOpenstack(t *openstack.OpenstackAPITarget, a, e, changes
 			VolumeType:       fi.ValueOf(e.VolumeType),
 		}
 
+		if fi.ValueOf(e.Encrypted) && e.VolumeType != nil {
+			if err := checkVolumeTypeEncrypted(t.Cloud, fi.ValueOf(e.VolumeType)); err != nil {
+				return err
+			}
+		}
+
 		v, err := t.Cloud.CreateVolume(opt)
 		if err != nil {
 			return fmt.Errorf("error creating PersistentVolume: %v", err)
 		}
 
+		// Cinder reports the encryption of the volume, also when the volume type can not be inspected
+		if fi.ValueOf(e.Encrypted) && !v.Encrypted {
+			if err := t.Cloud.DeleteVolume(v.ID); err != nil {
+				klog.Warningf("Failed to delete unencrypted volume %q: %v", v.ID, err)
+			}
+			return fmt.Errorf("Volume %s must be encrypted, but volume type %q is not encrypted", fi.ValueOf(e.Name), v.VolumeType)
+		}
+
 		e.ID = fi.PtrTo(v.ID)
 		e.AvailabilityZone = fi.PtrTo(v.AvailabilityZone)
 		return nil
 	}
 
+	if changes != nil && changes.SizeGB != nil {
+		klog.V(2).Infof("Extending volume %q from %dGB to %dGB", fi.ValueOf(a.ID), fi.ValueOf(a.SizeGB), fi.ValueOf(e.SizeGB))
+
+		err := t.Cloud.ExtendVolume(fi.ValueOf(a.ID), int(fi.ValueOf(e.SizeGB)))
+		if err != nil {
+			return fmt.Errorf("error extending volume %q: %v", fi.ValueOf(a.ID), err)
+		}
+		// Cinder rejects other actions, such as a retype, while the volume is extending
+		if err := openstack.WaitVolumeAvailable(t.Cloud, fi.ValueOf(a.ID), volumeExtendTimeout); err != nil {
+			return fmt.Errorf("error waiting for volume %q to be extended: %v", fi.ValueOf(a.ID), err)
+		}
+	}
+
+	if changes != nil && changes.VolumeType != nil {
+		if fi.ValueOf(e.Encrypted) {
+			if err := checkVolumeTypeEncrypted(t.Cloud, fi.ValueOf(e.VolumeType)); err != nil {
+				return err
+			}
+		}
+		klog.V(2).Infof("Changing type of volume %q from %q to %q", fi.ValueOf(a.ID), fi.ValueOf(a.VolumeType), fi.ValueOf(e.VolumeType))
+
+		err := t.Cloud.RetypeVolume(fi.ValueOf(a.ID), fi.ValueOf(e.VolumeType))
+		if err != nil {
+			return fmt.Errorf("error changing type of volume %q: %v", fi.ValueOf(a.ID), err)
+		}
+	}
+
 	if changes != nil && changes.Tags != nil {
 		klog.V(2).Infof("Update the tags on volume %q: %v, the differences are %v", fi.ValueOf(e.ID), e.Tags, changes.Tags)
 
-		err := t.Cloud.SetVolumeTags(fi.ValueOf(e.ID), e.Tags)
+		// the metadata of the volume is replaced, metadata added by others is kept
+		volume, err := cinderv3.Get(t.Cloud.BlockStorageClient(), fi.ValueOf(e.ID)).Extract()
+		if err != nil {
+			return fmt.Errorf("error getting volume %q: %v", fi.ValueOf(e.ID), err)
+		}
+		err = t.Cloud.SetVolumeTags(fi.ValueOf(e.ID), mergeMetadata(volume.Metadata, e.Tags))
 		if err != nil {
 			return fmt.Errorf("error updating the tags on volume %q: %v", fi.ValueOf(e.ID), err)
 		}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstacktasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	cinderv3 "github.com/gophercloud/gophercloud/openstack/blockstorage/v3/volumes"
+	"k8s.io/kops/cloudmock/openstack/mockblockstorage"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
+
+func Test_Volume_CheckChanges(t *testing.T) {
+	tests := []struct {
+		desc          string
+		actual        *Volume
+		expected      *Volume
+		changes       *Volume
+		expectedError error
+	}{
+		{
+			desc:   "actual nil all required fields set",
+			actual: nil,
+			expected: &Volume{
+				Name:             fi.PtrTo("name"),
+				AvailabilityZone: fi.PtrTo("zone-1"),
+				VolumeType:       fi.PtrTo("ssd"),
+				SizeGB:           fi.PtrTo(int64(10)),
+			},
+			expectedError: nil,
+		},
+		{
+			desc:   "actual nil required field SizeGB missing",
+			actual: nil,
+			expected: &Volume{
+				Name:             fi.PtrTo("name"),
+				AvailabilityZone: fi.PtrTo("zone-1"),
+				VolumeType:       fi.PtrTo("ssd"),
+			},
+			expectedError: fi.RequiredField("SizeGB"),
+		},
+		{
+			desc: "actual not nil size increased",
+			actual: &Volume{
+				Name:   fi.PtrTo("name"),
+				SizeGB: fi.PtrTo(int64(10)),
+			},
+			expected: &Volume{
+				Name:   fi.PtrTo("name"),
+				SizeGB: fi.PtrTo(int64(20)),
+			},
+			changes: &Volume{
+				SizeGB: fi.PtrTo(int64(20)),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil size decreased",
+			actual: &Volume{
+				Name:   fi.PtrTo("name"),
+				SizeGB: fi.PtrTo(int64(20)),
+			},
+			expected: &Volume{
+				Name:   fi.PtrTo("name"),
+				SizeGB: fi.PtrTo(int64(10)),
+			},
+			changes: &Volume{
+				SizeGB: fi.PtrTo(int64(10)),
+			},
+			expectedError: fmt.Errorf("cannot shrink Volume name from 20GB to 10GB"),
+		},
+		{
+			desc: "actual not nil volume type changed",
+			actual: &Volume{
+				Name:       fi.PtrTo("name"),
+				VolumeType: fi.PtrTo("hdd"),
+			},
+			expected: &Volume{
+				Name:       fi.PtrTo("name"),
+				VolumeType: fi.PtrTo("ssd"),
+			},
+			changes: &Volume{
+				VolumeType: fi.PtrTo("ssd"),
+			},
+			expectedError: nil,
+		},
+		{
+			desc: "actual not nil encryption required",
+			actual: &Volume{
+				Name:      fi.PtrTo("name"),
+				Encrypted: fi.PtrTo(false),
+			},
+			expected: &Volume{
+				Name:      fi.PtrTo("name"),
+				Encrypted: fi.PtrTo(true),
+			},
+			changes: &Volume{
+				Encrypted: fi.PtrTo(true),
+			},
+			expectedError: fmt.Errorf("Volume name is not encrypted, encryption cannot be enabled on an existing volume"),
+		},
+		{
+			desc: "actual not nil unchangeable field AvailabilityZone set",
+			actual: &Volume{
+				Name:             fi.PtrTo("name"),
+				AvailabilityZone: fi.PtrTo("zone-1"),
+			},
+			expected: &Volume{
+				Name:             fi.PtrTo("name"),
+				AvailabilityZone: fi.PtrTo("zone-2"),
+			},
+			changes: &Volume{
+				AvailabilityZone: fi.PtrTo("zone-2"),
+			},
+			expectedError: fi.CannotChangeField("AvailabilityZone"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var volume Volume
+			err := (&volume).CheckChanges(testCase.actual, testCase.expected, testCase.changes)
+
+			compareErrors(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func Test_Volume_RenderOpenstack_ExtendAndRetype(t *testing.T) {
+	cinder := mockblockstorage.CreateClient()
+	cloud := &openstack.MockCloud{
+		MockCinderClient: cinder,
+	}
+
+	// the volume has been extended once its status is read, Cinder rejects a retype while it is extending
+	var mutex sync.Mutex
+	volume := cinderv3.Volume{ID: "volume-id", Name: "volume", Size: 10, VolumeType: "old", Status: "in-use"}
+	var actions []string
+	cinder.Mux.HandleFunc("/volumes/volume-id", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if volume.Status == "extending" {
+			volume.Status = "in-use"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]cinderv3.Volume{"volume": volume})
+	})
+	cinder.Mux.HandleFunc("/volumes/volume-id/action", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var action map[string]map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+			t.Errorf("error decoding volume action: %v", err)
+		}
+		switch {
+		case action["os-extend"] != nil:
+			actions = append(actions, "extend")
+			volume.Size = int(action["os-extend"]["new_size"].(float64))
+			volume.Status = "extending"
+		case action["os-retype"] != nil:
+			actions = append(actions, "retype")
+			if volume.Status != "in-use" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			volume.VolumeType = action["os-retype"]["new_type"].(string)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	actual := &Volume{
+		ID:         fi.PtrTo("volume-id"),
+		Name:       fi.PtrTo("volume"),
+		SizeGB:     fi.PtrTo(int64(10)),
+		VolumeType: fi.PtrTo("old"),
+	}
+	expected := &Volume{
+		ID:         fi.PtrTo("volume-id"),
+		Name:       fi.PtrTo("volume"),
+		SizeGB:     fi.PtrTo(int64(20)),
+		VolumeType: fi.PtrTo("new"),
+	}
+	changes := &Volume{
+		SizeGB:     fi.PtrTo(int64(20)),
+		VolumeType: fi.PtrTo("new"),
+	}
+	if err := (&Volume{}).RenderOpenstack(&openstack.OpenstackAPITarget{Cloud: cloud}, actual, expected, changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(actions, []string{"extend", "retype"}) {
+		t.Errorf("expected the volume to be extended and retyped, got %v", actions)
+	}
+	if volume.Size != 20 || volume.VolumeType != "new" {
+		t.Errorf("expected the volume to have 20GB and type new, got %dGB and type %s", volume.Size, volume.VolumeType)
+	}
+}
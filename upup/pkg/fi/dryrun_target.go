@@ -143,7 +143,13 @@ func (t *DryRunTarget[T]) PrintReport(taskMap map[string]Task[T], out io.Writer)
 		var creates []*render[T]
 		var updates []*render[T]
 
+		// the changes of tasks that are parts of another task are shown in the tree of their parent
+		trees, inTree := buildPlanTrees(taskMap, t.changes)
+
 		for _, r := range t.changes {
+			if inTree[r.e] {
+				continue
+			}
 			if r.aIsNil {
 				creates = append(creates, r)
 			} else {
@@ -160,92 +166,32 @@ func (t *DryRunTarget[T]) PrintReport(taskMap map[string]Task[T], out io.Writer)
 			for _, r := range creates {
 				taskName := getTaskName(r.changes)
 				fmt.Fprintf(b, "  %s/%s\n", taskName, idForTask(taskMap, r.e))
-
-				changes := reflect.ValueOf(r.changes)
-				if changes.Kind() == reflect.Ptr && !changes.IsNil() {
-					changes = changes.Elem()
-				}
-
-				if changes.Kind() == reflect.Struct {
-					for i := 0; i < changes.NumField(); i++ {
-
-						field := changes.Field(i)
-
-						fieldName := changes.Type().Field(i).Name
-						if changes.Type().Field(i).PkgPath != "" {
-							// Not exported
-							continue
-						}
-
-						fieldValue := reflectutils.ValueAsString(field)
-
-						shouldPrint := true
-						if fieldName == "Name" {
-							// The field name is already printed above, no need to repeat it.
-							shouldPrint = false
-						}
-						if fieldName == "Lifecycle" {
-							// Lifecycle is a "system" field; no need to show it
-							shouldPrint = false
-						}
-						if fieldValue == "<nil>" || fieldValue == "<resource>" {
-							// Uninformative
-							shouldPrint = false
-						}
-						if fieldValue == "id:<nil>" {
-							// Uninformative, but we can often print the name instead
-							name := ""
-							if field.CanInterface() {
-								hasName, ok := field.Interface().(HasName)
-								if ok {
-									name = ValueOf(hasName.GetName())
-								}
-							}
-							if name != "" {
-								fieldValue = "name:" + name
-							} else {
-								shouldPrint = false
-							}
-						}
-						if shouldPrint {
-							fmt.Fprintf(b, "  \t%-20s\t%s\n", fieldName, fieldValue)
-						}
-					}
-				}
-
+				printCreatedFields(b, r.changes, "  ")
 				fmt.Fprintf(b, "\n")
 			}
 		}
 
 		if len(updates) != 0 {
 			fmt.Fprintf(b, "Will modify resources:\n")
-			// We can't use our reflection helpers here - we want corresponding values from a,e,c
 			for _, r := range updates {
-				changeList, err := buildChangeList(r.a, r.e, r.changes)
+				taskName := getTaskName(r.changes)
+				fmt.Fprintf(b, "  %s/%s\n", taskName, idForTask(taskMap, r.e))
+				ok, err := printChangedFields(b, r, "  ")
 				if err != nil {
 					return err
 				}
-				taskName := getTaskName(r.changes)
-				fmt.Fprintf(b, "  %s/%s\n", taskName, idForTask(taskMap, r.e))
-
-				if len(changeList) == 0 {
-					fmt.Fprintf(b, "   internal consistency error!\n")
-					fmt.Fprintf(b, "    actual: %+v\n", r.a)
-					fmt.Fprintf(b, "    expect: %+v\n", r.e)
-					fmt.Fprintf(b, "    change: %+v\n", r.changes)
+				if !ok {
 					continue
 				}
+				fmt.Fprintf(b, "\n")
+			}
+		}
 
-				for _, change := range changeList {
-					lines := strings.Split(change.Description, "\n")
-					if len(lines) == 1 {
-						fmt.Fprintf(b, "  \t%-20s\t%s\n", change.FieldName, change.Description)
-					} else {
-						fmt.Fprintf(b, "  \t%-20s\n", change.FieldName)
-						for _, line := range lines {
-							fmt.Fprintf(b, "  \t%-20s\t%s\n", "", line)
-						}
-					}
+		if len(trees) != 0 {
+			fmt.Fprintf(b, "Will create or modify resources and their parts:\n")
+			for _, tree := range trees {
+				if err := printPlanNode(b, taskMap, tree, "  "); err != nil {
+					return err
 				}
 				fmt.Fprintf(b, "\n")
 			}
@@ -303,6 +249,190 @@ func (t *DryRunTarget[T]) PrintReport(taskMap map[string]Task[T], out io.Writer)
 	return err
 }
 
+// printCreatedFields prints the fields of a created task
+func printCreatedFields[T SubContext](b *bytes.Buffer, c Task[T], indent string) {
+	changes := reflect.ValueOf(c)
+	if changes.Kind() == reflect.Ptr && !changes.IsNil() {
+		changes = changes.Elem()
+	}
+
+	if changes.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < changes.NumField(); i++ {
+
+		field := changes.Field(i)
+
+		fieldName := changes.Type().Field(i).Name
+		if changes.Type().Field(i).PkgPath != "" {
+			// Not exported
+			continue
+		}
+
+		fieldValue := reflectutils.ValueAsString(field)
+
+		shouldPrint := true
+		if fieldName == "Name" {
+			// The field name is already printed above, no need to repeat it.
+			shouldPrint = false
+		}
+		if fieldName == "Lifecycle" {
+			// Lifecycle is a "system" field; no need to show it
+			shouldPrint = false
+		}
+		if fieldValue == "<nil>" || fieldValue == "<resource>" {
+			// Uninformative
+			shouldPrint = false
+		}
+		if fieldValue == "id:<nil>" {
+			// Uninformative, but we can often print the name instead
+			name := ""
+			if field.CanInterface() {
+				hasName, ok := field.Interface().(HasName)
+				if ok {
+					name = ValueOf(hasName.GetName())
+				}
+			}
+			if name != "" {
+				fieldValue = "name:" + name
+			} else {
+				shouldPrint = false
+			}
+		}
+		if shouldPrint {
+			fmt.Fprintf(b, "%s\t%-20s\t%s\n", indent, fieldName, fieldValue)
+		}
+	}
+}
+
+// printChangedFields prints the changed fields of a modified task, it returns false if no change was found
+func printChangedFields[T SubContext](b *bytes.Buffer, r *render[T], indent string) (bool, error) {
+	// We can't use our reflection helpers here - we want corresponding values from a,e,c
+	changeList, err := buildChangeList(r.a, r.e, r.changes)
+	if err != nil {
+		return false, err
+	}
+
+	if len(changeList) == 0 {
+		fmt.Fprintf(b, "%s internal consistency error!\n", indent)
+		fmt.Fprintf(b, "%s  actual: %+v\n", indent, r.a)
+		fmt.Fprintf(b, "%s  expect: %+v\n", indent, r.e)
+		fmt.Fprintf(b, "%s  change: %+v\n", indent, r.changes)
+		return false, nil
+	}
+
+	for _, change := range changeList {
+		lines := strings.Split(change.Description, "\n")
+		if len(lines) == 1 {
+			fmt.Fprintf(b, "%s\t%-20s\t%s\n", indent, change.FieldName, change.Description)
+		} else {
+			fmt.Fprintf(b, "%s\t%-20s\n", indent, change.FieldName)
+			for _, line := range lines {
+				fmt.Fprintf(b, "%s\t%-20s\t%s\n", indent, "", line)
+			}
+		}
+	}
+	return true, nil
+}
+
+// planNode is a task of the dry-run report together with the tasks that are parts of it
+type planNode[T SubContext] struct {
+	task Task[T]
+	// render is the change of the task, nil if only its parts change
+	render   *render[T]
+	children []*planNode[T]
+}
+
+// buildPlanTrees groups the changes of the tasks implementing HasPlanParent under their parents. It returns the roots
+// of the trees and the tasks that are part of a tree.
+func buildPlanTrees[T SubContext](taskMap map[string]Task[T], changes []*render[T]) ([]*planNode[T], map[Task[T]]bool) {
+	renders := make(map[Task[T]]*render[T])
+	for _, r := range changes {
+		renders[r.e] = r
+	}
+
+	nodes := make(map[Task[T]]*planNode[T])
+	node := func(task Task[T]) (*planNode[T], bool) {
+		if n, ok := nodes[task]; ok {
+			return n, false
+		}
+		n := &planNode[T]{task: task, render: renders[task]}
+		nodes[task] = n
+		return n, true
+	}
+	parentOf := func(task Task[T]) Task[T] {
+		if hasParent, ok := task.(HasPlanParent[T]); ok {
+			return hasParent.PlanParent(taskMap)
+		}
+		return nil
+	}
+
+	var roots []*planNode[T]
+	attached := make(map[Task[T]]bool)
+	for _, r := range changes {
+		parent := parentOf(r.e)
+		if parent == nil || attached[r.e] {
+			continue
+		}
+		child, _ := node(r.e)
+		// walk up to the root, stopping at the first task that is already attached to its parent
+		path := map[Task[T]]bool{r.e: true}
+		for parent != nil && !attached[child.task] {
+			if path[parent] {
+				klog.Warningf("tasks %s form a cycle of parents, showing it without its parent", buildTaskKey(child.task))
+				break
+			}
+			path[parent] = true
+			p, created := node(parent)
+			p.children = append(p.children, child)
+			attached[child.task] = true
+			grandparent := parentOf(parent)
+			if grandparent == nil && created {
+				roots = append(roots, p)
+			}
+			child, parent = p, grandparent
+		}
+	}
+
+	inTree := make(map[Task[T]]bool)
+	var sortNodes func(nodes []*planNode[T])
+	sortNodes = func(nodes []*planNode[T]) {
+		sort.Slice(nodes, func(i, j int) bool {
+			return buildTaskKey(nodes[i].task) < buildTaskKey(nodes[j].task)
+		})
+		for _, n := range nodes {
+			inTree[n.task] = true
+			sortNodes(n.children)
+		}
+	}
+	sortNodes(roots)
+
+	return roots, inTree
+}
+
+// printPlanNode prints the changes of a task, followed by the changes of its parts indented below it
+func printPlanNode[T SubContext](b *bytes.Buffer, taskMap map[string]Task[T], n *planNode[T], indent string) error {
+	key := getTaskName(n.task) + "/" + idForTask(taskMap, n.task)
+	switch {
+	case n.render == nil:
+		fmt.Fprintf(b, "%s%s\n", indent, key)
+	case n.render.aIsNil:
+		fmt.Fprintf(b, "%s%s (create)\n", indent, key)
+		printCreatedFields(b, n.render.changes, indent)
+	default:
+		fmt.Fprintf(b, "%s%s (modify)\n", indent, key)
+		if _, err := printChangedFields(b, n.render, indent); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.children {
+		if err := printPlanNode(b, taskMap, child, indent+"    "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type change struct {
 	FieldName   string
 	Description string
@@ -91,3 +91,74 @@ func Test_DryrunTarget_PrintReport(t *testing.T) {
 	err = target.PrintReport(tasks, &out)
 	assert.NoError(t, err, "target.PrintReport()")
 }
+
+func (t *testTask) GetName() *string {
+	return t.Name
+}
+
+// testPartTask is a task shown below its parent in the dry-run report
+type testPartTask struct {
+	Name      *string
+	Lifecycle Lifecycle
+	Parent    *string
+	Port      *int
+}
+
+var _ HasPlanParent[CloudupSubContext] = &testPartTask{}
+
+func (*testPartTask) Run(_ *CloudupContext) error {
+	panic("not implemented")
+}
+
+func (t *testPartTask) GetName() *string {
+	return t.Name
+}
+
+func (t *testPartTask) PlanParent(tasks map[string]CloudupTask) CloudupTask {
+	return tasks[ValueOf(t.Parent)]
+}
+
+func Test_DryrunTarget_PrintReport_PlanTree(t *testing.T) {
+	builder := assets.NewAssetBuilder(vfs.Context, nil, "1.17.3", false)
+	target := newDryRunTarget[CloudupSubContext](builder, nil)
+
+	tasks := map[string]CloudupTask{
+		"testTask/lb":         &testTask{Name: PtrTo("lb")},
+		"testTask/other":      &testTask{Name: PtrTo("other")},
+		"testPartTask/pool":   &testPartTask{Name: PtrTo("pool"), Parent: PtrTo("testPartTask/https"), Port: PtrTo(443)},
+		"testPartTask/https":  &testPartTask{Name: PtrTo("https"), Parent: PtrTo("testTask/lb"), Port: PtrTo(443)},
+		"testPartTask/http":   &testPartTask{Name: PtrTo("http"), Parent: PtrTo("testTask/lb"), Port: PtrTo(80)},
+		"testPartTask/orphan": &testPartTask{Name: PtrTo("orphan"), Port: PtrTo(8080)},
+	}
+	render := func(a, e CloudupTask) {
+		changes := reflect.New(reflect.TypeOf(e).Elem()).Interface().(CloudupTask)
+		BuildChanges(a, e, changes)
+		assert.NoError(t, target.Render(a, e, changes), "target.Render()")
+	}
+	render((*testTask)(nil), tasks["testTask/other"])
+	render((*testPartTask)(nil), tasks["testPartTask/pool"])
+	render((*testPartTask)(nil), tasks["testPartTask/orphan"])
+	render(&testPartTask{Name: PtrTo("http"), Parent: PtrTo("testTask/lb"), Port: PtrTo(8080)}, tasks["testPartTask/http"])
+
+	var out bytes.Buffer
+	err := target.PrintReport(tasks, &out)
+	assert.NoError(t, err, "target.PrintReport()")
+
+	expected := `Will create resources:
+  testPartTask/orphan
+  	Port                	8080
+
+  testTask/other
+
+Will create or modify resources and their parts:
+  testTask/lb
+      testPartTask/http (modify)
+      	Port                	 8080 -> 80
+      testPartTask/https
+          testPartTask/pool (create)
+          	Parent              	testPartTask/https
+          	Port                	443
+
+`
+	assert.Equal(t, expected, out.String())
+}
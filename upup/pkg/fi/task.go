@@ -79,6 +79,14 @@ type HasCheckExisting[T SubContext] interface {
 type NodeupHasCheckExisting = HasCheckExisting[NodeupSubContext]
 type CloudupHasCheckExisting = HasCheckExisting[CloudupSubContext]
 
+// HasPlanParent is implemented by tasks that are parts of another task, e.g. the listeners of a loadbalancer.
+// The dry-run report shows their changes indented below their parent, so related changes are reviewed together.
+type HasPlanParent[T SubContext] interface {
+	Task[T]
+	// PlanParent returns the task this task is part of, or nil if it is not in the tasks
+	PlanParent(tasks map[string]Task[T]) Task[T]
+}
+
 // ModelBuilder allows for plugins that configure an aspect of the model, based on the configuration
 type ModelBuilder[T SubContext] interface {
 	Build(context *ModelBuilderContext[T]) error